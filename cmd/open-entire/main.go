@@ -3,6 +3,11 @@ package main
 import (
 	"os"
 
+	// Register agent integrations
+	_ "github.com/yibudak/open-entire/internal/agent/aider"
+	_ "github.com/yibudak/open-entire/internal/agent/claude"
+	_ "github.com/yibudak/open-entire/internal/agent/codex"
+	_ "github.com/yibudak/open-entire/internal/agent/gemini"
 	"github.com/yibudak/open-entire/internal/cli"
 )
 
@@ -2,19 +2,108 @@ package agent
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
 
 	"github.com/yibudak/open-entire/pkg/types"
 )
 
+// ActiveWindow is how recently a session must have been active to count as
+// currently running.
+const ActiveWindow = 5 * time.Minute
+
 // Agent is the interface for AI agent integrations.
 type Agent interface {
 	Name() string
 	Detect(repoDir string) (sessionID string, err error)
+	SessionsSince(repoDir string, since time.Time) ([]types.SessionActivity, error)
 	ParseSession(sessionID string, repoDir string) (*types.SessionData, error)
 	SessionPaths(repoDir string) types.AgentPaths
 }
 
-var registry = map[string]Agent{}
+// CommitRecognizer is implemented by agents that make their own commits.
+type CommitRecognizer interface {
+	// IsAgentCommit reports whether the agent itself authored the commit.
+	IsAgentCommit(repoDir, commitHash string) bool
+}
+
+// DeltaParser is implemented by agents that can parse only what a session
+// appended since it was last checkpointed.
+type DeltaParser interface {
+	// ParseSessionDelta returns the part of the session not yet checkpointed,
+	// with that slice of the transcript in Transcript. Calling advance marks
+	// it as checkpointed, so the next delta starts where this one ended.
+	ParseSessionDelta(sessionID string, repoDir string) (data *types.SessionData, advance func() error, err error)
+}
+
+// PendingChecker is implemented by delta parsers that can tell, without
+// parsing, whether a session has grown since it was last checkpointed.
+type PendingChecker interface {
+	// HasPending reports whether the session may hold something not yet
+	// checkpointed. It must be cheap, as commit hooks call it.
+	HasPending(sessionID string, repoDir string) bool
+}
+
+// TranscriptParser is implemented by agents that can parse a transcript
+// file as stored in a checkpoint's full.jsonl.
+type TranscriptParser interface {
+	ParseTranscript(path string) (*types.SessionData, error)
+}
+
+// TranscriptRestorer is implemented by agents that can put a checkpointed
+// transcript back where the agent looks for it, so the session can be
+// resumed.
+type TranscriptRestorer interface {
+	// RestoreTranscript writes a session's transcript and returns its path.
+	RestoreTranscript(sessionID, repoDir string, transcript []byte) (path string, err error)
+}
+
+// Agent hook events, as `open-entire _agent-hook` receives them.
+const (
+	HookSessionStart = "session-start"
+	HookPromptSubmit = "user-prompt-submit"
+	HookStop         = "stop"
+	HookSubagentStop = "subagent-stop"
+	HookSessionEnd   = "session-end"
+)
+
+// HookEvent is what an agent's own hook reports about one of its sessions.
+type HookEvent struct {
+	Name           string
+	SessionID      string
+	TranscriptPath string
+	// Dir is the directory the agent is running in.
+	Dir string
+}
+
+// HookIntegration is implemented by agents whose own hooks can report
+// session events, so sessions need not be guessed from transcript
+// modification times.
+type HookIntegration interface {
+	// InstallHooks configures the agent to run `open-entire _agent-hook`
+	// on session events in the repository. Reinstalling replaces the
+	// earlier entries.
+	InstallHooks(repoDir string) error
+	// RemoveHooks removes what InstallHooks added, leaving other hooks.
+	RemoveHooks(repoDir string) error
+	// ParseHookEvent reads the payload the agent passes its hooks on stdin.
+	ParseHookEvent(name string, payload io.Reader) (*HookEvent, error)
+}
+
+// Detection is an agent session found to be active in a repository.
+type Detection struct {
+	Agent    Agent
+	Priority int
+	types.SessionActivity
+}
+
+var (
+	registry   = map[string]Agent{}
+	priorities = map[string]int{}
+)
 
 // Register adds an agent to the registry.
 func Register(a Agent) {
@@ -30,18 +119,96 @@ func Get(name string) (Agent, error) {
 	return a, nil
 }
 
-// DetectAny tries all registered agents and returns the first match.
-func DetectAny(repoDir string) (Agent, string, error) {
-	for _, a := range registry {
-		sessionID, err := a.Detect(repoDir)
-		if err == nil && sessionID != "" {
-			return a, sessionID, nil
+// SetPriority sets an agent's priority. Sessions of agents with a higher
+// priority come first, however recently the others were active.
+func SetPriority(name string, priority int) {
+	priorities[name] = priority
+}
+
+// Priority returns an agent's priority (default 0).
+func Priority(name string) int {
+	return priorities[name]
+}
+
+// DetectActive returns every session active since the given time across all
+// registered agents, ordered by agent priority and then most recently active
+// first. Ties are broken by agent name and session ID, so the order is
+// deterministic.
+func DetectActive(repoDir string, since time.Time) []Detection {
+	var found []Detection
+	for name, a := range registry {
+		sessions, err := a.SessionsSince(repoDir, since)
+		if err != nil {
+			slog.Debug("no sessions for agent", "agent", name, "error", err)
+			continue
+		}
+		for _, s := range sessions {
+			found = append(found, Detection{
+				Agent:           a,
+				Priority:        Priority(name),
+				SessionActivity: s,
+			})
 		}
 	}
-	return nil, "", fmt.Errorf("no active agent detected")
+
+	sort.Slice(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if !a.LastActivity.Equal(b.LastActivity) {
+			return a.LastActivity.After(b.LastActivity)
+		}
+		if a.Agent.Name() != b.Agent.Name() {
+			return a.Agent.Name() < b.Agent.Name()
+		}
+		return a.SessionID < b.SessionID
+	})
+
+	return found
+}
+
+// DetectRunning returns the sessions active within ActiveWindow.
+func DetectRunning(repoDir string) []Detection {
+	return DetectActive(repoDir, time.Now().Add(-ActiveWindow))
 }
 
 // All returns all registered agents.
 func All() map[string]Agent {
 	return registry
 }
+
+// ParseStoredTranscript parses a transcript as stored in a checkpoint with
+// the parser of the agent that wrote it.
+func ParseStoredTranscript(agentName string, transcript []byte) (*types.SessionData, error) {
+	a, err := Get(agentName)
+	if err != nil {
+		return nil, err
+	}
+	parser, ok := a.(TranscriptParser)
+	if !ok {
+		return nil, fmt.Errorf("agent %s cannot parse stored transcripts", agentName)
+	}
+
+	// Agent parsers read files
+	f, err := os.CreateTemp("", "open-entire-transcript-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(transcript)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := parser.ParseTranscript(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	data.AgentName = agentName
+	data.TranscriptPath = ""
+	return data, nil
+}
@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+type fakeAgent struct {
+	name     string
+	sessions []types.SessionActivity
+	err      error
+}
+
+func (f *fakeAgent) Name() string { return f.name }
+
+func (f *fakeAgent) Detect(repoDir string) (string, error) { return "", nil }
+
+func (f *fakeAgent) SessionsSince(repoDir string, since time.Time) ([]types.SessionActivity, error) {
+	return f.sessions, f.err
+}
+
+func (f *fakeAgent) ParseSession(sessionID string, repoDir string) (*types.SessionData, error) {
+	return nil, nil
+}
+
+func (f *fakeAgent) SessionPaths(repoDir string) types.AgentPaths { return types.AgentPaths{} }
+
+func withRegistry(t *testing.T, agents ...Agent) {
+	t.Helper()
+	oldRegistry, oldPriorities := registry, priorities
+	registry, priorities = map[string]Agent{}, map[string]int{}
+	t.Cleanup(func() { registry, priorities = oldRegistry, oldPriorities })
+	for _, a := range agents {
+		Register(a)
+	}
+}
+
+func TestDetectActiveOrdersByActivity(t *testing.T) {
+	now := time.Now()
+	withRegistry(t,
+		&fakeAgent{name: "alpha", sessions: []types.SessionActivity{
+			{SessionID: "a-old", LastActivity: now.Add(-3 * time.Minute), Reason: "old"},
+		}},
+		&fakeAgent{name: "beta", sessions: []types.SessionActivity{
+			{SessionID: "b-new", LastActivity: now.Add(-1 * time.Minute), Reason: "new"},
+			{SessionID: "b-mid", LastActivity: now.Add(-2 * time.Minute), Reason: "mid"},
+		}},
+		&fakeAgent{name: "broken", err: errors.New("no sessions directory")},
+	)
+
+	found := DetectActive("/repo", now.Add(-time.Hour))
+	var ids []string
+	for _, d := range found {
+		ids = append(ids, d.SessionID)
+	}
+	assert.Equal(t, []string{"b-new", "b-mid", "a-old"}, ids)
+	assert.Equal(t, "beta", found[0].Agent.Name())
+	assert.Equal(t, "new", found[0].Reason)
+}
+
+func TestDetectActiveOrdersByPriorityFirst(t *testing.T) {
+	now := time.Now()
+	withRegistry(t,
+		&fakeAgent{name: "alpha", sessions: []types.SessionActivity{
+			{SessionID: "a-new", LastActivity: now.Add(-1 * time.Minute)},
+		}},
+		&fakeAgent{name: "beta", sessions: []types.SessionActivity{
+			{SessionID: "b-mid", LastActivity: now.Add(-2 * time.Minute)},
+			{SessionID: "b-old", LastActivity: now.Add(-3 * time.Minute)},
+		}},
+	)
+	SetPriority("beta", 10)
+
+	var ids []string
+	for _, d := range DetectActive("/repo", time.Time{}) {
+		ids = append(ids, d.SessionID)
+	}
+	assert.Equal(t, []string{"b-mid", "b-old", "a-new"}, ids)
+}
+
+func TestDetectActiveTieBreaksByPriorityThenName(t *testing.T) {
+	at := time.Now().Add(-time.Minute)
+	withRegistry(t,
+		&fakeAgent{name: "zeta", sessions: []types.SessionActivity{{SessionID: "z", LastActivity: at}}},
+		&fakeAgent{name: "alpha", sessions: []types.SessionActivity{{SessionID: "a", LastActivity: at}}},
+		&fakeAgent{name: "mid", sessions: []types.SessionActivity{{SessionID: "m", LastActivity: at}}},
+	)
+	SetPriority("zeta", 10)
+
+	for i := 0; i < 5; i++ {
+		found := DetectActive("/repo", time.Time{})
+		var names []string
+		for _, d := range found {
+			names = append(names, d.Agent.Name())
+		}
+		assert.Equal(t, []string{"zeta", "alpha", "mid"}, names)
+	}
+	assert.Equal(t, 10, Priority("zeta"))
+	assert.Equal(t, 0, Priority("alpha"))
+}
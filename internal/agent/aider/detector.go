@@ -0,0 +1,68 @@
+package aider
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+const (
+	agentName = "aider"
+	// Consider sessions active if modified within this window
+	activeWindow = 5 * time.Minute
+)
+
+// Detect checks if Aider is active for the given repo.
+// Returns the ID of the latest session if the history was recently written.
+func Detect(repoDir string) (string, error) {
+	recent, err := SessionsSince(repoDir, time.Now().Add(-activeWindow))
+	if err != nil {
+		return "", err
+	}
+	if len(recent) == 0 {
+		return "", fmt.Errorf("no active Aider session found")
+	}
+	return recent[0].SessionID, nil
+}
+
+// SessionsSince returns the sessions that were active after since, most
+// recent first. A session runs until the next one starts; the last one runs
+// until the history file was last modified.
+func SessionsSince(repoDir string, since time.Time) ([]types.SessionActivity, error) {
+	path := HistoryFile(repoDir)
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no Aider chat history found")
+		}
+		return nil, err
+	}
+	if !info.ModTime().After(since) {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sessions := SplitSessions(string(data))
+
+	var recent []types.SessionActivity
+	for i := len(sessions) - 1; i >= 0; i-- {
+		end, reason := info.ModTime(), "chat history modified"
+		if i+1 < len(sessions) {
+			end, reason = sessions[i+1].StartedAt, "ran until the next Aider session started"
+		}
+		if !end.After(since) {
+			break
+		}
+		recent = append(recent, types.SessionActivity{
+			SessionID:    sessions[i].ID,
+			LastActivity: end,
+			Reason:       reason,
+		})
+	}
+	return recent, nil
+}
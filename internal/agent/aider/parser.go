@@ -0,0 +1,283 @@
+package aider
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+const (
+	sessionHeader = "# aider chat started at "
+	promptPrefix  = "#### "
+	headerLayout  = "2006-01-02 15:04:05"
+	inputLayout   = "2006-01-02 15:04:05.999999"
+	// idLayout turns a session start time into a file-safe session ID
+	idLayout = "2006-01-02T15-04-05"
+)
+
+var (
+	tokenCountRe = regexp.MustCompile(`([\d.]+)([kM]?) (sent|received|cache write|cache hit)`)
+	commitRe     = regexp.MustCompile(`^Commit ([0-9a-f]{7,40})\b`)
+)
+
+// ChatSession is one Aider run within the chat history.
+type ChatSession struct {
+	ID        string
+	StartedAt time.Time
+	Raw       string
+}
+
+// InputEntry is one prompt recorded in the input history.
+type InputEntry struct {
+	Timestamp time.Time
+	Content   string
+}
+
+// SplitSessions splits a chat history into sessions, one per
+// "# aider chat started at" header. A session is named by its start time,
+// or by the header's byte offset in the history when the time cannot be
+// parsed or another session already has it; the history is only appended
+// to, so offsets stay put.
+func SplitSessions(history string) []ChatSession {
+	var sessions []ChatSession
+	var current *ChatSession
+	var b strings.Builder
+	seen := make(map[string]bool)
+	offset := 0
+
+	flush := func() {
+		if current != nil {
+			current.Raw = b.String()
+			sessions = append(sessions, *current)
+		}
+		b.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(history, "\n") {
+		trimmed := strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(trimmed, sessionHeader) {
+			flush()
+			started, err := time.ParseInLocation(headerLayout, strings.TrimSpace(strings.TrimPrefix(trimmed, sessionHeader)), time.Local)
+			id := started.Format(idLayout)
+			if err != nil || seen[id] {
+				id = fmt.Sprintf("offset-%d", offset)
+			}
+			seen[id] = true
+			current = &ChatSession{
+				ID:        id,
+				StartedAt: started,
+			}
+		}
+		if current != nil {
+			b.WriteString(line)
+		}
+		offset += len(line)
+	}
+	flush()
+
+	return sessions
+}
+
+// ParseChat maps one session's markdown onto SessionData.
+// "#### " lines are user prompts, "> " lines are Aider's own output and
+// everything else is the model's response.
+func ParseChat(raw string) *types.SessionData {
+	session := &types.SessionData{
+		AgentName: agentName,
+	}
+
+	var prompt, response []string
+	var usage types.TokenUsage
+	inPrompt := false
+	files := make(map[string]bool)
+
+	flushResponse := func() {
+		text := strings.TrimSpace(strings.Join(response, "\n"))
+		if text != "" || usage.APICalls > 0 {
+			session.Responses = append(session.Responses, types.Response{
+				Content:    text,
+				RequestID:  turnID(len(session.Prompts)),
+				TokenUsage: usage,
+			})
+		}
+		response = nil
+		usage = types.TokenUsage{}
+	}
+	flushPrompt := func() {
+		if len(prompt) > 0 {
+			session.Prompts = append(session.Prompts, types.Prompt{
+				Content:   strings.Join(prompt, "\n"),
+				RequestID: turnID(len(session.Prompts) + 1),
+			})
+		}
+		prompt = nil
+	}
+
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimRight(line, " \r")
+
+		switch {
+		case strings.HasPrefix(line, sessionHeader):
+			continue
+
+		case strings.HasPrefix(line, promptPrefix):
+			if !inPrompt {
+				flushResponse()
+				inPrompt = true
+			}
+			prompt = append(prompt, strings.TrimPrefix(line, promptPrefix))
+
+		case strings.HasPrefix(line, ">"):
+			flushPrompt()
+			inPrompt = false
+			out := strings.TrimSpace(strings.TrimPrefix(line, ">"))
+			switch {
+			case strings.HasPrefix(out, "Tokens:"):
+				addTokens(&usage, out)
+			case strings.HasPrefix(out, "Applied edit to "):
+				files[strings.TrimPrefix(out, "Applied edit to ")] = true
+			}
+
+		default:
+			if inPrompt {
+				flushPrompt()
+				inPrompt = false
+			}
+			if len(session.Prompts) > 0 {
+				response = append(response, line)
+			}
+		}
+	}
+	flushPrompt()
+	flushResponse()
+
+	for _, r := range session.Responses {
+		session.TokenUsage.InputTokens += r.TokenUsage.InputTokens
+		session.TokenUsage.OutputTokens += r.TokenUsage.OutputTokens
+		session.TokenUsage.CacheCreation += r.TokenUsage.CacheCreation
+		session.TokenUsage.CacheReads += r.TokenUsage.CacheReads
+		session.TokenUsage.APICalls += r.TokenUsage.APICalls
+	}
+	for f := range files {
+		session.FilesChanged = append(session.FilesChanged, f)
+	}
+	sort.Strings(session.FilesChanged)
+
+	return session
+}
+
+// CommitHashes returns the hashes of the commits Aider reports making.
+func CommitHashes(history string) []string {
+	var hashes []string
+	for _, line := range strings.Split(history, "\n") {
+		out := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), ">"))
+		if m := commitRe.FindStringSubmatch(out); m != nil {
+			hashes = append(hashes, m[1])
+		}
+	}
+	return hashes
+}
+
+// ParseInputHistory parses .aider.input.history, where each entry is a
+// "# <timestamp>" line followed by "+"-prefixed prompt lines.
+func ParseInputHistory(data string) []InputEntry {
+	var entries []InputEntry
+	var current *InputEntry
+	var lines []string
+
+	flush := func() {
+		if current != nil {
+			current.Content = strings.Join(lines, "\n")
+			entries = append(entries, *current)
+		}
+		lines = nil
+	}
+
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "# "):
+			flush()
+			ts, _ := time.ParseInLocation(inputLayout, strings.TrimPrefix(line, "# "), time.Local)
+			current = &InputEntry{Timestamp: ts}
+		case strings.HasPrefix(line, "+") && current != nil:
+			lines = append(lines, strings.TrimPrefix(line, "+"))
+		}
+	}
+	flush()
+
+	return entries
+}
+
+// applyTimestamps copies prompt times from the input history, matching
+// prompts in order by content. Responses take the time of their prompt.
+func applyTimestamps(session *types.SessionData, entries []InputEntry) {
+	cursor := 0
+	for i := range session.Prompts {
+		for j := cursor; j < len(entries); j++ {
+			if entries[j].Timestamp.Before(session.StartedAt) {
+				continue
+			}
+			if entries[j].Content == session.Prompts[i].Content {
+				session.Prompts[i].Timestamp = entries[j].Timestamp
+				cursor = j + 1
+				break
+			}
+		}
+	}
+
+	promptTimes := make(map[string]time.Time, len(session.Prompts))
+	for _, p := range session.Prompts {
+		promptTimes[p.RequestID] = p.Timestamp
+	}
+	for i := range session.Responses {
+		session.Responses[i].Timestamp = promptTimes[session.Responses[i].RequestID]
+	}
+}
+
+// turnID links a response to the prompt that started its turn.
+func turnID(n int) string {
+	return fmt.Sprintf("turn-%d", n)
+}
+
+// addTokens parses a "Tokens: 2.5k sent, 1.1k cache write, 3k cache hit,
+// 180 received." report into usage.
+func addTokens(usage *types.TokenUsage, line string) {
+	matched := false
+	for _, m := range tokenCountRe.FindAllStringSubmatch(line, -1) {
+		n := parseCount(m[1], m[2])
+		switch m[3] {
+		case "sent":
+			usage.InputTokens += n
+		case "received":
+			usage.OutputTokens += n
+		case "cache write":
+			usage.CacheCreation += n
+		case "cache hit":
+			usage.CacheReads += n
+		}
+		matched = true
+	}
+	if matched {
+		usage.APICalls++
+	}
+}
+
+func parseCount(num, suffix string) int {
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	switch suffix {
+	case "k":
+		f *= 1e3
+	case "M":
+		f *= 1e6
+	}
+	return int(f + 0.5)
+}
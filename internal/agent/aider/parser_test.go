@@ -0,0 +1,92 @@
+package aider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestSplitSessions(t *testing.T) {
+	sessions := SplitSessions(readFixture(t, "chat.history.md"))
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "2025-01-15T09-00-00", sessions[0].ID)
+	assert.Equal(t, "2025-01-15T10-00-00", sessions[1].ID)
+	assert.Equal(t, 10, sessions[1].StartedAt.Hour())
+	assert.Contains(t, sessions[0].Raw, "what does main.go do?")
+	assert.NotContains(t, sessions[0].Raw, "farewell")
+}
+
+func TestSplitSessionsUnparsableHeaders(t *testing.T) {
+	history := "# aider chat started at yesterday\n\n#### one\n" +
+		"# aider chat started at sometime\n\n#### two\n" +
+		"# aider chat started at 2025-01-15 09:00:00\n\n#### three\n" +
+		"# aider chat started at 2025-01-15 09:00:00\n\n#### four\n"
+
+	sessions := SplitSessions(history)
+	require.Len(t, sessions, 4)
+	assert.Equal(t, "offset-0", sessions[0].ID)
+	assert.Equal(t, "offset-44", sessions[1].ID)
+	assert.Equal(t, "2025-01-15T09-00-00", sessions[2].ID)
+	assert.Equal(t, "offset-143", sessions[3].ID, "a repeated start time gets an ID of its own")
+	assert.Contains(t, sessions[1].Raw, "two")
+
+	// IDs stay the same as the history grows
+	again := SplitSessions(history + "# aider chat started at later\n")
+	require.Len(t, again, 5)
+	assert.Equal(t, sessions[1].ID, again[1].ID)
+}
+
+func TestParseChat(t *testing.T) {
+	sessions := SplitSessions(readFixture(t, "chat.history.md"))
+	session := ParseChat(sessions[1].Raw)
+
+	assert.Equal(t, "aider", session.AgentName)
+	require.Len(t, session.Prompts, 3)
+	assert.Equal(t, "add a farewell function\nand call it from main", session.Prompts[0].Content)
+	assert.Equal(t, "/run go test ./...", session.Prompts[1].Content)
+	assert.Equal(t, "thanks", session.Prompts[2].Content)
+
+	require.Len(t, session.Responses, 2)
+	assert.Contains(t, session.Responses[0].Content, "I'll add `farewell` to main.go.")
+	assert.NotContains(t, session.Responses[0].Content, "Applied edit")
+	assert.Equal(t, "turn-1", session.Responses[0].RequestID)
+	assert.Equal(t, "You're welcome!", session.Responses[1].Content)
+	assert.Equal(t, "turn-3", session.Responses[1].RequestID)
+
+	first := session.Responses[0].TokenUsage
+	assert.Equal(t, 2500, first.InputTokens)
+	assert.Equal(t, 1100, first.CacheCreation)
+	assert.Equal(t, 3000, first.CacheReads)
+	assert.Equal(t, 180, first.OutputTokens)
+
+	assert.Equal(t, 5600, session.TokenUsage.InputTokens)
+	assert.Equal(t, 192, session.TokenUsage.OutputTokens)
+	assert.Equal(t, 2, session.TokenUsage.APICalls)
+	assert.Equal(t, []string{"main.go"}, session.FilesChanged)
+}
+
+func TestApplyTimestamps(t *testing.T) {
+	sessions := SplitSessions(readFixture(t, "chat.history.md"))
+	session := ParseChat(sessions[1].Raw)
+	session.StartedAt = sessions[1].StartedAt
+
+	applyTimestamps(session, ParseInputHistory(readFixture(t, "input.history")))
+
+	assert.Equal(t, "10:00:10", session.Prompts[0].Timestamp.Format("15:04:05"))
+	assert.Equal(t, "10:03:00", session.Prompts[2].Timestamp.Format("15:04:05"))
+	assert.Equal(t, session.Prompts[2].Timestamp, session.Responses[1].Timestamp)
+}
+
+func TestCommitHashes(t *testing.T) {
+	assert.Equal(t, []string{"1a2b3c4"}, CommitHashes(readFixture(t, "chat.history.md")))
+}
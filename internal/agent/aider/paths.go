@@ -0,0 +1,13 @@
+package aider
+
+import "path/filepath"
+
+// HistoryFile returns the path of Aider's chat history in a repo.
+func HistoryFile(repoDir string) string {
+	return filepath.Join(repoDir, ".aider.chat.history.md")
+}
+
+// InputHistoryFile returns the path of Aider's prompt input history in a repo.
+func InputHistoryFile(repoDir string) string {
+	return filepath.Join(repoDir, ".aider.input.history")
+}
@@ -0,0 +1,105 @@
+package aider
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yibudak/open-entire/internal/agent"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// AiderAgent implements the Agent interface for Aider.
+type AiderAgent struct{}
+
+func init() {
+	agent.Register(&AiderAgent{})
+}
+
+func (a *AiderAgent) Name() string {
+	return agentName
+}
+
+func (a *AiderAgent) Detect(repoDir string) (string, error) {
+	return Detect(repoDir)
+}
+
+func (a *AiderAgent) SessionsSince(repoDir string, since time.Time) ([]types.SessionActivity, error) {
+	return SessionsSince(repoDir, since)
+}
+
+func (a *AiderAgent) ParseSession(sessionID string, repoDir string) (*types.SessionData, error) {
+	path := HistoryFile(repoDir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Aider history: %w", err)
+	}
+
+	for _, cs := range SplitSessions(string(data)) {
+		if cs.ID != sessionID {
+			continue
+		}
+
+		session := ParseChat(cs.Raw)
+		session.ID = sessionID
+		session.StartedAt = cs.StartedAt
+		session.TranscriptPath = path
+		session.Transcript = []byte(cs.Raw)
+
+		if input, err := os.ReadFile(InputHistoryFile(repoDir)); err == nil {
+			applyTimestamps(session, ParseInputHistory(string(input)))
+		}
+		return session, nil
+	}
+
+	return nil, fmt.Errorf("session %s not found in Aider history", sessionID)
+}
+
+// ParseTranscript parses a stored chat session, which is the session's
+// section of the chat history.
+func (a *AiderAgent) ParseTranscript(path string) (*types.SessionData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseChat(string(data)), nil
+}
+
+func (a *AiderAgent) SessionPaths(repoDir string) types.AgentPaths {
+	return types.AgentPaths{
+		SessionDir: repoDir,
+		Pattern:    ".aider.chat.history.md",
+	}
+}
+
+// IsAgentCommit recognises Aider's auto-commits. Aider marks the author or
+// committer name with "(aider)" or adds a co-author trailer; commits it
+// reported in the chat history are recognised as well.
+func (a *AiderAgent) IsAgentCommit(repoDir, commitHash string) bool {
+	repo, err := git.Open(repoDir)
+	if err != nil {
+		return false
+	}
+
+	author, committer, err := repo.CommitIdentities(commitHash)
+	if err == nil && (strings.HasSuffix(author, "(aider)") || strings.HasSuffix(committer, "(aider)")) {
+		return true
+	}
+
+	if msg, err := repo.CommitMessage(commitHash); err == nil {
+		if strings.Contains(strings.ToLower(msg), "co-authored-by: aider") {
+			return true
+		}
+	}
+
+	if data, err := os.ReadFile(HistoryFile(repoDir)); err == nil {
+		for _, h := range CommitHashes(string(data)) {
+			if strings.HasPrefix(commitHash, h) {
+				return true
+			}
+		}
+	}
+	return false
+}
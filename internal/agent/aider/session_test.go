@@ -0,0 +1,95 @@
+package aider
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func gitCmd(t *testing.T, dir string, env []string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
+func setupRepo(t *testing.T) string {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "Dev")
+	t.Setenv("GIT_AUTHOR_EMAIL", "dev@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Dev")
+	t.Setenv("GIT_COMMITTER_EMAIL", "dev@example.com")
+
+	dir := t.TempDir()
+	gitCmd(t, dir, nil, "init", "-q", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".aider.chat.history.md"), []byte(readFixture(t, "chat.history.md")), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".aider.input.history"), []byte(readFixture(t, "input.history")), 0o644))
+	return dir
+}
+
+func TestIsAgentCommit(t *testing.T) {
+	dir := setupRepo(t)
+	a := &AiderAgent{}
+
+	gitCmd(t, dir, nil, "commit", "-q", "--allow-empty", "-m", "human commit")
+	assert.False(t, a.IsAgentCommit(dir, gitCmd(t, dir, nil, "rev-parse", "HEAD")))
+
+	gitCmd(t, dir, []string{"GIT_AUTHOR_NAME=Dev (aider)"}, "commit", "-q", "--allow-empty", "-m", "feat: by aider")
+	assert.True(t, a.IsAgentCommit(dir, gitCmd(t, dir, nil, "rev-parse", "HEAD")))
+
+	gitCmd(t, dir, nil, "commit", "-q", "--allow-empty", "-m", "fix: thing\n\nCo-authored-by: aider (sonnet) <noreply@aider.chat>")
+	assert.True(t, a.IsAgentCommit(dir, gitCmd(t, dir, nil, "rev-parse", "HEAD")))
+}
+
+func TestParseSession(t *testing.T) {
+	dir := setupRepo(t)
+	a := &AiderAgent{}
+
+	session, err := a.ParseSession("2025-01-15T10-00-00", dir)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01-15T10-00-00", session.ID)
+	assert.Len(t, session.Prompts, 3)
+	assert.Equal(t, "10:00:10", session.Prompts[0].Timestamp.Format("15:04:05"))
+	assert.Contains(t, string(session.Transcript), "# aider chat started at 2025-01-15 10:00:00")
+	assert.NotContains(t, string(session.Transcript), "09:00:00")
+
+	_, err = a.ParseSession("2024-01-01T00-00-00", dir)
+	assert.Error(t, err)
+}
+
+func TestSessionsSince(t *testing.T) {
+	dir := setupRepo(t)
+
+	// Only the last session was still running after 10:30
+	since := time.Date(2025, 1, 15, 10, 30, 0, 0, time.Local)
+	ids, err := SessionsSince(dir, since)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2025-01-15T10-00-00"}, sessionIDs(ids))
+	assert.Equal(t, "chat history modified", ids[0].Reason)
+
+	ids, err = SessionsSince(dir, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2025-01-15T10-00-00", "2025-01-15T09-00-00"}, sessionIDs(ids))
+	assert.Equal(t, 10, ids[1].LastActivity.Hour())
+
+	_, err = SessionsSince(t.TempDir(), time.Time{})
+	assert.Error(t, err)
+}
+
+func sessionIDs(sessions []types.SessionActivity) []string {
+	ids := make([]string, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.SessionID
+	}
+	return ids
+}
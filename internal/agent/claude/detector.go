@@ -7,6 +7,9 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/yibudak/open-entire/internal/session"
+	"github.com/yibudak/open-entire/pkg/types"
 )
 
 const (
@@ -18,67 +21,61 @@ const (
 // Detect checks if Claude Code is active for the given repo.
 // Returns the session ID if found.
 func Detect(repoDir string) (string, error) {
-	projDir := ProjectDir(repoDir)
-
-	entries, err := os.ReadDir(projDir)
+	recent, err := SessionsSince(repoDir, time.Now().Add(-activeWindow))
 	if err != nil {
-		if os.IsNotExist(err) {
-			return "", fmt.Errorf("no Claude Code project directory found")
-		}
 		return "", err
 	}
-
-	// Find recently modified .jsonl files
-	type sessionFile struct {
-		name    string
-		modTime time.Time
+	if len(recent) == 0 {
+		return "", fmt.Errorf("no active Claude Code session found")
 	}
+	return recent[0].SessionID, nil
+}
 
-	var recent []sessionFile
-	threshold := time.Now().Add(-activeWindow)
+// SessionsSince returns the sessions whose transcript was modified after
+// since, most recently modified first. Besides the repository's project
+// directory, the transcripts that hooks reported elsewhere are checked.
+func SessionsSince(repoDir string, since time.Time) ([]types.SessionActivity, error) {
+	projDir := ProjectDir(repoDir)
 
+	var paths []string
+	entries, err := os.ReadDir(projDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
 	for _, e := range entries {
-		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
-			continue
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".jsonl") {
+			paths = append(paths, filepath.Join(projDir, e.Name()))
+		}
+	}
+	if store, err := session.NewStore(repoDir); err == nil {
+		for _, s := range store.Sessions() {
+			if s.AgentName == agentName && s.TranscriptPath != "" && filepath.Dir(s.TranscriptPath) != projDir {
+				paths = append(paths, s.TranscriptPath)
+			}
 		}
-		info, err := e.Info()
+	}
+	if len(paths) == 0 && os.IsNotExist(err) {
+		return nil, fmt.Errorf("no Claude Code project directory found")
+	}
+
+	var recent []types.SessionActivity
+	for _, path := range paths {
+		info, err := os.Stat(path)
 		if err != nil {
 			continue
 		}
-		if info.ModTime().After(threshold) {
-			recent = append(recent, sessionFile{
-				name:    strings.TrimSuffix(e.Name(), ".jsonl"),
-				modTime: info.ModTime(),
+		if info.ModTime().After(since) {
+			name := filepath.Base(path)
+			recent = append(recent, types.SessionActivity{
+				SessionID:    strings.TrimSuffix(name, ".jsonl"),
+				LastActivity: info.ModTime(),
+				Reason:       "transcript " + name + " modified",
 			})
 		}
 	}
 
-	if len(recent) == 0 {
-		return "", fmt.Errorf("no active Claude Code session found")
-	}
-
-	// Return the most recently modified session
 	sort.Slice(recent, func(i, j int) bool {
-		return recent[i].modTime.After(recent[j].modTime)
+		return recent[i].LastActivity.After(recent[j].LastActivity)
 	})
-
-	return recent[0].name, nil
-}
-
-// DetectProcess checks if a Claude Code process is running.
-func DetectProcess() bool {
-	// Check for claude process in /proc or using ps
-	entries, err := filepath.Glob("/proc/*/comm")
-	if err == nil {
-		for _, entry := range entries {
-			data, err := os.ReadFile(entry)
-			if err == nil && strings.TrimSpace(string(data)) == "claude" {
-				return true
-			}
-		}
-	}
-
-	// macOS fallback: check for process by looking at common locations
-	// The actual process detection will use go-ps in production
-	return false
+	return recent, nil
 }
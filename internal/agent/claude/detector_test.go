@@ -0,0 +1,110 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func writeSession(t *testing.T, repoDir, id string, modTime time.Time) string {
+	t.Helper()
+	dir := ProjectDir(repoDir)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	path := filepath.Join(dir, id+".jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"type":"user","message":"hi"}`+"\n"), 0o644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	return path
+}
+
+func TestSessionsSince(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repoDir := "/work/repo"
+	now := time.Now()
+
+	writeSession(t, repoDir, "old", now.Add(-2*time.Hour))
+	writeSession(t, repoDir, "recent", now.Add(-10*time.Minute))
+	writeSession(t, repoDir, "newest", now.Add(-1*time.Minute))
+
+	ids, err := SessionsSince(repoDir, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"newest", "recent"}, sessionIDs(ids))
+	assert.Contains(t, ids[0].Reason, "newest.jsonl")
+
+	id, err := Detect(repoDir)
+	require.NoError(t, err)
+	assert.Equal(t, "newest", id)
+}
+
+func TestSessionsSinceNoProjectDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	_, err := SessionsSince("/work/missing", time.Time{})
+	assert.Error(t, err)
+}
+
+func TestParseSessionSetsTranscriptPath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repoDir := "/work/repo"
+	path := writeSession(t, repoDir, "sess-1", time.Now())
+
+	session, err := (&ClaudeAgent{}).ParseSession("sess-1", repoDir)
+	require.NoError(t, err)
+	assert.Equal(t, "sess-1", session.ID)
+	assert.Equal(t, path, session.TranscriptPath)
+}
+
+func sessionIDs(sessions []types.SessionActivity) []string {
+	ids := make([]string, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.SessionID
+	}
+	return ids
+}
+
+func TestParseSessionDeltaAdvances(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repoDir := t.TempDir()
+	path := writeSession(t, repoDir, "sess-1", time.Now())
+
+	a := &ClaudeAgent{}
+	delta, advance, err := a.ParseSessionDelta("sess-1", repoDir)
+	require.NoError(t, err)
+	assert.Len(t, delta.Prompts, 1)
+	assert.Equal(t, path, delta.TranscriptPath)
+
+	// Until advanced, the same delta is returned again
+	again, _, err := a.ParseSessionDelta("sess-1", repoDir)
+	require.NoError(t, err)
+	assert.Len(t, again.Prompts, 1)
+
+	require.NoError(t, advance())
+	appendFile(t, path, `{"type":"user","message":"more"}`+"\n")
+
+	delta, _, err = a.ParseSessionDelta("sess-1", repoDir)
+	require.NoError(t, err)
+	require.Len(t, delta.Prompts, 1)
+	assert.Equal(t, "more", delta.Prompts[0].Content)
+	assert.Equal(t, `{"type":"user","message":"more"}`+"\n", string(delta.Transcript))
+}
+
+func TestHasPending(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repoDir := t.TempDir()
+	path := writeSession(t, repoDir, "sess-1", time.Now())
+
+	a := &ClaudeAgent{}
+	assert.True(t, a.HasPending("sess-1", repoDir))
+	assert.False(t, a.HasPending("missing", repoDir))
+
+	_, advance, err := a.ParseSessionDelta("sess-1", repoDir)
+	require.NoError(t, err)
+	require.NoError(t, advance())
+	assert.False(t, a.HasPending("sess-1", repoDir))
+
+	appendFile(t, path, `{"type":"user","message":"more"}`+"\n")
+	assert.True(t, a.HasPending("sess-1", repoDir))
+}
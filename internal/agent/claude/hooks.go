@@ -0,0 +1,198 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yibudak/open-entire/internal/agent"
+)
+
+// hookCommand starts every command InstallHooks adds, which is how they are
+// told apart from the user's own hooks.
+const hookCommand = "open-entire _agent-hook"
+
+// hookEvents maps Claude Code's hook events to open-entire's.
+var hookEvents = map[string]string{
+	"SessionStart":     agent.HookSessionStart,
+	"UserPromptSubmit": agent.HookPromptSubmit,
+	"Stop":             agent.HookStop,
+	"SubagentStop":     agent.HookSubagentStop,
+	"SessionEnd":       agent.HookSessionEnd,
+}
+
+// SettingsPath returns the project settings file Claude Code reads hooks from.
+func SettingsPath(repoDir string) string {
+	return filepath.Join(repoDir, ".claude", "settings.json")
+}
+
+// InstallHooks adds open-entire's hooks to .claude/settings.json, keeping
+// everything else in the file.
+func (a *ClaudeAgent) InstallHooks(repoDir string) error {
+	path := SettingsPath(repoDir)
+	settings, err := readSettings(path)
+	if err != nil {
+		return err
+	}
+
+	hooks := withoutOurHooks(settings["hooks"])
+	events := make([]string, 0, len(hookEvents))
+	for event := range hookEvents {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	for _, event := range events {
+		list, _ := hooks[event].([]any)
+		hooks[event] = append(list, map[string]any{
+			"hooks": []any{map[string]any{
+				"type":    "command",
+				"command": hookCommand + " " + hookEvents[event],
+			}},
+		})
+	}
+	settings["hooks"] = hooks
+	return writeSettings(path, settings)
+}
+
+// RemoveHooks removes open-entire's hooks from .claude/settings.json.
+func (a *ClaudeAgent) RemoveHooks(repoDir string) error {
+	path := SettingsPath(repoDir)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	settings, err := readSettings(path)
+	if err != nil {
+		return err
+	}
+
+	hooks := withoutOurHooks(settings["hooks"])
+	if len(hooks) == 0 {
+		delete(settings, "hooks")
+	} else {
+		settings["hooks"] = hooks
+	}
+	return writeSettings(path, settings)
+}
+
+// HooksInstalled reports whether .claude/settings.json runs open-entire.
+func HooksInstalled(repoDir string) bool {
+	data, err := os.ReadFile(SettingsPath(repoDir))
+	return err == nil && strings.Contains(string(data), hookCommand)
+}
+
+// withoutOurHooks returns the hooks setting minus the commands open-entire
+// installed. Matcher groups left empty are dropped, as are events left
+// without groups.
+func withoutOurHooks(v any) map[string]any {
+	hooks, _ := v.(map[string]any)
+	kept := make(map[string]any, len(hooks))
+	for event, groups := range hooks {
+		list, ok := groups.([]any)
+		if !ok {
+			kept[event] = groups
+			continue
+		}
+		var keptGroups []any
+		for _, g := range list {
+			group, ok := g.(map[string]any)
+			if !ok {
+				keptGroups = append(keptGroups, g)
+				continue
+			}
+			entries, _ := group["hooks"].([]any)
+			var keptEntries []any
+			for _, e := range entries {
+				if entry, ok := e.(map[string]any); ok {
+					if cmd, _ := entry["command"].(string); strings.HasPrefix(cmd, hookCommand) {
+						continue
+					}
+				}
+				keptEntries = append(keptEntries, e)
+			}
+			if len(keptEntries) == 0 && len(entries) > 0 {
+				continue
+			}
+			if len(keptEntries) != len(entries) {
+				group["hooks"] = keptEntries
+			}
+			keptGroups = append(keptGroups, group)
+		}
+		if len(keptGroups) > 0 {
+			kept[event] = keptGroups
+		}
+	}
+	return kept
+}
+
+func readSettings(path string) (map[string]any, error) {
+	settings := make(map[string]any)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return settings, nil
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+func writeSettings(path string, settings map[string]any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// hookInput is the JSON Claude Code passes its hooks on stdin.
+type hookInput struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	Cwd            string `json:"cwd"`
+	HookEventName  string `json:"hook_event_name"`
+}
+
+// ParseHookEvent reads the payload of one of the hooks InstallHooks adds.
+func (a *ClaudeAgent) ParseHookEvent(name string, payload io.Reader) (*agent.HookEvent, error) {
+	known := false
+	for _, e := range hookEvents {
+		known = known || e == name
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown hook event: %s", name)
+	}
+
+	var in hookInput
+	if err := json.NewDecoder(payload).Decode(&in); err != nil {
+		return nil, fmt.Errorf("failed to read hook input: %w", err)
+	}
+	if in.SessionID == "" {
+		return nil, fmt.Errorf("hook input has no session_id")
+	}
+	if in.SessionID != filepath.Base(in.SessionID) {
+		return nil, fmt.Errorf("invalid session ID %q", in.SessionID)
+	}
+	if rest, ok := strings.CutPrefix(in.TranscriptPath, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		in.TranscriptPath = filepath.Join(home, rest)
+	}
+	return &agent.HookEvent{
+		Name:           name,
+		SessionID:      in.SessionID,
+		TranscriptPath: in.TranscriptPath,
+		Dir:            in.Cwd,
+	}, nil
+}
@@ -0,0 +1,89 @@
+package claude
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/internal/agent"
+)
+
+func TestInstallHooks(t *testing.T) {
+	repoDir := t.TempDir()
+	path := SettingsPath(repoDir)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	existing := `{
+  "model": "opus",
+  "hooks": {
+    "Stop": [{"hooks": [{"type": "command", "command": "make lint"}]}]
+  }
+}`
+	require.NoError(t, os.WriteFile(path, []byte(existing), 0o644))
+
+	a := &ClaudeAgent{}
+	require.NoError(t, a.InstallHooks(repoDir))
+	// Installing again does not add the hooks twice
+	require.NoError(t, a.InstallHooks(repoDir))
+	assert.True(t, HooksInstalled(repoDir))
+
+	settings := readTestSettings(t, path)
+	assert.Equal(t, "opus", settings["model"])
+	hooks := settings["hooks"].(map[string]any)
+	for event, name := range hookEvents {
+		groups := hooks[event].([]any)
+		data, _ := json.Marshal(groups)
+		assert.Equal(t, 1, strings.Count(string(data), hookCommand+" "+name), event)
+	}
+	stop, _ := json.Marshal(hooks["Stop"])
+	assert.Contains(t, string(stop), "make lint")
+
+	require.NoError(t, a.RemoveHooks(repoDir))
+	assert.False(t, HooksInstalled(repoDir))
+	settings = readTestSettings(t, path)
+	assert.Equal(t, "opus", settings["model"])
+	hooks = settings["hooks"].(map[string]any)
+	assert.Len(t, hooks, 1)
+	stop, _ = json.Marshal(hooks["Stop"])
+	assert.Contains(t, string(stop), "make lint")
+}
+
+func TestRemoveHooksWithoutSettings(t *testing.T) {
+	repoDir := t.TempDir()
+	require.NoError(t, (&ClaudeAgent{}).RemoveHooks(repoDir))
+	_, err := os.Stat(SettingsPath(repoDir))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestParseHookEvent(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	a := &ClaudeAgent{}
+
+	payload := `{"session_id":"abc-123","transcript_path":"~/.claude/projects/x/abc-123.jsonl","cwd":"/work/repo","hook_event_name":"Stop"}`
+	ev, err := a.ParseHookEvent(agent.HookStop, strings.NewReader(payload))
+	require.NoError(t, err)
+	assert.Equal(t, agent.HookStop, ev.Name)
+	assert.Equal(t, "abc-123", ev.SessionID)
+	assert.Equal(t, filepath.Join(home, ".claude/projects/x/abc-123.jsonl"), ev.TranscriptPath)
+	assert.Equal(t, "/work/repo", ev.Dir)
+
+	_, err = a.ParseHookEvent("bogus", strings.NewReader(payload))
+	assert.Error(t, err)
+	_, err = a.ParseHookEvent(agent.HookStop, strings.NewReader(`{"cwd":"/work"}`))
+	assert.Error(t, err)
+	_, err = a.ParseHookEvent(agent.HookStop, strings.NewReader(`{"session_id":"../etc"}`))
+	assert.Error(t, err)
+}
+
+func readTestSettings(t *testing.T, path string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var settings map[string]any
+	require.NoError(t, json.Unmarshal(data, &settings))
+	return settings
+}
@@ -2,8 +2,11 @@ package claude
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -13,14 +16,15 @@ import (
 
 // JSONLEvent represents a single event in a Claude Code JSONL transcript.
 type JSONLEvent struct {
-	Type       string          `json:"type"`
-	Timestamp  string          `json:"timestamp,omitempty"`
-	RequestID  string          `json:"requestId,omitempty"`
-	Message    json.RawMessage `json:"message,omitempty"`
-	Content    json.RawMessage `json:"content,omitempty"`
-	Usage      *UsageData      `json:"usage,omitempty"`
-	Role       string          `json:"role,omitempty"`
-	CostUSD    float64         `json:"costUSD,omitempty"`
+	Type      string          `json:"type"`
+	Timestamp string          `json:"timestamp,omitempty"`
+	RequestID string          `json:"requestId,omitempty"`
+	Message   json.RawMessage `json:"message,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+	Usage     *UsageData      `json:"usage,omitempty"`
+	Role      string          `json:"role,omitempty"`
+	Model     string          `json:"model,omitempty"`
+	CostUSD   float64         `json:"costUSD,omitempty"`
 }
 
 // UsageData represents token usage from Claude's API.
@@ -31,54 +35,169 @@ type UsageData struct {
 	CacheReadInput     int `json:"cache_read_input_tokens"`
 }
 
+// ParseState is where an incremental parse of a transcript left off.
+// It is saved between parses so that only newly appended lines are read.
+type ParseState struct {
+	// Offset is the byte offset just past the last complete line parsed.
+	Offset    int64     `json:"offset"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+	// Open is the most recent assistant request. Claude Code streams a
+	// request as several events sharing a requestId, so more of it may
+	// still be appended.
+	Open *OpenRequest `json:"open,omitempty"`
+	// Pending are tool calls already reported whose results have not been
+	// read yet, by tool_use id. A checkpoint can fall between a call and
+	// its result; the result is then reported in the next delta.
+	Pending map[string]PendingCall `json:"pending,omitempty"`
+}
+
+// PendingCall is a tool call waiting for its result.
+type PendingCall struct {
+	Name      string    `json:"name"`
+	Timestamp time.Time `json:"timestamp"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// maxPending bounds how many calls wait for results across parses. Calls
+// that never get one, as when the agent is killed, are dropped oldest first.
+const maxPending = 64
+
+// OpenRequest aggregates the events of one assistant request.
+type OpenRequest struct {
+	RequestID string     `json:"request_id"`
+	Timestamp string     `json:"timestamp,omitempty"`
+	Content   string     `json:"content,omitempty"`
+	Model     string     `json:"model,omitempty"`
+	Usage     *UsageData `json:"usage,omitempty"`
+	CostUSD   float64    `json:"cost_usd,omitempty"`
+	// What has already been reported in earlier deltas
+	EmittedContent string     `json:"emitted_content,omitempty"`
+	EmittedUsage   *UsageData `json:"emitted_usage,omitempty"`
+	EmittedCostUSD float64    `json:"emitted_cost_usd,omitempty"`
+	Emitted        bool       `json:"emitted,omitempty"`
+}
+
 // ParseJSONL parses a Claude Code JSONL transcript file.
 func ParseJSONL(path string) (*types.SessionData, error) {
+	session, _, err := parseJSONL(path, nil, false)
+	return session, err
+}
+
+// ParseJSONLFrom parses what was appended to a transcript since state was
+// saved, or the whole transcript if state is nil. It returns the delta, with
+// the raw lines read in Transcript, and the state to resume from next time.
+// A trailing line that is still being written is left for the next parse.
+func ParseJSONLFrom(path string, state *ParseState) (*types.SessionData, *ParseState, error) {
+	return parseJSONL(path, state, true)
+}
+
+func parseJSONL(path string, state *ParseState, keepRaw bool) (*types.SessionData, *ParseState, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer f.Close()
 
-	session := &types.SessionData{
-		AgentName: "claude-code",
+	next := &ParseState{}
+	if state != nil {
+		*next = *state
+		if next.Open != nil {
+			open := *next.Open
+			next.Open = &open
+		}
+		next.Pending = make(map[string]PendingCall, len(state.Pending))
+		for id, call := range state.Pending {
+			next.Pending[id] = call
+		}
 	}
 
-	// Track request IDs for deduplication
-	requestLastEvent := make(map[string]*JSONLEvent)
-	var allEvents []JSONLEvent
+	// A transcript shorter than the saved offset was rewritten; start over
+	if info, err := f.Stat(); err == nil && info.Size() < next.Offset {
+		next = &ParseState{}
+	}
+	if _, err := f.Seek(next.Offset, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
 
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024) // 10MB line buffer
+	session := &types.SessionData{
+		AgentName:        agentName,
+		TranscriptOffset: next.Offset,
+	}
+	var raw bytes.Buffer
+	var lastTS time.Time
+	// Tool calls by tool_use id, so results can be attached
+	callIndex := make(map[string]int)
+	answered := make(map[string]bool)
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.TrimSpace(line) == "" {
+	reader := bufio.NewReaderSize(f, 1024*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		eof := errors.Is(err, io.EOF)
+		if err != nil && !eof {
+			return nil, nil, fmt.Errorf("error reading JSONL: %w", err)
+		}
+		// A resumable parse leaves a partial last line for next time
+		if eof && (keepRaw || len(line) == 0) {
+			break
+		}
+		next.Offset += int64(len(line))
+		if keepRaw {
+			raw.Write(line)
+		}
+
+		if len(bytes.TrimSpace(line)) == 0 {
 			continue
 		}
 
 		var event JSONLEvent
-		if err := json.Unmarshal([]byte(line), &event); err != nil {
+		if err := json.Unmarshal(line, &event); err != nil {
+			if eof {
+				break
+			}
 			continue // Skip malformed lines
 		}
 
-		allEvents = append(allEvents, event)
-
-		// Deduplicate by requestId — keep last occurrence for token usage
-		if event.RequestID != "" {
-			requestLastEvent[event.RequestID] = &event
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading JSONL: %w", err)
-	}
-
-	// Process events
-	for _, event := range allEvents {
 		ts := parseTimestamp(event.Timestamp)
+		if !ts.IsZero() {
+			if next.StartedAt.IsZero() {
+				next.StartedAt = ts
+			}
+			lastTS = ts
+		}
 
 		switch event.Type {
 		case "user":
+			// Tool results come back as user turns; they are not prompts
+			if results := toolResults(event.Message); len(results) > 0 {
+				for _, r := range results {
+					i, ok := callIndex[r.ToolUseID]
+					if !ok {
+						// Report the result of a call from an earlier delta
+						// as the call again, without its input
+						p, pending := next.Pending[r.ToolUseID]
+						if !pending {
+							continue
+						}
+						delete(next.Pending, r.ToolUseID)
+						i = len(session.ToolCalls)
+						session.ToolCalls = append(session.ToolCalls, types.ToolCall{
+							ID:        r.ToolUseID,
+							Name:      p.Name,
+							Timestamp: p.Timestamp,
+							RequestID: p.RequestID,
+						})
+					}
+					answered[r.ToolUseID] = true
+					call := &session.ToolCalls[i]
+					call.Output = resultText(r.Content)
+					call.IsError = r.IsError
+					if !ts.IsZero() && !call.Timestamp.IsZero() && ts.After(call.Timestamp) {
+						call.DurationMS = ts.Sub(call.Timestamp).Milliseconds()
+					}
+				}
+				continue
+			}
+
 			content := extractContent(event.Message)
 			if content != "" {
 				session.Prompts = append(session.Prompts, types.Prompt{
@@ -89,59 +208,147 @@ func ParseJSONL(path string) (*types.SessionData, error) {
 			}
 
 		case "assistant":
-			// Only process the last event per requestId for responses
 			if event.RequestID != "" {
-				last := requestLastEvent[event.RequestID]
-				if last != nil && last.Timestamp == event.Timestamp {
-					content := extractContent(event.Message)
-					resp := types.Response{
-						Content:   content,
-						Timestamp: ts,
-						RequestID: event.RequestID,
-					}
-					if event.Usage != nil {
-						resp.TokenUsage = types.TokenUsage{
-							InputTokens:   event.Usage.InputTokens,
-							OutputTokens:  event.Usage.OutputTokens,
-							CacheCreation: event.Usage.CacheCreationInput,
-							CacheReads:    event.Usage.CacheReadInput,
-						}
-					}
-					session.Responses = append(session.Responses, resp)
+				if next.Open != nil && next.Open.RequestID != event.RequestID {
+					flushRequest(session, next.Open)
+					next.Open = nil
+				}
+				if next.Open == nil {
+					next.Open = &OpenRequest{RequestID: event.RequestID}
+				}
+				next.Open.Timestamp = event.Timestamp
+				if content := extractContent(event.Message); content != "" {
+					next.Open.Content = content
+				}
+				model, usage := messageMeta(event.Message)
+				if event.Model != "" {
+					model = event.Model
+				}
+				if event.Usage != nil {
+					usage = event.Usage
+				}
+				if model != "" {
+					next.Open.Model = model
+				}
+				if usage != nil {
+					u := *usage
+					next.Open.Usage = &u
+				}
+				if event.CostUSD > 0 {
+					next.Open.CostUSD = event.CostUSD
 				}
 			}
-		}
 
-		// Extract tool calls
-		if event.Type == "assistant" {
-			toolCalls := extractToolCalls(event.Message)
-			for _, tc := range toolCalls {
+			for _, tc := range extractToolCalls(event.Message) {
 				tc.Timestamp = ts
 				tc.RequestID = event.RequestID
+				if tc.ID != "" {
+					callIndex[tc.ID] = len(session.ToolCalls)
+				}
 				session.ToolCalls = append(session.ToolCalls, tc)
 			}
 		}
 	}
 
-	// Aggregate token usage from deduplicated responses
-	for _, last := range requestLastEvent {
-		if last.Usage != nil {
-			session.TokenUsage.InputTokens += last.Usage.InputTokens
-			session.TokenUsage.OutputTokens += last.Usage.OutputTokens
-			session.TokenUsage.CacheCreation += last.Usage.CacheCreationInput
-			session.TokenUsage.CacheReads += last.Usage.CacheReadInput
-			session.TokenUsage.APICalls++
-		}
+	// Report the open request as it stands; later events add to it
+	if next.Open != nil {
+		flushRequest(session, next.Open)
+	}
+	if keepRaw {
+		keepPending(next, session.ToolCalls, answered)
+	}
+
+	for _, r := range session.Responses {
+		session.TokenUsage.InputTokens += r.TokenUsage.InputTokens
+		session.TokenUsage.OutputTokens += r.TokenUsage.OutputTokens
+		session.TokenUsage.CacheCreation += r.TokenUsage.CacheCreation
+		session.TokenUsage.CacheReads += r.TokenUsage.CacheReads
+		session.TokenUsage.APICalls += r.TokenUsage.APICalls
+		session.TokenUsage.CostUSD += r.TokenUsage.CostUSD
 	}
 
-	// Set timestamps
-	if len(allEvents) > 0 {
-		session.StartedAt = parseTimestamp(allEvents[0].Timestamp)
-		lastTS := parseTimestamp(allEvents[len(allEvents)-1].Timestamp)
+	session.StartedAt = next.StartedAt
+	if !lastTS.IsZero() {
 		session.EndedAt = &lastTS
 	}
+	if keepRaw {
+		session.Transcript = raw.Bytes()
+	}
+
+	return session, next, nil
+}
 
-	return session, nil
+// keepPending adds the calls of a delta that got no result to the state's
+// pending calls, keeping the newest maxPending.
+func keepPending(state *ParseState, calls []types.ToolCall, answered map[string]bool) {
+	for _, tc := range calls {
+		if tc.ID == "" || answered[tc.ID] {
+			continue
+		}
+		if state.Pending == nil {
+			state.Pending = make(map[string]PendingCall)
+		}
+		state.Pending[tc.ID] = PendingCall{Name: tc.Name, Timestamp: tc.Timestamp, RequestID: tc.RequestID}
+	}
+	for len(state.Pending) > maxPending {
+		oldest := ""
+		for id, call := range state.Pending {
+			if oldest == "" || call.Timestamp.Before(state.Pending[oldest].Timestamp) {
+				oldest = id
+			}
+		}
+		delete(state.Pending, oldest)
+	}
+}
+
+// flushRequest appends a response for whatever part of the request has not
+// been reported yet, so usage summed across deltas is counted once.
+func flushRequest(session *types.SessionData, req *OpenRequest) {
+	contentChanged := req.Content != req.EmittedContent
+	usage := subtractUsage(req.Usage, req.EmittedUsage)
+	usage.CostUSD = req.CostUSD - req.EmittedCostUSD
+	if req.Emitted && !contentChanged && usage == (types.TokenUsage{}) {
+		return
+	}
+
+	resp := types.Response{
+		Timestamp:  parseTimestamp(req.Timestamp),
+		RequestID:  req.RequestID,
+		Model:      req.Model,
+		TokenUsage: usage,
+	}
+	if contentChanged {
+		resp.Content = req.Content
+	}
+	if !req.Emitted && req.Usage != nil {
+		resp.TokenUsage.APICalls = 1
+	}
+	session.Responses = append(session.Responses, resp)
+
+	req.Emitted = true
+	req.EmittedContent = req.Content
+	req.EmittedCostUSD = req.CostUSD
+	if req.Usage != nil {
+		usage := *req.Usage
+		req.EmittedUsage = &usage
+	}
+}
+
+func subtractUsage(current, emitted *UsageData) types.TokenUsage {
+	var u types.TokenUsage
+	if current != nil {
+		u.InputTokens = current.InputTokens
+		u.OutputTokens = current.OutputTokens
+		u.CacheCreation = current.CacheCreationInput
+		u.CacheReads = current.CacheReadInput
+	}
+	if emitted != nil {
+		u.InputTokens -= emitted.InputTokens
+		u.OutputTokens -= emitted.OutputTokens
+		u.CacheCreation -= emitted.CacheCreationInput
+		u.CacheReads -= emitted.CacheReadInput
+	}
+	return u
 }
 
 func parseTimestamp(s string) time.Time {
@@ -162,6 +369,19 @@ func parseTimestamp(s string) time.Time {
 	return time.Time{}
 }
 
+// messageMeta reads the model and usage Claude Code records inside an
+// assistant message.
+func messageMeta(raw json.RawMessage) (string, *UsageData) {
+	var msg struct {
+		Model string     `json:"model"`
+		Usage *UsageData `json:"usage"`
+	}
+	if raw == nil || json.Unmarshal(raw, &msg) != nil {
+		return "", nil
+	}
+	return msg.Model, msg.Usage
+}
+
 func extractContent(raw json.RawMessage) string {
 	if raw == nil {
 		return ""
@@ -197,27 +417,38 @@ func extractContent(raw json.RawMessage) string {
 	return string(raw)
 }
 
-func extractToolCalls(raw json.RawMessage) []types.ToolCall {
+// contentBlock is one block of a message's content array.
+type contentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+func messageBlocks(raw json.RawMessage) []contentBlock {
 	if raw == nil {
 		return nil
 	}
 
 	var msg struct {
-		Content []struct {
-			Type  string          `json:"type"`
-			Name  string          `json:"name"`
-			Input json.RawMessage `json:"input"`
-		} `json:"content"`
+		Content []contentBlock `json:"content"`
 	}
-
 	if json.Unmarshal(raw, &msg) != nil {
 		return nil
 	}
+	return msg.Content
+}
 
+func extractToolCalls(raw json.RawMessage) []types.ToolCall {
 	var calls []types.ToolCall
-	for _, c := range msg.Content {
+	for _, c := range messageBlocks(raw) {
 		if c.Type == "tool_use" {
 			calls = append(calls, types.ToolCall{
+				ID:    c.ID,
 				Name:  c.Name,
 				Input: string(c.Input),
 			})
@@ -225,3 +456,38 @@ func extractToolCalls(raw json.RawMessage) []types.ToolCall {
 	}
 	return calls
 }
+
+func toolResults(raw json.RawMessage) []contentBlock {
+	var results []contentBlock
+	for _, c := range messageBlocks(raw) {
+		if c.Type == "tool_result" {
+			results = append(results, c)
+		}
+	}
+	return results
+}
+
+// resultText returns the text of a tool_result, whose content is either a
+// string or a list of blocks.
+func resultText(raw json.RawMessage) string {
+	if raw == nil {
+		return ""
+	}
+
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+
+	var blocks []contentBlock
+	if json.Unmarshal(raw, &blocks) == nil {
+		var parts []string
+		for _, b := range blocks {
+			if b.Text != "" {
+				parts = append(parts, b.Text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return string(raw)
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -82,3 +83,231 @@ func TestParseJSONLDeduplicatesByRequestID(t *testing.T) {
 	assert.Equal(t, 100, session.TokenUsage.InputTokens)
 	assert.Equal(t, 1, session.TokenUsage.APICalls)
 }
+
+func TestParseJSONLRecordsModelAndCost(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.jsonl")
+
+	// Current transcripts keep model and usage inside the message; older
+	// ones also report the cost
+	content := `{"type":"assistant","timestamp":"2025-01-15T10:00:01Z","requestId":"req-1","message":{"model":"claude-sonnet-4-5-20250929","content":[{"type":"text","text":"Hi"}],"usage":{"input_tokens":10,"output_tokens":5}}}
+{"type":"assistant","timestamp":"2025-01-15T10:00:02Z","requestId":"req-2","message":"Done","usage":{"input_tokens":100,"output_tokens":50},"costUSD":0.25}
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	session, err := ParseJSONL(path)
+	require.NoError(t, err)
+	require.Len(t, session.Responses, 2)
+	assert.Equal(t, "claude-sonnet-4-5-20250929", session.Responses[0].Model)
+	assert.Equal(t, 10, session.Responses[0].TokenUsage.InputTokens)
+	assert.Equal(t, 0.25, session.Responses[1].TokenUsage.CostUSD)
+	assert.Equal(t, 110, session.TokenUsage.InputTokens)
+	assert.Equal(t, 0.25, session.TokenUsage.CostUSD)
+}
+
+func TestParseJSONLFromResumesAtOffset(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.jsonl")
+
+	first := `{"type":"user","timestamp":"2025-01-15T10:00:00Z","message":"Write a hello world function"}
+{"type":"assistant","timestamp":"2025-01-15T10:00:05Z","requestId":"req-1","message":"Done","usage":{"input_tokens":100,"output_tokens":50}}
+`
+	require.NoError(t, os.WriteFile(path, []byte(first), 0o644))
+
+	delta, state, err := ParseJSONLFrom(path, nil)
+	require.NoError(t, err)
+	assert.Len(t, delta.Prompts, 1)
+	assert.Equal(t, first, string(delta.Transcript))
+	assert.Equal(t, int64(len(first)), state.Offset)
+	assert.Zero(t, delta.TranscriptOffset)
+
+	second := `{"type":"user","timestamp":"2025-01-15T10:01:00Z","message":"Add tests"}
+{"type":"assistant","timestamp":"2025-01-15T10:01:10Z","requestId":"req-2","message":"Added","usage":{"input_tokens":200,"output_tokens":80}}
+`
+	appendFile(t, path, second)
+
+	delta, state, err = ParseJSONLFrom(path, state)
+	require.NoError(t, err)
+	require.Len(t, delta.Prompts, 1)
+	assert.Equal(t, "Add tests", delta.Prompts[0].Content)
+	require.Len(t, delta.Responses, 1)
+	assert.Equal(t, "Added", delta.Responses[0].Content)
+	assert.Equal(t, 200, delta.TokenUsage.InputTokens)
+	assert.Equal(t, 1, delta.TokenUsage.APICalls)
+	assert.Equal(t, second, string(delta.Transcript))
+	assert.Equal(t, int64(len(first)), delta.TranscriptOffset)
+	assert.Equal(t, "2025-01-15T10:00:00Z", delta.StartedAt.Format(time.RFC3339))
+
+	// Nothing appended
+	delta, _, err = ParseJSONLFrom(path, state)
+	require.NoError(t, err)
+	assert.Empty(t, delta.Prompts)
+	assert.Empty(t, delta.Responses)
+	assert.Empty(t, delta.Transcript)
+}
+
+func TestParseJSONLFromLeavesPartialLine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.jsonl")
+
+	complete := `{"type":"user","timestamp":"2025-01-15T10:00:00Z","message":"Hello"}` + "\n"
+	partial := `{"type":"user","timestamp":"2025-01-15T10:01:00Z","mess`
+	require.NoError(t, os.WriteFile(path, []byte(complete+partial), 0o644))
+
+	delta, state, err := ParseJSONLFrom(path, nil)
+	require.NoError(t, err)
+	assert.Len(t, delta.Prompts, 1)
+	assert.Equal(t, int64(len(complete)), state.Offset)
+
+	appendFile(t, path, `age":"World"}`+"\n")
+
+	delta, _, err = ParseJSONLFrom(path, state)
+	require.NoError(t, err)
+	require.Len(t, delta.Prompts, 1)
+	assert.Equal(t, "World", delta.Prompts[0].Content)
+}
+
+func TestParseJSONLFromCountsSplitRequestOnce(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.jsonl")
+
+	// The request is still streaming when the first delta is taken
+	require.NoError(t, os.WriteFile(path, []byte(
+		`{"type":"assistant","timestamp":"2025-01-15T10:00:01Z","requestId":"req-1","message":"Working","usage":{"input_tokens":100,"output_tokens":10}}`+"\n"), 0o644))
+
+	first, state, err := ParseJSONLFrom(path, nil)
+	require.NoError(t, err)
+	require.Len(t, first.Responses, 1)
+	assert.Equal(t, 10, first.TokenUsage.OutputTokens)
+
+	appendFile(t, path, `{"type":"assistant","timestamp":"2025-01-15T10:00:02Z","requestId":"req-1","message":"Working, done","usage":{"input_tokens":100,"output_tokens":40}}`+"\n"+
+		`{"type":"assistant","timestamp":"2025-01-15T10:00:09Z","requestId":"req-2","message":"Next","usage":{"input_tokens":300,"output_tokens":20}}`+"\n")
+
+	second, _, err := ParseJSONLFrom(path, state)
+	require.NoError(t, err)
+	require.Len(t, second.Responses, 2)
+	assert.Equal(t, "Working, done", second.Responses[0].Content)
+
+	// Totals across deltas match a full parse
+	full, err := ParseJSONL(path)
+	require.NoError(t, err)
+	assert.Equal(t, full.TokenUsage.InputTokens, first.TokenUsage.InputTokens+second.TokenUsage.InputTokens)
+	assert.Equal(t, full.TokenUsage.OutputTokens, first.TokenUsage.OutputTokens+second.TokenUsage.OutputTokens)
+	assert.Equal(t, full.TokenUsage.APICalls, first.TokenUsage.APICalls+second.TokenUsage.APICalls)
+	assert.Equal(t, 2, full.TokenUsage.APICalls)
+}
+
+func TestParseJSONLFromRestartsWhenRewritten(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.jsonl")
+
+	require.NoError(t, os.WriteFile(path, []byte(
+		`{"type":"user","timestamp":"2025-01-15T10:00:00Z","message":"A long first prompt"}`+"\n"+
+			`{"type":"user","timestamp":"2025-01-15T10:00:01Z","message":"Another prompt"}`+"\n"), 0o644))
+	_, state, err := ParseJSONLFrom(path, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"type":"user","message":"New"}`+"\n"), 0o644))
+	delta, _, err := ParseJSONLFrom(path, state)
+	require.NoError(t, err)
+	require.Len(t, delta.Prompts, 1)
+	assert.Equal(t, "New", delta.Prompts[0].Content)
+}
+
+func TestParseStateRoundTrip(t *testing.T) {
+	repoDir := t.TempDir()
+	transcript := "/home/dev/.claude/projects/-repo/abc-123.jsonl"
+
+	state, err := LoadParseState(repoDir, transcript)
+	require.NoError(t, err)
+	assert.Nil(t, state)
+
+	saved := &ParseState{Offset: 42, Open: &OpenRequest{RequestID: "req-1", Emitted: true}}
+	require.NoError(t, SaveParseState(repoDir, transcript, saved))
+	assert.FileExists(t, filepath.Join(repoDir, ".open-entire", "transcripts", "claude-code", "abc-123.json"))
+
+	loaded, err := LoadParseState(repoDir, transcript)
+	require.NoError(t, err)
+	assert.Equal(t, saved, loaded)
+}
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestParseJSONLLinksToolResults(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tools.jsonl")
+
+	content := `{"type":"user","timestamp":"2025-01-15T10:00:00Z","message":{"role":"user","content":"Run the tests"}}
+{"type":"assistant","timestamp":"2025-01-15T10:00:02Z","requestId":"req-1","message":{"content":[{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"go test ./..."}},{"type":"tool_use","id":"toolu_2","name":"Read","input":{"file_path":"missing.go"}}]}}
+{"type":"user","timestamp":"2025-01-15T10:00:05.500Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"ok  example.com/pkg"}]}}
+{"type":"user","timestamp":"2025-01-15T10:00:06Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_2","content":[{"type":"text","text":"File does not exist."}],"is_error":true}]}}
+{"type":"assistant","timestamp":"2025-01-15T10:00:08Z","requestId":"req-2","message":{"content":[{"type":"text","text":"Tests pass."}]}}
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	session, err := ParseJSONL(path)
+	require.NoError(t, err)
+
+	// Only the typed prompt counts
+	require.Len(t, session.Prompts, 1)
+	assert.Equal(t, "Run the tests", session.Prompts[0].Content)
+
+	require.Len(t, session.ToolCalls, 2)
+	bash := session.ToolCalls[0]
+	assert.Equal(t, "toolu_1", bash.ID)
+	assert.Equal(t, "ok  example.com/pkg", bash.Output)
+	assert.False(t, bash.IsError)
+	assert.Equal(t, int64(3500), bash.DurationMS)
+
+	read := session.ToolCalls[1]
+	assert.Equal(t, "File does not exist.", read.Output)
+	assert.True(t, read.IsError)
+	assert.Equal(t, int64(4000), read.DurationMS)
+}
+
+func TestParseJSONLFromReportsResultOfEarlierCall(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tools.jsonl")
+
+	require.NoError(t, os.WriteFile(path, []byte(
+		`{"type":"assistant","timestamp":"2025-01-15T10:00:02Z","requestId":"req-1","message":{"content":[{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"make"}}]}}`+"\n"), 0o644))
+	first, state, err := ParseJSONLFrom(path, nil)
+	require.NoError(t, err)
+	require.Len(t, first.ToolCalls, 1)
+	assert.Empty(t, first.ToolCalls[0].Output)
+	assert.Contains(t, state.Pending, "toolu_1")
+
+	// The state survives being saved between checkpoints
+	repoDir := t.TempDir()
+	require.NoError(t, SaveParseState(repoDir, path, state))
+	state, err = LoadParseState(repoDir, path)
+	require.NoError(t, err)
+
+	appendFile(t, path, `{"type":"user","timestamp":"2025-01-15T10:00:03.250Z","message":{"content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"build failed","is_error":true}]}}`+"\n")
+
+	delta, state, err := ParseJSONLFrom(path, state)
+	require.NoError(t, err)
+	assert.Empty(t, delta.Prompts)
+	require.Len(t, delta.ToolCalls, 1)
+	call := delta.ToolCalls[0]
+	assert.Equal(t, "toolu_1", call.ID)
+	assert.Equal(t, "Bash", call.Name)
+	assert.Empty(t, call.Input)
+	assert.Equal(t, "build failed", call.Output)
+	assert.True(t, call.IsError)
+	assert.Equal(t, int64(1250), call.DurationMS)
+	assert.Empty(t, state.Pending)
+
+	// A result is reported once
+	appendFile(t, path, `{"type":"user","timestamp":"2025-01-15T10:00:04Z","message":{"content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"again"}]}}`+"\n")
+	delta, _, err = ParseJSONLFrom(path, state)
+	require.NoError(t, err)
+	assert.Empty(t, delta.ToolCalls)
+}
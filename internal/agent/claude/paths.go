@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/yibudak/open-entire/internal/session"
 )
 
 // EncodePath converts a filesystem path to Claude's encoded format.
@@ -19,6 +21,19 @@ func ProjectDir(repoDir string) string {
 	return filepath.Join(home, ".claude", "projects", encoded)
 }
 
+// TranscriptPath returns where a session's transcript is. A session
+// reported by Claude Code's hooks is found wherever it said, e.g. when it
+// was started in a subdirectory of the repository; any other is looked for
+// in the repository's project directory.
+func TranscriptPath(repoDir, sessionID string) string {
+	if store, err := session.NewStore(repoDir); err == nil {
+		if s, ok := store.GetSession(sessionID); ok && s.AgentName == agentName && s.TranscriptPath != "" {
+			return s.TranscriptPath
+		}
+	}
+	return filepath.Join(ProjectDir(repoDir), sessionID+".jsonl")
+}
+
 // SessionFiles returns all .jsonl session files for a project.
 func SessionFiles(repoDir string) ([]string, error) {
 	dir := ProjectDir(repoDir)
@@ -38,7 +53,7 @@ func SessionFiles(repoDir string) ([]string, error) {
 
 // SubagentFiles returns subagent JSONL files for a session.
 func SubagentFiles(repoDir, sessionID string) ([]string, error) {
-	dir := filepath.Join(ProjectDir(repoDir), sessionID, "subagents")
+	dir := filepath.Join(filepath.Dir(TranscriptPath(repoDir, sessionID)), sessionID, "subagents")
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
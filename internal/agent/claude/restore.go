@@ -0,0 +1,80 @@
+package claude
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RestoreTranscript writes a checkpointed transcript back where Claude
+// Code keeps the session, so that `claude --resume` finds it. A local
+// transcript that already holds every event of it is left alone, even if
+// the stored copy was redacted; any other is kept beside it with a .bak
+// suffix. The parse state is moved to the end of the
+// restored transcript, so only what the resumed session appends is
+// checkpointed again.
+func (a *ClaudeAgent) RestoreTranscript(sessionID, repoDir string, transcript []byte) (string, error) {
+	if sessionID == "" || sessionID != filepath.Base(sessionID) || strings.HasPrefix(sessionID, ".") {
+		return "", fmt.Errorf("invalid session ID %q", sessionID)
+	}
+	path := TranscriptPath(repoDir, sessionID)
+
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil && containsEvents(existing, transcript):
+		return path, nil
+	case err == nil:
+		backup := fmt.Sprintf("%s.%s.bak", path, time.Now().UTC().Format("20060102-150405"))
+		if err := os.Rename(path, backup); err != nil {
+			return "", fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, transcript, 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", err
+	}
+
+	if err := SaveParseState(repoDir, path, &ParseState{Offset: int64(len(transcript))}); err != nil {
+		return path, fmt.Errorf("failed to save parse state: %w", err)
+	}
+	return path, nil
+}
+
+// containsEvents reports whether a transcript holds every event of another.
+// Events are matched by their uuid, which redaction leaves alone, and
+// lines without one by their text.
+func containsEvents(transcript, events []byte) bool {
+	have := make(map[string]bool)
+	for _, line := range bytes.Split(transcript, []byte("\n")) {
+		have[eventKey(line)] = true
+	}
+	for _, line := range bytes.Split(events, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 && !have[eventKey(line)] {
+			return false
+		}
+	}
+	return true
+}
+
+func eventKey(line []byte) string {
+	var event struct {
+		UUID string `json:"uuid"`
+	}
+	if json.Unmarshal(line, &event) == nil && event.UUID != "" {
+		return "uuid:" + event.UUID
+	}
+	return "line:" + string(bytes.TrimSpace(line))
+}
@@ -0,0 +1,56 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreTranscript(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repoDir := t.TempDir()
+	a := &ClaudeAgent{}
+	transcript := []byte(`{"type":"user","message":{"role":"user","content":"hi"}}` + "\n")
+
+	path, err := a.RestoreTranscript("sess-1", repoDir, transcript)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(ProjectDir(repoDir), "sess-1.jsonl"), path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, transcript, data)
+
+	state, err := LoadParseState(repoDir, path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(transcript)), state.Offset)
+
+	// A local transcript that has moved on is left alone
+	longer := append(append([]byte{}, transcript...), []byte(`{"type":"assistant"}`+"\n")...)
+	require.NoError(t, os.WriteFile(path, longer, 0o600))
+	_, err = a.RestoreTranscript("sess-1", repoDir, transcript)
+	require.NoError(t, err)
+	data, _ = os.ReadFile(path)
+	assert.Equal(t, longer, data)
+
+	// So is one whose events the stored copy redacted
+	local := []byte(`{"uuid":"u1","type":"user","message":{"role":"user","content":"export TOKEN=abc"}}` + "\n" +
+		`{"uuid":"u2","type":"assistant"}` + "\n")
+	require.NoError(t, os.WriteFile(path, local, 0o600))
+	redacted := []byte(`{"uuid":"u1","type":"user","message":{"role":"user","content":"export TOKEN=[REDACTED]"}}` + "\n")
+	_, err = a.RestoreTranscript("sess-1", repoDir, redacted)
+	require.NoError(t, err)
+	data, _ = os.ReadFile(path)
+	assert.Equal(t, local, data)
+
+	// A different one is backed up
+	require.NoError(t, os.WriteFile(path, []byte("other\n"), 0o600))
+	_, err = a.RestoreTranscript("sess-1", repoDir, transcript)
+	require.NoError(t, err)
+	backups, _ := filepath.Glob(path + ".*.bak")
+	assert.Len(t, backups, 1)
+
+	_, err = a.RestoreTranscript("../escape", repoDir, transcript)
+	assert.Error(t, err)
+}
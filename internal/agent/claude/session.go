@@ -2,8 +2,10 @@ package claude
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/yibudak/open-entire/internal/agent"
 	"github.com/yibudak/open-entire/pkg/types"
@@ -24,9 +26,12 @@ func (a *ClaudeAgent) Detect(repoDir string) (string, error) {
 	return Detect(repoDir)
 }
 
+func (a *ClaudeAgent) SessionsSince(repoDir string, since time.Time) ([]types.SessionActivity, error) {
+	return SessionsSince(repoDir, since)
+}
+
 func (a *ClaudeAgent) ParseSession(sessionID string, repoDir string) (*types.SessionData, error) {
-	projDir := ProjectDir(repoDir)
-	path := filepath.Join(projDir, sessionID+".jsonl")
+	path := TranscriptPath(repoDir, sessionID)
 
 	session, err := ParseJSONL(path)
 	if err != nil {
@@ -34,6 +39,7 @@ func (a *ClaudeAgent) ParseSession(sessionID string, repoDir string) (*types.Ses
 	}
 
 	session.ID = sessionID
+	session.TranscriptPath = path
 
 	// Parse subagent sessions
 	subFiles, err := SubagentFiles(repoDir, sessionID)
@@ -51,6 +57,99 @@ func (a *ClaudeAgent) ParseSession(sessionID string, repoDir string) (*types.Ses
 	return session, nil
 }
 
+// ParseSessionDelta parses what the session and its subagents appended since
+// the last checkpoint, resuming from the byte offsets saved in .open-entire/.
+func (a *ClaudeAgent) ParseSessionDelta(sessionID string, repoDir string) (*types.SessionData, func() error, error) {
+	path := TranscriptPath(repoDir, sessionID)
+
+	type resume struct {
+		path  string
+		state *ParseState
+	}
+	var advances []resume
+
+	parse := func(path string) (*types.SessionData, error) {
+		state, err := LoadParseState(repoDir, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load parse state for %s: %w", filepath.Base(path), err)
+		}
+		delta, next, err := ParseJSONLFrom(path, state)
+		if err != nil {
+			return nil, err
+		}
+		advances = append(advances, resume{path: path, state: next})
+		return delta, nil
+	}
+
+	session, err := parse(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse Claude session %s: %w", sessionID, err)
+	}
+	session.ID = sessionID
+	session.TranscriptPath = path
+
+	subFiles, err := SubagentFiles(repoDir, sessionID)
+	if err == nil {
+		for _, sf := range subFiles {
+			subSession, err := parse(sf)
+			if err != nil {
+				continue
+			}
+			if isEmptyDelta(subSession) {
+				continue
+			}
+			subSession.ID = strings.TrimSuffix(filepath.Base(sf), ".jsonl")
+			session.NestedSessions = append(session.NestedSessions, *subSession)
+		}
+	}
+
+	advance := func() error {
+		for _, r := range advances {
+			if err := SaveParseState(repoDir, r.path, r.state); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return session, advance, nil
+}
+
+// HasPending reports whether the session or any of its subagents has grown
+// past the byte offset last checkpointed. Only file sizes are compared.
+func (a *ClaudeAgent) HasPending(sessionID string, repoDir string) bool {
+	paths := []string{TranscriptPath(repoDir, sessionID)}
+	if subFiles, err := SubagentFiles(repoDir, sessionID); err == nil {
+		paths = append(paths, subFiles...)
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		state, err := LoadParseState(repoDir, path)
+		if err != nil || state == nil {
+			if info.Size() > 0 {
+				return true
+			}
+			continue
+		}
+		if info.Size() != state.Offset {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyDelta(s *types.SessionData) bool {
+	return len(s.Prompts) == 0 && len(s.Responses) == 0 && len(s.ToolCalls) == 0
+}
+
+// ParseTranscript parses a stored transcript slice. A slice that starts
+// mid-session parses as if the session began there.
+func (a *ClaudeAgent) ParseTranscript(path string) (*types.SessionData, error) {
+	return ParseJSONL(path)
+}
+
 func (a *ClaudeAgent) SessionPaths(repoDir string) types.AgentPaths {
 	return types.AgentPaths{
 		SessionDir: ProjectDir(repoDir),
@@ -0,0 +1,50 @@
+package claude
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StatePath returns where the parse state of a transcript is kept.
+func StatePath(repoDir, transcriptPath string) string {
+	name := strings.TrimSuffix(filepath.Base(transcriptPath), ".jsonl") + ".json"
+	return filepath.Join(repoDir, ".open-entire", "transcripts", agentName, name)
+}
+
+// LoadParseState reads the saved parse state of a transcript.
+// It returns nil if the transcript has not been checkpointed yet.
+func LoadParseState(repoDir, transcriptPath string) (*ParseState, error) {
+	data, err := os.ReadFile(StatePath(repoDir, transcriptPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var state ParseState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SaveParseState records how far a transcript has been checkpointed.
+func SaveParseState(repoDir, transcriptPath string, state *ParseState) error {
+	path := StatePath(repoDir, transcriptPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so a crash never leaves a torn state file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
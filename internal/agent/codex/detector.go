@@ -0,0 +1,68 @@
+package codex
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+const (
+	agentName = "codex"
+	// Consider sessions active if modified within this window
+	activeWindow = 5 * time.Minute
+)
+
+// Detect checks if Codex CLI is active for the given repo.
+// Returns the session ID if found.
+func Detect(repoDir string) (string, error) {
+	recent, err := SessionsSince(repoDir, time.Now().Add(-activeWindow))
+	if err != nil {
+		return "", err
+	}
+	if len(recent) == 0 {
+		return "", fmt.Errorf("no active Codex session found")
+	}
+	return recent[0].SessionID, nil
+}
+
+// SessionsSince returns the sessions started in repoDir whose rollout was
+// modified after since, most recently modified first.
+func SessionsSince(repoDir string, since time.Time) ([]types.SessionActivity, error) {
+	files, err := RolloutFiles()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no Codex sessions directory found")
+		}
+		return nil, err
+	}
+
+	repoDir = filepath.Clean(repoDir)
+	var recent []types.SessionActivity
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil || !info.ModTime().After(since) {
+			continue
+		}
+
+		// Sessions belong to the repo they were started in
+		meta, err := ReadMeta(f)
+		if err != nil || filepath.Clean(meta.Cwd) != repoDir {
+			continue
+		}
+
+		recent = append(recent, types.SessionActivity{
+			SessionID:    SessionID(f),
+			LastActivity: info.ModTime(),
+			Reason:       "rollout " + filepath.Base(f) + " modified with matching cwd",
+		})
+	}
+
+	sort.Slice(recent, func(i, j int) bool {
+		return recent[i].LastActivity.After(recent[j].LastActivity)
+	})
+	return recent, nil
+}
@@ -0,0 +1,93 @@
+package codex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// installFixtures copies the testdata rollouts into a fresh CODEX_HOME.
+func installFixtures(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("CODEX_HOME", home)
+
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "rollout-*.jsonl"))
+	require.NoError(t, err)
+	for _, src := range fixtures {
+		data, err := os.ReadFile(src)
+		require.NoError(t, err)
+		dir := filepath.Join(home, "sessions", "2025", "09", "20")
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.Base(src)), data, 0o644))
+	}
+}
+
+func TestSessionsSinceMatchesCwd(t *testing.T) {
+	installFixtures(t)
+
+	ids, err := SessionsSince("/work/repo", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{fixtureID}, sessionIDs(ids))
+
+	id, err := Detect("/work/repo/")
+	require.NoError(t, err)
+	assert.Equal(t, fixtureID, id)
+
+	ids, err = SessionsSince("/work/unrelated", time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestParseSessionFindsRollout(t *testing.T) {
+	installFixtures(t)
+
+	session, err := (&CodexAgent{}).ParseSession(fixtureID, "/work/repo")
+	require.NoError(t, err)
+	assert.Equal(t, fixtureID, session.ID)
+	assert.FileExists(t, session.TranscriptPath)
+
+	_, err = (&CodexAgent{}).ParseSession("missing", "/work/repo")
+	assert.Error(t, err)
+}
+
+func TestSessionsSinceNoSessionsDir(t *testing.T) {
+	t.Setenv("CODEX_HOME", t.TempDir())
+	_, err := SessionsSince("/work/repo", time.Time{})
+	assert.Error(t, err)
+}
+
+func sessionIDs(sessions []types.SessionActivity) []string {
+	ids := make([]string, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.SessionID
+	}
+	return ids
+}
+
+func TestSessionIDFromMetadata(t *testing.T) {
+	installFixtures(t)
+
+	// A rollout whose file name does not carry its session ID
+	src, err := filepath.Glob(filepath.Join("testdata", "rollout-2025-09-20T*.jsonl"))
+	require.NoError(t, err)
+	require.Len(t, src, 1)
+	dir := filepath.Join(os.Getenv("CODEX_HOME"), "sessions", "2025", "09", "20")
+	require.NoError(t, os.Rename(filepath.Join(dir, filepath.Base(src[0])), filepath.Join(dir, "rollout-2025-09-20T10-00-00-renamed.jsonl")))
+
+	ids, err := SessionsSince("/work/repo", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{fixtureID}, sessionIDs(ids))
+
+	session, err := (&CodexAgent{}).ParseSession(ids[0].SessionID, "/work/repo")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "rollout-2025-09-20T10-00-00-renamed.jsonl"), session.TranscriptPath)
+
+	_, err = FindRollout("renamed")
+	assert.Error(t, err)
+}
@@ -0,0 +1,333 @@
+package codex
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// RolloutLine is a single line of a Codex rollout file.
+type RolloutLine struct {
+	Timestamp string          `json:"timestamp"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// SessionMeta is the payload of the session_meta line that opens a rollout.
+type SessionMeta struct {
+	ID         string `json:"id"`
+	Timestamp  string `json:"timestamp"`
+	Cwd        string `json:"cwd"`
+	Originator string `json:"originator"`
+	CLIVersion string `json:"cli_version"`
+}
+
+// TurnContext is the payload of the turn_context line that starts each
+// turn, naming the model that answers it.
+type TurnContext struct {
+	Cwd   string `json:"cwd"`
+	Model string `json:"model"`
+}
+
+// ResponseItem is a model input or output item recorded in the rollout.
+type ResponseItem struct {
+	Type      string          `json:"type"`
+	Role      string          `json:"role,omitempty"`
+	Content   []ContentItem   `json:"content,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Arguments string          `json:"arguments,omitempty"`
+	Input     string          `json:"input,omitempty"`
+	CallID    string          `json:"call_id,omitempty"`
+	Output    json.RawMessage `json:"output,omitempty"`
+}
+
+// ContentItem is one part of a message's content.
+type ContentItem struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// EventMsg is the payload of an event_msg line.
+type EventMsg struct {
+	Type string          `json:"type"`
+	Info *TokenCountInfo `json:"info,omitempty"`
+}
+
+// TokenCountInfo carries cumulative and per-request token usage.
+type TokenCountInfo struct {
+	TotalTokenUsage UsageData `json:"total_token_usage"`
+	LastTokenUsage  UsageData `json:"last_token_usage"`
+}
+
+// UsageData represents token usage from the OpenAI API.
+// InputTokens includes CachedInputTokens.
+type UsageData struct {
+	InputTokens           int `json:"input_tokens"`
+	CachedInputTokens     int `json:"cached_input_tokens"`
+	OutputTokens          int `json:"output_tokens"`
+	ReasoningOutputTokens int `json:"reasoning_output_tokens"`
+	TotalTokens           int `json:"total_tokens"`
+}
+
+// ReadMeta reads the session metadata from the first line of a rollout.
+func ReadMeta(path string) (*SessionMeta, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("empty rollout: %s", path)
+	}
+
+	var line RolloutLine
+	if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+		return nil, err
+	}
+	if line.Type != "session_meta" {
+		return nil, fmt.Errorf("rollout %s does not start with session metadata", path)
+	}
+
+	var meta SessionMeta
+	if err := json.Unmarshal(line.Payload, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// ParseRollout parses a Codex CLI rollout file.
+func ParseRollout(path string) (*types.SessionData, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	session := &types.SessionData{
+		AgentName: agentName,
+	}
+
+	// Tool calls by call_id, so outputs can be attached
+	callIndex := make(map[string]int)
+	// Usage reported before the turn's first response is held until it arrives
+	var pending types.TokenUsage
+	respondedThisTurn := false
+	// The model can change between turns
+	var model string
+	var first, last time.Time
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024) // 10MB line buffer
+
+	for scanner.Scan() {
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		var line RolloutLine
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			continue // Skip malformed lines
+		}
+
+		ts := parseTimestamp(line.Timestamp)
+		if !ts.IsZero() {
+			if first.IsZero() {
+				first = ts
+			}
+			last = ts
+		}
+
+		switch line.Type {
+		case "session_meta":
+			var meta SessionMeta
+			if json.Unmarshal(line.Payload, &meta) == nil {
+				session.ID = meta.ID
+				if t := parseTimestamp(meta.Timestamp); !t.IsZero() {
+					first = t
+				}
+			}
+
+		case "turn_context":
+			var tc TurnContext
+			if json.Unmarshal(line.Payload, &tc) == nil && tc.Model != "" {
+				model = tc.Model
+			}
+
+		case "response_item":
+			var item ResponseItem
+			if json.Unmarshal(line.Payload, &item) != nil {
+				continue
+			}
+
+			switch item.Type {
+			case "message":
+				text := messageText(item.Content)
+				if text == "" {
+					continue
+				}
+				switch item.Role {
+				case "user":
+					if isInjectedContext(text) {
+						continue
+					}
+					session.Prompts = append(session.Prompts, types.Prompt{
+						Content:   text,
+						Timestamp: ts,
+					})
+					respondedThisTurn = false
+				case "assistant":
+					session.Responses = append(session.Responses, types.Response{
+						Content:    text,
+						Timestamp:  ts,
+						Model:      model,
+						TokenUsage: pending,
+					})
+					pending = types.TokenUsage{}
+					respondedThisTurn = true
+				}
+
+			case "function_call", "custom_tool_call", "local_shell_call":
+				input := item.Arguments
+				if input == "" {
+					input = item.Input
+				}
+				callIndex[item.CallID] = len(session.ToolCalls)
+				session.ToolCalls = append(session.ToolCalls, types.ToolCall{
+					Name:      item.Name,
+					Input:     input,
+					Timestamp: ts,
+					RequestID: item.CallID,
+				})
+
+			case "function_call_output", "custom_tool_call_output":
+				if i, ok := callIndex[item.CallID]; ok {
+					session.ToolCalls[i].Output = toolOutput(item.Output)
+				}
+			}
+
+		case "event_msg":
+			var ev EventMsg
+			if json.Unmarshal(line.Payload, &ev) != nil || ev.Type != "token_count" || ev.Info == nil {
+				continue
+			}
+
+			// Totals are cumulative, so the last event wins
+			session.TokenUsage = convertUsage(ev.Info.TotalTokenUsage)
+			session.TokenUsage.APICalls = 0
+
+			usage := convertUsage(ev.Info.LastTokenUsage)
+			if respondedThisTurn && len(session.Responses) > 0 {
+				addUsage(&session.Responses[len(session.Responses)-1].TokenUsage, usage)
+			} else {
+				addUsage(&pending, usage)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rollout: %w", err)
+	}
+
+	for _, r := range session.Responses {
+		session.TokenUsage.APICalls += r.TokenUsage.APICalls
+	}
+	session.TokenUsage.APICalls += pending.APICalls
+
+	session.StartedAt = first
+	if !last.IsZero() {
+		session.EndedAt = &last
+	}
+
+	return session, nil
+}
+
+// convertUsage maps OpenAI usage onto TokenUsage, where InputTokens
+// excludes cache reads as it does for Claude.
+func convertUsage(u UsageData) types.TokenUsage {
+	return types.TokenUsage{
+		InputTokens:  u.InputTokens - u.CachedInputTokens,
+		OutputTokens: u.OutputTokens,
+		CacheReads:   u.CachedInputTokens,
+		APICalls:     1,
+	}
+}
+
+func addUsage(dst *types.TokenUsage, u types.TokenUsage) {
+	dst.InputTokens += u.InputTokens
+	dst.OutputTokens += u.OutputTokens
+	dst.CacheCreation += u.CacheCreation
+	dst.CacheReads += u.CacheReads
+	dst.APICalls += u.APICalls
+}
+
+func messageText(content []ContentItem) string {
+	var parts []string
+	for _, c := range content {
+		if c.Text != "" {
+			parts = append(parts, c.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// isInjectedContext reports whether a user message was injected by Codex
+// rather than typed by the user.
+func isInjectedContext(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, "<environment_context>") ||
+		strings.HasPrefix(text, "<user_instructions>")
+}
+
+// toolOutput unwraps a tool output, which is either plain text, a JSON
+// string holding {"output": ...}, or an object with a content field.
+func toolOutput(raw json.RawMessage) string {
+	if raw == nil {
+		return ""
+	}
+
+	var wrapped struct {
+		Output  *string `json:"output"`
+		Content *string `json:"content"`
+	}
+
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		if json.Unmarshal([]byte(s), &wrapped) == nil && wrapped.Output != nil {
+			return *wrapped.Output
+		}
+		return s
+	}
+
+	if json.Unmarshal(raw, &wrapped) == nil {
+		if wrapped.Content != nil {
+			return *wrapped.Content
+		}
+		if wrapped.Output != nil {
+			return *wrapped.Output
+		}
+	}
+	return string(raw)
+}
+
+func parseTimestamp(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,87 @@
+package codex
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fixtureID = "0199a1b2-c3d4-7e5f-8a9b-0c1d2e3f4a5b"
+
+var fixturePath = filepath.Join("testdata", "rollout-2025-09-20T10-00-00-"+fixtureID+".jsonl")
+
+func TestParseRollout(t *testing.T) {
+	session, err := ParseRollout(fixturePath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "codex", session.AgentName)
+	assert.Equal(t, fixtureID, session.ID)
+	assert.Equal(t, "2025-09-20T10:00:00Z", session.StartedAt.Format("2006-01-02T15:04:05Z07:00"))
+	require.NotNil(t, session.EndedAt)
+
+	// Injected environment context is not a prompt
+	require.Len(t, session.Prompts, 2)
+	assert.Equal(t, "List the Go files", session.Prompts[0].Content)
+	assert.Equal(t, "Add a greeting", session.Prompts[1].Content)
+
+	require.Len(t, session.Responses, 2)
+	assert.Equal(t, "There is one Go file: main.go.", session.Responses[0].Content)
+	// Each response is answered by the model of its turn
+	assert.Equal(t, "gpt-5-codex", session.Responses[0].Model)
+	assert.Equal(t, "gpt-5-codex", session.Responses[1].Model)
+	// Usage before and after the first response both belong to it
+	assert.Equal(t, 1000+600, session.Responses[0].TokenUsage.InputTokens)
+	assert.Equal(t, 90, session.Responses[0].TokenUsage.OutputTokens)
+	assert.Equal(t, 2, session.Responses[0].TokenUsage.APICalls)
+	assert.Equal(t, 200, session.Responses[1].TokenUsage.InputTokens)
+
+	// Totals come from the last cumulative token count
+	assert.Equal(t, 1800, session.TokenUsage.InputTokens)
+	assert.Equal(t, 2200, session.TokenUsage.CacheReads)
+	assert.Equal(t, 150, session.TokenUsage.OutputTokens)
+	assert.Equal(t, 3, session.TokenUsage.APICalls)
+
+	require.Len(t, session.ToolCalls, 2)
+	assert.Equal(t, "shell", session.ToolCalls[0].Name)
+	assert.Contains(t, session.ToolCalls[0].Input, "ls *.go")
+	assert.Equal(t, "main.go\n", session.ToolCalls[0].Output)
+	assert.Equal(t, "apply_patch", session.ToolCalls[1].Name)
+	assert.Contains(t, session.ToolCalls[1].Input, "*** Add File: greet.go")
+	assert.Contains(t, session.ToolCalls[1].Output, "A greet.go")
+}
+
+func TestParseRolloutModelPerTurn(t *testing.T) {
+	lines := []string{
+		`{"timestamp":"2025-09-20T10:00:00Z","type":"session_meta","payload":{"id":"s","cwd":"/work/repo"}}`,
+		`{"timestamp":"2025-09-20T10:00:01Z","type":"turn_context","payload":{"cwd":"/work/repo","model":"gpt-5-codex"}}`,
+		`{"timestamp":"2025-09-20T10:00:02Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"one"}]}}`,
+		`{"timestamp":"2025-09-20T10:00:03Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"first"}]}}`,
+		`{"timestamp":"2025-09-20T10:01:00Z","type":"turn_context","payload":{"cwd":"/work/repo","model":"gpt-5-mini"}}`,
+		`{"timestamp":"2025-09-20T10:01:01Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"two"}]}}`,
+		`{"timestamp":"2025-09-20T10:01:02Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"second"}]}}`,
+	}
+	path := filepath.Join(t.TempDir(), "rollout.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+
+	session, err := ParseRollout(path)
+	require.NoError(t, err)
+	require.Len(t, session.Responses, 2)
+	assert.Equal(t, "gpt-5-codex", session.Responses[0].Model)
+	assert.Equal(t, "gpt-5-mini", session.Responses[1].Model)
+}
+
+func TestReadMeta(t *testing.T) {
+	meta, err := ReadMeta(fixturePath)
+	require.NoError(t, err)
+	assert.Equal(t, fixtureID, meta.ID)
+	assert.Equal(t, "/work/repo", meta.Cwd)
+}
+
+func TestSessionIDFromPath(t *testing.T) {
+	assert.Equal(t, fixtureID, SessionIDFromPath(fixturePath))
+	assert.Equal(t, "", SessionIDFromPath("testdata/notes.jsonl"))
+}
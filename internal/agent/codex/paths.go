@@ -0,0 +1,86 @@
+package codex
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// rolloutPrefix and rolloutStampLen describe rollout file names:
+// rollout-2025-09-20T10-00-00-<session-id>.jsonl
+const (
+	rolloutPrefix   = "rollout-"
+	rolloutStampLen = len("2025-09-20T10-00-00")
+)
+
+// HomeDir returns the Codex home directory, honouring CODEX_HOME.
+func HomeDir() string {
+	if dir := os.Getenv("CODEX_HOME"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".codex")
+}
+
+// SessionsDir returns the directory Codex writes rollout files to.
+// Rollouts are stored as sessions/YYYY/MM/DD/rollout-*.jsonl.
+func SessionsDir() string {
+	return filepath.Join(HomeDir(), "sessions")
+}
+
+// RolloutFiles returns all rollout files under the sessions directory.
+func RolloutFiles() ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(SessionsDir(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && isRollout(d.Name()) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// SessionIDFromPath extracts the session ID from a rollout file name.
+func SessionIDFromPath(path string) string {
+	name := filepath.Base(path)
+	if !isRollout(name) || len(name) <= len(rolloutPrefix)+rolloutStampLen+len("-.jsonl") {
+		return ""
+	}
+	return strings.TrimSuffix(name[len(rolloutPrefix)+rolloutStampLen+1:], ".jsonl")
+}
+
+// SessionID returns the ID of the session a rollout records: the ID in its
+// session metadata, or the one in its file name for rollouts without one.
+// Sessions are always named by this, so they are found again by it.
+func SessionID(path string) string {
+	if meta, err := ReadMeta(path); err == nil && meta.ID != "" {
+		return meta.ID
+	}
+	return SessionIDFromPath(path)
+}
+
+// FindRollout returns the rollout file for a session ID.
+func FindRollout(sessionID string) (string, error) {
+	files, err := RolloutFiles()
+	if err != nil {
+		return "", err
+	}
+	for _, f := range files {
+		if SessionID(f) == sessionID {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("no Codex rollout found for session %s", sessionID)
+}
+
+func isRollout(name string) bool {
+	return strings.HasPrefix(name, rolloutPrefix) && strings.HasSuffix(name, ".jsonl")
+}
@@ -0,0 +1,55 @@
+package codex
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yibudak/open-entire/internal/agent"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// CodexAgent implements the Agent interface for OpenAI Codex CLI.
+type CodexAgent struct{}
+
+func init() {
+	agent.Register(&CodexAgent{})
+}
+
+func (a *CodexAgent) Name() string {
+	return agentName
+}
+
+func (a *CodexAgent) Detect(repoDir string) (string, error) {
+	return Detect(repoDir)
+}
+
+func (a *CodexAgent) SessionsSince(repoDir string, since time.Time) ([]types.SessionActivity, error) {
+	return SessionsSince(repoDir, since)
+}
+
+func (a *CodexAgent) ParseSession(sessionID string, repoDir string) (*types.SessionData, error) {
+	path, err := FindRollout(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := ParseRollout(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Codex session %s: %w", sessionID, err)
+	}
+
+	session.ID = sessionID
+	session.TranscriptPath = path
+	return session, nil
+}
+
+func (a *CodexAgent) ParseTranscript(path string) (*types.SessionData, error) {
+	return ParseRollout(path)
+}
+
+func (a *CodexAgent) SessionPaths(repoDir string) types.AgentPaths {
+	return types.AgentPaths{
+		SessionDir: SessionsDir(),
+		Pattern:    "*/*/*/rollout-*.jsonl",
+	}
+}
@@ -0,0 +1,60 @@
+package gemini
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+const (
+	agentName = "gemini-cli"
+	// Consider sessions active if modified within this window
+	activeWindow = 5 * time.Minute
+)
+
+// Detect checks if Gemini CLI is active for the given repo.
+// Returns the session ID if found.
+func Detect(repoDir string) (string, error) {
+	recent, err := SessionsSince(repoDir, time.Now().Add(-activeWindow))
+	if err != nil {
+		return "", err
+	}
+	if len(recent) == 0 {
+		return "", fmt.Errorf("no active Gemini CLI session found")
+	}
+	return recent[0].SessionID, nil
+}
+
+// SessionsSince returns the sessions whose file was modified after since,
+// most recently modified first.
+func SessionsSince(repoDir string, since time.Time) ([]types.SessionActivity, error) {
+	files, err := SessionFiles(repoDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no Gemini CLI project directory found")
+		}
+		return nil, err
+	}
+
+	var recent []types.SessionActivity
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil || !info.ModTime().After(since) {
+			continue
+		}
+		recent = append(recent, types.SessionActivity{
+			SessionID:    SessionID(f),
+			LastActivity: info.ModTime(),
+			Reason:       "session file " + filepath.Base(f) + " modified",
+		})
+	}
+
+	sort.Slice(recent, func(i, j int) bool {
+		return recent[i].LastActivity.After(recent[j].LastActivity)
+	})
+	return recent, nil
+}
@@ -0,0 +1,69 @@
+package gemini
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func installFixture(t *testing.T, repoDir, src, dst string, modTime time.Time) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", src))
+	require.NoError(t, err)
+	path := filepath.Join(ProjectDir(repoDir), dst)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestProjectDir(t *testing.T) {
+	t.Setenv("HOME", "/home/dev")
+	// sha256 of the absolute project path
+	assert.Equal(t, "1afbf223bb0b58ba08766ec87173ebd68e0507d66983531a95b52ff9b529c7db", ProjectHash("/home/dev/project"))
+	assert.Equal(t, "/home/dev/.gemini/tmp/1afbf223bb0b58ba08766ec87173ebd68e0507d66983531a95b52ff9b529c7db", ProjectDir("/home/dev/project"))
+}
+
+func TestSessionsSince(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repoDir := "/work/repo"
+	now := time.Now()
+
+	installFixture(t, repoDir, "session-2025-09-20T10-00-4f1c2d3e.json", "chats/session-2025-09-20T10-00-4f1c2d3e.json", now.Add(-time.Minute))
+	installFixture(t, repoDir, "checkpoint-refactor.json", "checkpoint-refactor.json", now.Add(-10*time.Minute))
+	installFixture(t, repoDir, "checkpoint-refactor.json", "checkpoint-old.json", now.Add(-48*time.Hour))
+
+	ids, err := SessionsSince(repoDir, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"session-2025-09-20T10-00-4f1c2d3e", "checkpoint-refactor"}, sessionIDs(ids))
+
+	id, err := Detect(repoDir)
+	require.NoError(t, err)
+	assert.Equal(t, "session-2025-09-20T10-00-4f1c2d3e", id)
+
+	agent := &GeminiAgent{}
+	for _, id := range sessionIDs(ids) {
+		session, err := agent.ParseSession(id, repoDir)
+		require.NoError(t, err)
+		assert.Equal(t, id, session.ID)
+		assert.FileExists(t, session.TranscriptPath)
+	}
+}
+
+func TestSessionsSinceNoProjectDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	_, err := SessionsSince("/work/missing", time.Time{})
+	assert.Error(t, err)
+}
+
+func sessionIDs(sessions []types.SessionActivity) []string {
+	ids := make([]string, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.SessionID
+	}
+	return ids
+}
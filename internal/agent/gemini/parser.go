@@ -0,0 +1,296 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// ConversationRecord is a recorded chat under chats/session-*.json.
+type ConversationRecord struct {
+	SessionID   string        `json:"sessionId"`
+	StartTime   string        `json:"startTime"`
+	LastUpdated string        `json:"lastUpdated"`
+	Messages    []ChatMessage `json:"messages"`
+}
+
+// ChatMessage is a single message in a recorded chat.
+// Type is "user", "gemini", or a UI notice such as "info" or "error".
+type ChatMessage struct {
+	ID        string          `json:"id"`
+	Timestamp string          `json:"timestamp"`
+	Type      string          `json:"type"`
+	Content   json.RawMessage `json:"content"`
+	Model     string          `json:"model,omitempty"`
+	Tokens    *TokensSummary  `json:"tokens,omitempty"`
+	ToolCalls []ToolCallEntry `json:"toolCalls,omitempty"`
+}
+
+// TokensSummary is the token usage of one model response.
+// Input includes Cached.
+type TokensSummary struct {
+	Input    int `json:"input"`
+	Output   int `json:"output"`
+	Cached   int `json:"cached"`
+	Thoughts int `json:"thoughts"`
+	Tool     int `json:"tool"`
+	Total    int `json:"total"`
+}
+
+// ToolCallEntry is a tool call recorded with a model response.
+type ToolCallEntry struct {
+	ID            string          `json:"id"`
+	Name          string          `json:"name"`
+	Args          json.RawMessage `json:"args"`
+	Result        []Part          `json:"result,omitempty"`
+	ResultDisplay json.RawMessage `json:"resultDisplay,omitempty"`
+	Status        string          `json:"status"`
+	Timestamp     string          `json:"timestamp"`
+}
+
+// Content is a Gemini API content entry, as stored in checkpoint files.
+type Content struct {
+	Role  string `json:"role"`
+	Parts []Part `json:"parts"`
+}
+
+// Part is one part of a Gemini API content entry.
+type Part struct {
+	Text             string            `json:"text,omitempty"`
+	FunctionCall     *FunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *FunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// FunctionCall is a tool invocation requested by the model.
+type FunctionCall struct {
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args"`
+}
+
+// FunctionResponse is the result of a tool invocation.
+type FunctionResponse struct {
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+// ParseFile parses a Gemini CLI chat recording or saved checkpoint.
+func ParseFile(path string) (*types.SessionData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var history []Content
+		if err := json.Unmarshal(data, &history); err != nil {
+			return nil, fmt.Errorf("invalid checkpoint file: %w", err)
+		}
+		return parseHistory(history), nil
+	}
+
+	var obj struct {
+		ConversationRecord
+		History []Content `json:"history"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("invalid session file: %w", err)
+	}
+	if obj.Messages == nil && obj.History != nil {
+		return parseHistory(obj.History), nil
+	}
+	return parseConversation(&obj.ConversationRecord), nil
+}
+
+func parseConversation(rec *ConversationRecord) *types.SessionData {
+	session := &types.SessionData{
+		ID:        rec.SessionID,
+		AgentName: agentName,
+		StartedAt: parseTimestamp(rec.StartTime),
+	}
+	if t := parseTimestamp(rec.LastUpdated); !t.IsZero() {
+		session.EndedAt = &t
+	}
+
+	for _, msg := range rec.Messages {
+		ts := parseTimestamp(msg.Timestamp)
+		text := contentText(msg.Content)
+
+		switch msg.Type {
+		case "user":
+			if text != "" {
+				session.Prompts = append(session.Prompts, types.Prompt{
+					Content:   text,
+					Timestamp: ts,
+					RequestID: msg.ID,
+				})
+			}
+
+		case "gemini":
+			resp := types.Response{
+				Content:   text,
+				Timestamp: ts,
+				RequestID: msg.ID,
+				Model:     msg.Model,
+			}
+			if msg.Tokens != nil {
+				resp.TokenUsage = convertTokens(msg.Tokens)
+				session.TokenUsage.InputTokens += resp.TokenUsage.InputTokens
+				session.TokenUsage.OutputTokens += resp.TokenUsage.OutputTokens
+				session.TokenUsage.CacheReads += resp.TokenUsage.CacheReads
+				session.TokenUsage.APICalls++
+			}
+			session.Responses = append(session.Responses, resp)
+
+			for _, tc := range msg.ToolCalls {
+				callTS := parseTimestamp(tc.Timestamp)
+				if callTS.IsZero() {
+					callTS = ts
+				}
+				session.ToolCalls = append(session.ToolCalls, types.ToolCall{
+					Name:      tc.Name,
+					Input:     string(tc.Args),
+					Output:    toolOutput(tc),
+					Timestamp: callTS,
+					RequestID: msg.ID,
+				})
+			}
+		}
+	}
+
+	if session.StartedAt.IsZero() && len(rec.Messages) > 0 {
+		session.StartedAt = parseTimestamp(rec.Messages[0].Timestamp)
+	}
+	return session
+}
+
+// parseHistory maps a saved checkpoint. These carry no timestamps or usage.
+func parseHistory(history []Content) *types.SessionData {
+	session := &types.SessionData{
+		AgentName: agentName,
+	}
+
+	// Tool calls by name awaiting their response
+	pending := make(map[string][]int)
+
+	for _, c := range history {
+		var texts []string
+		for _, p := range c.Parts {
+			switch {
+			case p.Text != "":
+				texts = append(texts, p.Text)
+			case p.FunctionCall != nil:
+				pending[p.FunctionCall.Name] = append(pending[p.FunctionCall.Name], len(session.ToolCalls))
+				session.ToolCalls = append(session.ToolCalls, types.ToolCall{
+					Name:  p.FunctionCall.Name,
+					Input: string(p.FunctionCall.Args),
+				})
+			case p.FunctionResponse != nil:
+				name := p.FunctionResponse.Name
+				if idx := pending[name]; len(idx) > 0 {
+					session.ToolCalls[idx[0]].Output = responseOutput(p.FunctionResponse.Response)
+					pending[name] = idx[1:]
+				}
+			}
+		}
+
+		text := strings.Join(texts, "\n")
+		if text == "" {
+			continue
+		}
+		switch c.Role {
+		case "user":
+			session.Prompts = append(session.Prompts, types.Prompt{Content: text})
+		case "model":
+			session.Responses = append(session.Responses, types.Response{Content: text})
+		}
+	}
+	return session
+}
+
+// convertTokens maps Gemini usage onto TokenUsage, where InputTokens
+// excludes cache reads and thinking tokens count as output.
+func convertTokens(t *TokensSummary) types.TokenUsage {
+	return types.TokenUsage{
+		InputTokens:  t.Input - t.Cached,
+		OutputTokens: t.Output + t.Thoughts,
+		CacheReads:   t.Cached,
+		APICalls:     1,
+	}
+}
+
+// contentText returns the text of a message whose content is either a
+// string or a list of parts.
+func contentText(raw json.RawMessage) string {
+	if raw == nil {
+		return ""
+	}
+
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+
+	var parts []Part
+	if json.Unmarshal(raw, &parts) == nil {
+		var texts []string
+		for _, p := range parts {
+			if p.Text != "" {
+				texts = append(texts, p.Text)
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
+
+func toolOutput(tc ToolCallEntry) string {
+	for _, p := range tc.Result {
+		if p.FunctionResponse != nil {
+			if out := responseOutput(p.FunctionResponse.Response); out != "" {
+				return out
+			}
+		}
+	}
+
+	var display string
+	if json.Unmarshal(tc.ResultDisplay, &display) == nil {
+		return display
+	}
+	return string(tc.ResultDisplay)
+}
+
+// responseOutput unwraps {"output": ...} or {"error": ...} tool responses.
+func responseOutput(raw json.RawMessage) string {
+	if raw == nil {
+		return ""
+	}
+	var resp struct {
+		Output *string `json:"output"`
+		Error  *string `json:"error"`
+	}
+	if json.Unmarshal(raw, &resp) == nil {
+		if resp.Output != nil {
+			return *resp.Output
+		}
+		if resp.Error != nil {
+			return *resp.Error
+		}
+	}
+	return string(raw)
+}
+
+func parseTimestamp(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,60 @@
+package gemini
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConversation(t *testing.T) {
+	session, err := ParseFile(filepath.Join("testdata", "session-2025-09-20T10-00-4f1c2d3e.json"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "gemini-cli", session.AgentName)
+	assert.Equal(t, "4f1c2d3e-5a6b-4c7d-8e9f-0a1b2c3d4e5f", session.ID)
+	assert.Equal(t, 2025, session.StartedAt.Year())
+	require.NotNil(t, session.EndedAt)
+
+	// Info notices are neither prompts nor responses
+	require.Len(t, session.Prompts, 2)
+	assert.Equal(t, "Create a greeting helper", session.Prompts[0].Content)
+	assert.Equal(t, "Now run the tests", session.Prompts[1].Content)
+	require.Len(t, session.Responses, 2)
+
+	assert.Equal(t, 1000, session.Responses[0].TokenUsage.InputTokens)
+	assert.Equal(t, 100, session.Responses[0].TokenUsage.OutputTokens)
+	assert.Equal(t, 500, session.Responses[0].TokenUsage.CacheReads)
+
+	assert.Equal(t, 1500, session.TokenUsage.InputTokens)
+	assert.Equal(t, 130, session.TokenUsage.OutputTokens)
+	assert.Equal(t, 2000, session.TokenUsage.CacheReads)
+	assert.Equal(t, 2, session.TokenUsage.APICalls)
+
+	require.Len(t, session.ToolCalls, 2)
+	assert.Equal(t, "write_file", session.ToolCalls[0].Name)
+	assert.Contains(t, session.ToolCalls[0].Input, "greet.go")
+	assert.Equal(t, "Successfully created greet.go", session.ToolCalls[0].Output)
+	assert.Equal(t, "run_shell_command", session.ToolCalls[1].Name)
+	assert.Equal(t, "ok  example 0.01s", session.ToolCalls[1].Output)
+}
+
+func TestParseCheckpoint(t *testing.T) {
+	session, err := ParseFile(filepath.Join("testdata", "checkpoint-refactor.json"))
+	require.NoError(t, err)
+
+	// The tool response turn is not a prompt
+	require.Len(t, session.Prompts, 1)
+	assert.Equal(t, "Refactor the parser", session.Prompts[0].Content)
+	require.Len(t, session.Responses, 2)
+
+	require.Len(t, session.ToolCalls, 1)
+	assert.Equal(t, "read_file", session.ToolCalls[0].Name)
+	assert.Equal(t, "package parser", session.ToolCalls[0].Output)
+}
+
+func TestParseFileInvalid(t *testing.T) {
+	_, err := ParseFile(filepath.Join("testdata", "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package gemini
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProjectHash returns the hash Gemini CLI uses to name a project's temp directory.
+func ProjectHash(repoDir string) string {
+	sum := sha256.Sum256([]byte(repoDir))
+	return hex.EncodeToString(sum[:])
+}
+
+// ProjectDir returns the Gemini CLI temp directory for a repo.
+// e.g., ~/.gemini/tmp/<sha256 of repo path>
+func ProjectDir(repoDir string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".gemini", "tmp", ProjectHash(repoDir))
+}
+
+// SessionFiles returns the recorded chats and saved checkpoints for a project.
+// Chats live under chats/session-*.json; /chat save writes checkpoint-<tag>.json.
+func SessionFiles(repoDir string) ([]string, error) {
+	dir := ProjectDir(repoDir)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, pattern := range []string{
+		filepath.Join(dir, "chats", "session-*.json"),
+		filepath.Join(dir, "checkpoint-*.json"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// SessionFile resolves a session ID to its file.
+func SessionFile(repoDir, sessionID string) string {
+	dir := ProjectDir(repoDir)
+	if strings.HasPrefix(sessionID, "checkpoint-") {
+		return filepath.Join(dir, sessionID+".json")
+	}
+	return filepath.Join(dir, "chats", sessionID+".json")
+}
+
+// SessionID returns the session ID for a session file.
+func SessionID(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".json")
+}
@@ -0,0 +1,52 @@
+package gemini
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yibudak/open-entire/internal/agent"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// GeminiAgent implements the Agent interface for Gemini CLI.
+type GeminiAgent struct{}
+
+func init() {
+	agent.Register(&GeminiAgent{})
+}
+
+func (a *GeminiAgent) Name() string {
+	return agentName
+}
+
+func (a *GeminiAgent) Detect(repoDir string) (string, error) {
+	return Detect(repoDir)
+}
+
+func (a *GeminiAgent) SessionsSince(repoDir string, since time.Time) ([]types.SessionActivity, error) {
+	return SessionsSince(repoDir, since)
+}
+
+func (a *GeminiAgent) ParseSession(sessionID string, repoDir string) (*types.SessionData, error) {
+	path := SessionFile(repoDir, sessionID)
+
+	session, err := ParseFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Gemini CLI session %s: %w", sessionID, err)
+	}
+
+	session.ID = sessionID
+	session.TranscriptPath = path
+	return session, nil
+}
+
+func (a *GeminiAgent) ParseTranscript(path string) (*types.SessionData, error) {
+	return ParseFile(path)
+}
+
+func (a *GeminiAgent) SessionPaths(repoDir string) types.AgentPaths {
+	return types.AgentPaths{
+		SessionDir: ProjectDir(repoDir),
+		Pattern:    "chats/session-*.json",
+	}
+}
@@ -1,9 +1,13 @@
 package attribution
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
 )
 
 func TestCalculate(t *testing.T) {
@@ -30,3 +34,118 @@ func TestCalculateZero(t *testing.T) {
 	assert.Equal(t, 0.0, attr.AgentPercent)
 	assert.Equal(t, 0, attr.TotalLines)
 }
+
+func TestAgentLines(t *testing.T) {
+	calls := []types.ToolCall{
+		{Name: "Write", Input: `{"file_path":"/repo/main.go","content":"package main\n\nfunc main() {}\n"}`},
+		{Name: "Edit", Input: `{"file_path":"/repo/main.go","old_string":"func main() {}","new_string":"func main() {\n\trun()\n}"}`},
+		{Name: "MultiEdit", Input: `{"file_path":"util.go","edits":[{"old_string":"a","new_string":"b := 1"},{"old_string":"c","new_string":"d := 2"}]}`},
+		{Name: "Read", Input: `{"file_path":"/repo/other.go"}`},
+		{Name: "Write", Input: `{"file_path":"/elsewhere/x.go","content":"outside"}`},
+	}
+
+	lines := AgentLines("/repo", calls)
+	assert.Len(t, lines, 2)
+	assert.Equal(t, 1, lines["main.go"]["package main"])
+	assert.Equal(t, 1, lines["main.go"]["\trun()"])
+	assert.Equal(t, 1, lines["util.go"]["b := 1"])
+	assert.Equal(t, 1, lines["util.go"]["d := 2"])
+}
+
+func TestAgentLinesOtherAgents(t *testing.T) {
+	patch := "*** Begin Patch\n*** Add File: greet.go\n+package main\n+\n+func greet() {}\n" +
+		"*** Update File: /repo/main.go\n@@\n-\told()\n+\tgreet()\n" +
+		"*** Update File: old.go\n*** Move to: new.go\n@@\n+moved := true\n" +
+		"*** Delete File: gone.go\n*** End Patch\n"
+	shellArgs, err := json.Marshal(map[string]any{"command": []string{"apply_patch", patch}})
+	require.NoError(t, err)
+	heredoc, err := json.Marshal(map[string]any{"command": []string{"bash", "-lc", "apply_patch <<'EOF'\n" +
+		"*** Begin Patch\n*** Add File: sh.go\n+package sh\n*** End Patch\nEOF"}})
+	require.NoError(t, err)
+
+	calls := []types.ToolCall{
+		// Codex
+		{Name: "apply_patch", Input: patch},
+		{Name: "shell", Input: string(shellArgs)},
+		{Name: "shell", Input: string(heredoc)},
+		{Name: "shell", Input: `{"command":["bash","-lc","ls"]}`},
+		// Gemini CLI
+		{Name: "write_file", Input: `{"file_path":"/repo/gem.go","content":"package gem\n"}`},
+		{Name: "replace", Input: `{"file_path":"/repo/gem.go","old_string":"package gem","new_string":"package gemini"}`},
+	}
+
+	lines := AgentLines("/repo", calls)
+	assert.Equal(t, LineSet{"package main": 2, "": 2, "func greet() {}": 2}, lines["greet.go"])
+	assert.Equal(t, LineSet{"\tgreet()": 2}, lines["main.go"])
+	assert.Equal(t, LineSet{"moved := true": 2}, lines["new.go"])
+	assert.Equal(t, LineSet{"package sh": 1}, lines["sh.go"])
+	assert.Equal(t, LineSet{"package gem": 1, "package gemini": 1}, lines["gem.go"])
+	assert.NotContains(t, lines, "old.go")
+	assert.NotContains(t, lines, "gone.go")
+}
+
+// numbered gives lines the numbers of a single run starting at 1.
+func numbered(lines ...string) []git.AddedLine {
+	added := make([]git.AddedLine, len(lines))
+	for i, text := range lines {
+		added[i] = git.AddedLine{Number: i + 1, Text: text}
+	}
+	return added
+}
+
+func TestAttribute(t *testing.T) {
+	added := map[string][]git.AddedLine{
+		"main.go":   numbered("package main", "", "func main() {", "\trun()  ", "\tlog()", "}"),
+		"README.md": numbered("# Title"),
+	}
+	agent := map[string]LineSet{
+		"main.go": {"package main": 1, "": 1, "func main() {": 1, "\trun()": 1, "}": 1},
+	}
+
+	attr := Attribute(added, agent)
+	assert.Equal(t, 5, attr.AgentLines)
+	assert.Equal(t, 7, attr.TotalLines)
+	assert.Equal(t, []types.FileAttribution{
+		{Path: "README.md", AgentLines: 0, HumanLines: 1},
+		{Path: "main.go", AgentLines: 5, HumanLines: 1},
+	}, attr.Files)
+}
+
+func TestAttributeCountsHumanDuplicates(t *testing.T) {
+	added := map[string][]git.AddedLine{"a.go": numbered("x++", "x++")}
+	agent := map[string]LineSet{"a.go": {"x++": 1}}
+
+	attr := Attribute(added, agent)
+	assert.Equal(t, 1, attr.AgentLines)
+	assert.Equal(t, 2, attr.TotalLines)
+}
+
+func TestAttributeHumanBlankLines(t *testing.T) {
+	// The agent wrote lines 1-3; the human added the rest further down
+	added := map[string][]git.AddedLine{"a.go": {
+		{Number: 1, Text: "func a() {}"},
+		{Number: 2, Text: ""},
+		{Number: 3, Text: "func b() {}"},
+		{Number: 10, Text: ""},
+		{Number: 11, Text: "// human"},
+		{Number: 12, Text: "  "},
+		{Number: 20, Text: ""},
+	}}
+	agent := map[string]LineSet{"a.go": {"func a() {}": 1, "": 3, "func b() {}": 1}}
+
+	attr := Attribute(added, agent)
+	assert.Equal(t, 3, attr.AgentLines)
+	assert.Equal(t, 7, attr.TotalLines)
+}
+
+func TestSessionToolCallsIncludesNested(t *testing.T) {
+	session := &types.SessionData{
+		ToolCalls: []types.ToolCall{{Name: "Write"}},
+		NestedSessions: []types.SessionData{
+			{ToolCalls: []types.ToolCall{{Name: "Edit"}}},
+		},
+	}
+	calls := SessionToolCalls(session)
+	assert.Len(t, calls, 2)
+	assert.Equal(t, "Edit", calls[1].Name)
+}
@@ -0,0 +1,194 @@
+package attribution
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// toolInput covers the inputs of the file-editing tools we replay.
+type toolInput struct {
+	FilePath  string     `json:"file_path"`
+	Path      string     `json:"path"`
+	Content   string     `json:"content"`
+	OldString string     `json:"old_string"`
+	NewString string     `json:"new_string"`
+	Edits     []editPair `json:"edits"`
+}
+
+type editPair struct {
+	OldString string `json:"old_string"`
+	NewString string `json:"new_string"`
+}
+
+// LineSet is a multiset of normalized lines written by the agent.
+type LineSet map[string]int
+
+// AgentLines replays the file-editing tool calls of every agent and returns
+// the lines the agent wrote, keyed by repo-relative file path.
+func AgentLines(repoDir string, calls []types.ToolCall) map[string]LineSet {
+	written := make(map[string]LineSet)
+
+	for _, tc := range calls {
+		for path, texts := range toolWrites(tc) {
+			path = relPath(repoDir, path)
+			if path == "" {
+				continue
+			}
+
+			set := written[path]
+			if set == nil {
+				set = make(LineSet)
+				written[path] = set
+			}
+			for _, text := range texts {
+				for _, line := range splitLines(text) {
+					set[normalize(line)]++
+				}
+			}
+		}
+	}
+
+	return written
+}
+
+// toolWrites returns the text a tool call wrote, keyed by the path as the
+// agent gave it. It knows Claude Code's Write, Edit and MultiEdit, Gemini
+// CLI's write_file and replace, and Codex patches, whether sent through
+// apply_patch or a shell command.
+func toolWrites(tc types.ToolCall) map[string][]string {
+	var in toolInput
+	switch tc.Name {
+	case "Write", "Edit", "MultiEdit", "write_file", "replace":
+		if json.Unmarshal([]byte(tc.Input), &in) != nil {
+			return nil
+		}
+	default:
+		if patch := findPatch(tc.Input); patch != "" {
+			return patchAdditions(patch)
+		}
+		return nil
+	}
+
+	path := in.FilePath
+	if path == "" {
+		path = in.Path
+	}
+	var texts []string
+	switch tc.Name {
+	case "Write", "write_file":
+		texts = append(texts, in.Content)
+	case "Edit", "replace":
+		texts = append(texts, in.NewString)
+	case "MultiEdit":
+		for _, e := range in.Edits {
+			texts = append(texts, e.NewString)
+		}
+	}
+	return map[string][]string{path: texts}
+}
+
+const (
+	patchBegin = "*** Begin Patch"
+	patchEnd   = "*** End Patch"
+)
+
+// findPatch returns the apply_patch envelope in a tool input. apply_patch
+// takes it as is; shell calls carry it inside their JSON arguments, as a
+// command argument or a heredoc.
+func findPatch(input string) string {
+	if strings.Contains(input, patchBegin) && !strings.HasPrefix(strings.TrimSpace(input), "{") {
+		return cutPatch(input)
+	}
+	var args any
+	if json.Unmarshal([]byte(input), &args) != nil {
+		return ""
+	}
+	var found string
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case string:
+			if found == "" && strings.Contains(v, patchBegin) {
+				found = cutPatch(v)
+			}
+		case []any:
+			for _, e := range v {
+				walk(e)
+			}
+		case map[string]any:
+			for _, e := range v {
+				walk(e)
+			}
+		}
+	}
+	walk(args)
+	return found
+}
+
+func cutPatch(text string) string {
+	_, patch, _ := strings.Cut(text, patchBegin)
+	patch, _, _ = strings.Cut(patch, patchEnd)
+	return patch
+}
+
+// patchAdditions returns the lines a patch adds, by the path they end up
+// in. Added files, updated files and the target of a move count.
+func patchAdditions(patch string) map[string][]string {
+	added := make(map[string][]string)
+	var path string
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "*** Add File: "):
+			path = strings.TrimSpace(strings.TrimPrefix(line, "*** Add File: "))
+		case strings.HasPrefix(line, "*** Update File: "):
+			path = strings.TrimSpace(strings.TrimPrefix(line, "*** Update File: "))
+		case strings.HasPrefix(line, "*** Move to: "):
+			path = strings.TrimSpace(strings.TrimPrefix(line, "*** Move to: "))
+		case strings.HasPrefix(line, "*** Delete File: "):
+			path = ""
+		case strings.HasPrefix(line, "+") && path != "":
+			added[path] = append(added[path], line[1:])
+		}
+	}
+	for path, lines := range added {
+		added[path] = []string{strings.Join(lines, "\n")}
+	}
+	return added
+}
+
+// SessionToolCalls returns the tool calls of a session and all its nested sessions.
+func SessionToolCalls(session *types.SessionData) []types.ToolCall {
+	calls := append([]types.ToolCall(nil), session.ToolCalls...)
+	for i := range session.NestedSessions {
+		calls = append(calls, SessionToolCalls(&session.NestedSessions[i])...)
+	}
+	return calls
+}
+
+func relPath(repoDir, path string) string {
+	if path == "" {
+		return ""
+	}
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(repoDir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return ""
+		}
+		path = rel
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+func normalize(line string) string {
+	return strings.TrimRight(line, " \t\r")
+}
@@ -0,0 +1,129 @@
+package attribution
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// SessionEdits are the prompts and tool calls of one session of a
+// checkpoint.
+type SessionEdits struct {
+	// Session is the session's index in the checkpoint.
+	Session int
+	Prompts []types.Prompt
+	Calls   []types.ToolCall
+}
+
+// Written maps paths and normalized lines to the sessions and prompts that
+// wrote them, one entry per copy written. Only Session and Prompt of each
+// entry are set.
+type Written map[string]map[string][]types.AgentLine
+
+// SessionWrites replays the editing tool calls of sessions, noting which
+// prompt each call answered.
+func SessionWrites(repoDir string, sessions []SessionEdits) Written {
+	written := make(Written)
+	for _, s := range sessions {
+		for _, tc := range s.Calls {
+			source := types.AgentLine{Session: s.Session, Prompt: promptFor(s.Prompts, tc.Timestamp)}
+			for path, set := range AgentLines(repoDir, []types.ToolCall{tc}) {
+				if written[path] == nil {
+					written[path] = make(map[string][]types.AgentLine)
+				}
+				for line, n := range set {
+					for i := 0; i < n; i++ {
+						written[path][line] = append(written[path][line], source)
+					}
+				}
+			}
+		}
+	}
+	return written
+}
+
+// promptFor returns the index of the prompt a tool call at t answered: the
+// last one before it. Prompts are counted as they are stored, without empty
+// ones; a call without a timestamp is put down to the last prompt.
+func promptFor(prompts []types.Prompt, t time.Time) int {
+	index, found := 0, -1
+	for _, p := range prompts {
+		if strings.TrimSpace(p.Content) == "" {
+			continue
+		}
+		if !t.IsZero() && p.Timestamp.After(t) {
+			break
+		}
+		found = index
+		index++
+	}
+	if found < 0 {
+		return 0
+	}
+	return found
+}
+
+// MapLines matches the lines a commit added against the lines sessions
+// wrote, the way Attribute does, and records where each agent line came
+// from. Each written copy is claimed by the first added line that matches,
+// blank lines after the rest.
+func MapLines(added map[string][]git.AddedLine, written Written) *types.LineAttribution {
+	lines := &types.LineAttribution{Files: make(map[string][]types.AgentLine)}
+	for path, fileLines := range added {
+		remaining := make(map[string][]types.AgentLine, len(written[path]))
+		for line, sources := range written[path] {
+			remaining[line] = sources
+		}
+		claimed := make([]types.AgentLine, len(fileLines))
+		matched := matchLines(fileLines, func(i int, key string) bool {
+			sources := remaining[key]
+			if len(sources) == 0 {
+				return false
+			}
+			claimed[i] = sources[0]
+			remaining[key] = sources[1:]
+			return true
+		})
+		for i, l := range fileLines {
+			if matched[i] {
+				source := claimed[i]
+				source.Line = l.Number
+				lines.Files[path] = append(lines.Files[path], source)
+			}
+		}
+	}
+	return lines
+}
+
+// LinesForCommit maps the lines a commit added to the sessions and prompts
+// whose edits wrote them.
+func (t *Tracker) LinesForCommit(commitHash string, sessions []SessionEdits) *types.LineAttribution {
+	added, err := t.addedLines(commitHash)
+	if err != nil {
+		slog.Debug("could not compute diff", "commit", commitHash, "error", err)
+		return nil
+	}
+	return MapLines(added, SessionWrites(t.repo.Dir, sessions))
+}
+
+// LinesForAgentCommit puts every line a commit added down to the latest
+// prompt of the session whose agent made the commit.
+func (t *Tracker) LinesForAgentCommit(commitHash string, s SessionEdits) *types.LineAttribution {
+	added, err := t.addedLines(commitHash)
+	if err != nil {
+		slog.Debug("could not compute diff", "commit", commitHash, "error", err)
+		return nil
+	}
+	source := types.AgentLine{Session: s.Session, Prompt: promptFor(s.Prompts, time.Time{})}
+	lines := &types.LineAttribution{Files: make(map[string][]types.AgentLine)}
+	for path, fileLines := range added {
+		for _, l := range fileLines {
+			source.Line = l.Number
+			lines.Files[path] = append(lines.Files[path], source)
+		}
+	}
+	return lines
+}
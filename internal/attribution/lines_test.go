@@ -0,0 +1,63 @@
+package attribution
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func TestPromptFor(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	prompts := []types.Prompt{
+		{Content: "first", Timestamp: t0},
+		{Content: "  ", Timestamp: t0.Add(time.Minute)},
+		{Content: "second", Timestamp: t0.Add(2 * time.Minute)},
+	}
+
+	assert.Equal(t, 0, promptFor(prompts, t0.Add(90*time.Second)))
+	assert.Equal(t, 1, promptFor(prompts, t0.Add(3*time.Minute)))
+	assert.Equal(t, 0, promptFor(prompts, t0.Add(-time.Minute)))
+	assert.Equal(t, 1, promptFor(prompts, time.Time{}))
+	assert.Equal(t, 0, promptFor(nil, t0))
+}
+
+func TestMapLines(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	sessions := []SessionEdits{{
+		Session: 1,
+		Prompts: []types.Prompt{
+			{Content: "write main", Timestamp: t0},
+			{Content: "add run", Timestamp: t0.Add(time.Minute)},
+		},
+		Calls: []types.ToolCall{
+			{Name: "Write", Timestamp: t0.Add(time.Second),
+				Input: `{"file_path":"/repo/main.go","content":"package main\n\nfunc main() {\n}\n"}`},
+			{Name: "Edit", Timestamp: t0.Add(2 * time.Minute),
+				Input: `{"file_path":"/repo/main.go","old_string":"}","new_string":"\trun()\n}"}`},
+		},
+	}}
+	added := map[string][]git.AddedLine{
+		"main.go": {
+			{Number: 1, Text: "package main"},
+			{Number: 2, Text: ""},
+			{Number: 3, Text: "func main() {"},
+			{Number: 4, Text: "\trun()"},
+			{Number: 5, Text: "\tlog()"},
+			{Number: 6, Text: "}"},
+		},
+		"go.mod": {{Number: 1, Text: "module x"}},
+	}
+
+	lines := MapLines(added, SessionWrites("/repo", sessions))
+	assert.Equal(t, []types.AgentLine{
+		{Line: 1, Session: 1, Prompt: 0},
+		{Line: 2, Session: 1, Prompt: 0},
+		{Line: 3, Session: 1, Prompt: 0},
+		{Line: 4, Session: 1, Prompt: 1},
+		{Line: 6, Session: 1, Prompt: 0},
+	}, lines.Files["main.go"])
+	assert.NotContains(t, lines.Files, "go.mod")
+}
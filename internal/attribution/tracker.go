@@ -2,7 +2,7 @@ package attribution
 
 import (
 	"log/slog"
-	"strings"
+	"sort"
 
 	"github.com/yibudak/open-entire/internal/git"
 	"github.com/yibudak/open-entire/pkg/types"
@@ -10,66 +10,158 @@ import (
 
 // Tracker tracks line attribution for a repository.
 type Tracker struct {
-	repo *git.Repository
+	repo  *git.Repository
+	diffs map[string]map[string][]git.AddedLine
 }
 
 // NewTracker creates an attribution tracker.
 func NewTracker(repo *git.Repository) *Tracker {
-	return &Tracker{repo: repo}
+	return &Tracker{
+		repo:  repo,
+		diffs: make(map[string]map[string][]git.AddedLine),
+	}
 }
 
 // ForCommit calculates attribution for a specific commit.
-func (t *Tracker) ForCommit(commitHash string, agentFiles []string) types.Attribution {
-	added, _, err := t.repo.DiffLinesChanged(commitHash)
+// An added line counts as agent-authored only if the agent wrote that exact
+// line to that file through one of its editing tool calls.
+func (t *Tracker) ForCommit(commitHash string, toolCalls []types.ToolCall) types.Attribution {
+	added, err := t.addedLines(commitHash)
 	if err != nil {
-		slog.Debug("could not compute diff stats", "commit", commitHash, "error", err)
+		slog.Debug("could not compute diff", "commit", commitHash, "error", err)
 		return types.Attribution{}
 	}
+	return Attribute(added, AgentLines(t.repo.Dir, toolCalls))
+}
 
-	// Heuristic: files touched by the agent are agent-authored lines
-	agentFileSet := make(map[string]bool)
-	for _, f := range agentFiles {
-		agentFileSet[f] = true
+// ForAgentCommit attributes every added line of a commit to the agent, for
+// commits the agent made itself.
+func (t *Tracker) ForAgentCommit(commitHash string) types.Attribution {
+	added, err := t.addedLines(commitHash)
+	if err != nil {
+		slog.Debug("could not compute diff", "commit", commitHash, "error", err)
+		return types.Attribution{}
 	}
 
-	changedFiles, err := t.repo.DiffFiles(commitHash)
-	if err != nil {
-		return Calculate(added, 0)
+	paths := make([]string, 0, len(added))
+	for path := range added {
+		paths = append(paths, path)
 	}
+	sort.Strings(paths)
 
-	agentLines := 0
-	humanLines := 0
-	for _, f := range changedFiles {
-		if agentFileSet[f] {
-			// Count lines from this file's diff as agent-authored
-			agentLines += countFileLines(t.repo, commitHash, f)
-		} else {
-			humanLines += countFileLines(t.repo, commitHash, f)
+	var files []types.FileAttribution
+	total := 0
+	for _, path := range paths {
+		if n := len(added[path]); n > 0 {
+			files = append(files, types.FileAttribution{Path: path, AgentLines: n})
+			total += n
 		}
 	}
+	attr := Calculate(total, 0)
+	attr.Files = files
+	return attr
+}
 
-	if agentLines+humanLines == 0 {
-		return Calculate(added, 0)
+// addedLines returns the commit's added lines, diffing each commit only once.
+func (t *Tracker) addedLines(commitHash string) (map[string][]git.AddedLine, error) {
+	if added, ok := t.diffs[commitHash]; ok {
+		return added, nil
 	}
+	added, err := t.repo.AddedLines(commitHash)
+	if err != nil {
+		return nil, err
+	}
+	t.diffs[commitHash] = added
+	return added, nil
+}
+
+// Attribute matches added lines against the lines the agent wrote.
+// Each agent-written line can be claimed by at most one added line, so a
+// human duplicating agent code still counts as human.
+func Attribute(added map[string][]git.AddedLine, agent map[string]LineSet) types.Attribution {
+	paths := make([]string, 0, len(added))
+	for path := range added {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var files []types.FileAttribution
+	agentTotal, humanTotal := 0, 0
 
-	return Calculate(agentLines, humanLines)
+	for _, path := range paths {
+		remaining := make(LineSet, len(agent[path]))
+		for line, n := range agent[path] {
+			remaining[line] = n
+		}
+
+		fa := types.FileAttribution{Path: path}
+		matched := matchLines(added[path], func(i int, key string) bool {
+			if remaining[key] == 0 {
+				return false
+			}
+			remaining[key]--
+			return true
+		})
+		for _, ok := range matched {
+			if ok {
+				fa.AgentLines++
+			} else {
+				fa.HumanLines++
+			}
+		}
+		if fa.AgentLines+fa.HumanLines == 0 {
+			continue
+		}
+
+		agentTotal += fa.AgentLines
+		humanTotal += fa.HumanLines
+		files = append(files, fa)
+	}
+
+	attr := Calculate(agentTotal, humanTotal)
+	attr.Files = files
+	return attr
 }
 
-func countFileLines(repo *git.Repository, commitHash, file string) int {
-	diff, err := repo.DiffContent(commitHash)
-	if err != nil {
-		return 0
+// matchLines reports which of a file's added lines the agent wrote. claim
+// takes one written copy of the normalized line at index i, if any is left.
+// Blank lines are matched last and only beside a line that matched in the
+// same run of added lines: every blank line looks alike, so the agent's
+// would otherwise claim blank lines a human added anywhere in the file.
+func matchLines(lines []git.AddedLine, claim func(i int, key string) bool) []bool {
+	matched := make([]bool, len(lines))
+	var blanks []int
+	for i, l := range lines {
+		key := normalize(l.Text)
+		if key == "" {
+			blanks = append(blanks, i)
+			continue
+		}
+		matched[i] = claim(i, key)
+	}
+	for _, i := range blanks {
+		if besideMatch(lines, matched, i) {
+			matched[i] = claim(i, "")
+		}
 	}
+	return matched
+}
 
-	count := 0
-	inFile := false
-	for _, line := range strings.Split(diff, "\n") {
-		if strings.HasPrefix(line, "diff --git") {
-			inFile = strings.Contains(line, file)
+// besideMatch reports whether the nearest non-blank line before or after
+// lines[i], without leaving its run of consecutive added lines, matched.
+func besideMatch(lines []git.AddedLine, matched []bool, i int) bool {
+	for j := i - 1; j >= 0 && lines[j].Number == lines[j+1].Number-1; j-- {
+		if normalize(lines[j].Text) != "" {
+			if matched[j] {
+				return true
+			}
+			break
 		}
-		if inFile && strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
-			count++
+	}
+	for j := i + 1; j < len(lines) && lines[j].Number == lines[j-1].Number+1; j++ {
+		if normalize(lines[j].Text) != "" {
+			return matched[j]
 		}
 	}
-	return count
+	return false
 }
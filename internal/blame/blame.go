@@ -0,0 +1,152 @@
+// Package blame overlays agent attribution on git blame.
+package blame
+
+import (
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// Origin is who wrote a line.
+type Origin string
+
+const (
+	Agent Origin = "agent"
+	Human Origin = "human"
+	// Unknown lines come from a checkpoint made before lines were recorded.
+	Unknown Origin = "unknown"
+	// Uncommitted lines are only in the working tree.
+	Uncommitted Origin = "uncommitted"
+)
+
+// Line is a line of a file with who wrote it.
+type Line struct {
+	Number int    `json:"line"`
+	Text   string `json:"text"`
+	Commit string `json:"commit,omitempty"`
+	Author string `json:"author,omitempty"`
+	Origin Origin `json:"origin"`
+	// Checkpoint is the checkpoint of the line's commit, if it has one.
+	Checkpoint string `json:"checkpoint,omitempty"`
+	// Session, AgentName and Prompt tell which session wrote an agent line
+	// and what it was asked.
+	Session   int    `json:"session,omitempty"`
+	AgentName string `json:"agent_name,omitempty"`
+	Prompt    string `json:"prompt,omitempty"`
+}
+
+// blamer looks up each commit and checkpoint once per file.
+type blamer struct {
+	repo        *git.Repository
+	store       *checkpoint.Store
+	checkpoints map[string]string
+	lines       map[string]map[string]map[int]types.AgentLine
+	metas       map[string]*types.CheckpointMetadata
+	prompts     map[string][]string
+}
+
+// File blames a file at a revision, or in the working tree when rev is
+// empty, and joins each line's commit to its checkpoint. The path is
+// relative to the repository root.
+func File(repo *git.Repository, store *checkpoint.Store, path, rev string) ([]Line, error) {
+	blamed, err := repo.Blame(path, rev)
+	if err != nil {
+		return nil, err
+	}
+	b := &blamer{
+		repo:        repo,
+		store:       store,
+		checkpoints: make(map[string]string),
+		lines:       make(map[string]map[string]map[int]types.AgentLine),
+		metas:       make(map[string]*types.CheckpointMetadata),
+		prompts:     make(map[string][]string),
+	}
+	lines := make([]Line, len(blamed))
+	for i, bl := range blamed {
+		lines[i] = b.line(bl)
+	}
+	return lines, nil
+}
+
+func (b *blamer) line(bl git.BlameLine) Line {
+	line := Line{Number: bl.Number, Text: bl.Text, Commit: bl.Commit, Author: bl.Author, Origin: Human}
+	if bl.Commit == git.NotCommitted {
+		line.Commit = ""
+		line.Author = ""
+		line.Origin = Uncommitted
+		return line
+	}
+
+	line.Checkpoint = b.checkpoint(bl.Commit)
+	if line.Checkpoint == "" {
+		return line
+	}
+	files := b.fileLines(line.Checkpoint)
+	if files == nil {
+		line.Origin = Unknown
+		return line
+	}
+	agentLine, ok := files[bl.OrigPath][bl.OrigLine]
+	if !ok {
+		return line
+	}
+	line.Origin = Agent
+	line.Session = agentLine.Session
+	if meta := b.meta(line.Checkpoint); meta != nil && agentLine.Session < len(meta.Sessions) {
+		line.AgentName = meta.Sessions[agentLine.Session].AgentName
+	}
+	if prompts := b.sessionPrompts(line.Checkpoint, agentLine.Session); agentLine.Prompt < len(prompts) {
+		line.Prompt = prompts[agentLine.Prompt]
+	}
+	return line
+}
+
+// checkpoint returns the checkpoint a commit's trailer names.
+func (b *blamer) checkpoint(commit string) string {
+	id, ok := b.checkpoints[commit]
+	if !ok {
+		id, _ = b.repo.CheckpointFromCommit(commit)
+		b.checkpoints[commit] = id
+	}
+	return id
+}
+
+// fileLines returns a checkpoint's agent lines by path and line number, or
+// nil when it has no line map.
+func (b *blamer) fileLines(id string) map[string]map[int]types.AgentLine {
+	if files, ok := b.lines[id]; ok {
+		return files
+	}
+	var files map[string]map[int]types.AgentLine
+	if la, err := b.store.Lines(id); err == nil {
+		files = make(map[string]map[int]types.AgentLine, len(la.Files))
+		for path, lines := range la.Files {
+			byNumber := make(map[int]types.AgentLine, len(lines))
+			for _, l := range lines {
+				byNumber[l.Line] = l
+			}
+			files[path] = byNumber
+		}
+	}
+	b.lines[id] = files
+	return files
+}
+
+func (b *blamer) meta(id string) *types.CheckpointMetadata {
+	meta, ok := b.metas[id]
+	if !ok {
+		meta, _ = b.store.Get(id)
+		b.metas[id] = meta
+	}
+	return meta
+}
+
+func (b *blamer) sessionPrompts(id string, session int) []string {
+	key := checkpoint.SessionPath(id, session)
+	prompts, ok := b.prompts[key]
+	if !ok {
+		prompts, _ = b.store.Prompts(id, session)
+		b.prompts[key] = prompts
+	}
+	return prompts
+}
@@ -0,0 +1,97 @@
+package blame
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestFile(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "tester")
+	t.Setenv("GIT_AUTHOR_EMAIL", "tester@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "tester")
+	t.Setenv("GIT_COMMITTER_EMAIL", "tester@example.com")
+
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q", "-b", "main")
+	writeFile(t, dir, "main.go", "package main\n")
+	gitCmd(t, dir, "add", "main.go")
+	gitCmd(t, dir, "commit", "-q", "-m", "initial")
+
+	repo, err := git.Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, repo.EnsureCheckpointsBranch())
+	store := checkpoint.NewStore(repo)
+
+	// An agent adds lines 2-4; the human edits line 3 before committing.
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {\n}\n")
+	gitCmd(t, dir, "commit", "-q", "-am", "add main\n\nEntire-Checkpoint: aabbccddeeff")
+	meta := checkpoint.NewMetadata("aabbccddeeff", "", "main", "tester", "add main", "manual-commit")
+	meta.Sessions = []types.SessionSummary{{Index: 0, AgentName: "claude-code", SessionID: "sess-1"}}
+	meta.Lines = &types.LineAttribution{Files: map[string][]types.AgentLine{
+		"main.go": {{Line: 2, Prompt: 0}, {Line: 4, Prompt: 1}},
+	}}
+	require.NoError(t, store.Create(meta, []checkpoint.SessionBundle{{
+		Metadata: &types.SessionMetadata{AgentName: "claude-code", SessionID: "sess-1"},
+		Prompts:  []byte("write main" + checkpoint.PromptSeparator + "close it\n"),
+	}}))
+
+	// A checkpoint from before lines were recorded.
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {\n}\n// old\n")
+	gitCmd(t, dir, "commit", "-q", "-am", "old\n\nEntire-Checkpoint: 112233445566")
+	require.NoError(t, store.Create(checkpoint.NewMetadata("112233445566", "", "main", "tester", "old", "manual-commit"), nil))
+
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {\n}\n// old\n// wip\n")
+
+	lines, err := File(repo, store, "main.go", "")
+	require.NoError(t, err)
+	require.Len(t, lines, 6)
+
+	assert.Equal(t, Human, lines[0].Origin)
+	assert.Empty(t, lines[0].Checkpoint)
+
+	assert.Equal(t, Agent, lines[1].Origin)
+	assert.Equal(t, "aabbccddeeff", lines[1].Checkpoint)
+	assert.Equal(t, "claude-code", lines[1].AgentName)
+	assert.Equal(t, "write main", lines[1].Prompt)
+
+	assert.Equal(t, Human, lines[2].Origin)
+	assert.Equal(t, "aabbccddeeff", lines[2].Checkpoint)
+	assert.Empty(t, lines[2].Prompt)
+
+	assert.Equal(t, Agent, lines[3].Origin)
+	assert.Equal(t, "close it", lines[3].Prompt)
+
+	assert.Equal(t, Unknown, lines[4].Origin)
+	assert.Equal(t, "112233445566", lines[4].Checkpoint)
+
+	assert.Equal(t, Uncommitted, lines[5].Origin)
+	assert.Equal(t, "// wip", lines[5].Text)
+	assert.Empty(t, lines[5].Commit)
+
+	lines, err = File(repo, store, "main.go", "HEAD~1")
+	require.NoError(t, err)
+	assert.Len(t, lines, 4)
+}
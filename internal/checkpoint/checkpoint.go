@@ -31,6 +31,20 @@ func MetadataPath(id string) string {
 	return ShardPath(id) + "metadata.json"
 }
 
+// SummaryPath returns the full path to summary.md for a checkpoint.
+func SummaryPath(id string) string {
+	return ShardPath(id) + "summary.md"
+}
+
+// LinesPath returns the full path to lines.json, the line-level
+// attribution of a checkpoint.
+func LinesPath(id string) string {
+	return ShardPath(id) + "lines.json"
+}
+
+// PromptSeparator separates the prompts in a session's prompt.txt.
+const PromptSeparator = "\n\n---\n\n"
+
 // SessionPath returns the path to a session folder within a checkpoint.
 func SessionPath(id string, index int) string {
 	return fmt.Sprintf("%s%d/", ShardPath(id), index)
@@ -0,0 +1,403 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yibudak/open-entire/internal/pricing"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// toolTargetKeys are the tool input fields that say what a call acted on,
+// in order of preference.
+var toolTargetKeys = []string{"file_path", "path", "notebook_path", "command", "pattern", "url", "query"}
+
+// ResponseLabel introduces each agent response in context.md.
+const ResponseLabel = "**Response**"
+
+// maxTargetLen caps how much of a tool's target is shown on its line.
+const maxTargetLen = 80
+
+// RenderContext renders a session as readable Markdown: each prompt with the
+// responses and tool calls that followed it, per-turn token usage, and
+// subagent sessions as sub-sections.
+func RenderContext(session *types.SessionData) []byte {
+	var b strings.Builder
+	renderSession(&b, session, 1)
+	return []byte(b.String())
+}
+
+// ContextEntry is one prompt, response or tool call read back from a
+// context.md.
+type ContextEntry struct {
+	// Subagent is the ID of the subagent session, or "" for the main session.
+	Subagent string
+	// Turn is the 1-based turn number, or 0 for activity before the first
+	// prompt.
+	Turn int
+	// Kind is "prompt", "response" or "tool".
+	Kind string
+	// Tool is the tool name of a tool entry.
+	Tool string
+	// Text is the prompt or response. For a tool call it is what the call
+	// acted on when read from context.md, and its whole input when taken
+	// from SessionEntries.
+	Text string
+}
+
+// SessionEntries lists a session's prompts, responses and tool calls, with
+// its subagents', turn by turn as RenderContext numbers them. Nothing is
+// shortened.
+func SessionEntries(s *types.SessionData) []ContextEntry {
+	var entries []ContextEntry
+	var walk func(s *types.SessionData, subagent string)
+	walk = func(s *types.SessionData, subagent string) {
+		n := 0
+		for _, t := range groupTurns(s) {
+			e := ContextEntry{Subagent: subagent}
+			if t.prompt != nil {
+				n++
+				e.Turn = n
+				if text := strings.TrimSpace(t.prompt.Content); text != "" {
+					p := e
+					p.Kind, p.Text = "prompt", text
+					entries = append(entries, p)
+				}
+			}
+			for _, r := range t.responses {
+				if text := strings.TrimSpace(r.Content); text != "" {
+					re := e
+					re.Kind, re.Text = "response", text
+					entries = append(entries, re)
+				}
+			}
+			for _, tc := range t.toolCalls {
+				te := e
+				te.Kind, te.Tool, te.Text = "tool", tc.Name, toolText(tc.Input)
+				entries = append(entries, te)
+			}
+		}
+		for i := range s.NestedSessions {
+			walk(&s.NestedSessions[i], s.NestedSessions[i].ID)
+		}
+	}
+	walk(s, "")
+	return entries
+}
+
+// toolText returns the text in a tool call's input: every string value of
+// a JSON input, nested ones included, or the input itself.
+func toolText(input string) string {
+	var v any
+	if json.Unmarshal([]byte(input), &v) != nil {
+		return strings.TrimSpace(input)
+	}
+	var parts []string
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case string:
+			if s := strings.TrimSpace(v); s != "" {
+				parts = append(parts, s)
+			}
+		case []any:
+			for _, e := range v {
+				walk(e)
+			}
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(v[k])
+			}
+		}
+	}
+	walk(v)
+	return strings.Join(parts, "\n")
+}
+
+var (
+	subagentHeading = regexp.MustCompile(`^#{2,} Subagent ?(.*)$`)
+	turnHeading     = regexp.MustCompile(`^#{2,} Turn (\d+)`)
+	leadHeading     = regexp.MustCompile(`^#{2,} Continued from previous checkpoint$`)
+	toolLine        = regexp.MustCompile("^- (\\S+)(?: `([^`]*)`)?")
+)
+
+// ParseContext reads the entries back out of Markdown written by
+// RenderContext. Headings inside responses are kept as text unless they
+// look like one of RenderContext's own.
+func ParseContext(md []byte) []ContextEntry {
+	var entries []ContextEntry
+	var cur ContextEntry
+	var kind string
+	var buf []string
+
+	flush := func() {
+		if kind == "prompt" || kind == "response" {
+			if text := strings.TrimSpace(strings.Join(buf, "\n")); text != "" {
+				e := cur
+				e.Kind, e.Text = kind, text
+				entries = append(entries, e)
+			}
+		}
+		kind, buf = "", nil
+	}
+
+	for _, line := range strings.Split(string(md), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# Session"):
+			flush()
+			cur = ContextEntry{}
+		case subagentHeading.MatchString(line):
+			flush()
+			cur = ContextEntry{Subagent: subagentHeading.FindStringSubmatch(line)[1]}
+		case turnHeading.MatchString(line):
+			flush()
+			cur.Turn, _ = strconv.Atoi(turnHeading.FindStringSubmatch(line)[1])
+		case leadHeading.MatchString(line):
+			flush()
+			cur.Turn = 0
+		case line == "**Prompt**":
+			flush()
+			kind = "prompt"
+		case line == ResponseLabel:
+			flush()
+			kind = "response"
+		case strings.HasPrefix(line, "**Tool calls ("):
+			flush()
+			kind = "tool"
+		case kind == "tool":
+			if m := toolLine.FindStringSubmatch(line); m != nil {
+				e := cur
+				e.Kind, e.Tool, e.Text = "tool", m[1], m[2]
+				entries = append(entries, e)
+			}
+		case kind == "prompt":
+			buf = append(buf, strings.TrimPrefix(strings.TrimPrefix(line, ">"), " "))
+		case kind == "response":
+			buf = append(buf, line)
+		}
+	}
+	flush()
+	return entries
+}
+
+// turn is a prompt and everything the agent did in reply.
+type turn struct {
+	prompt    *types.Prompt
+	responses []types.Response
+	toolCalls []types.ToolCall
+}
+
+func renderSession(b *strings.Builder, s *types.SessionData, level int) {
+	h := strings.Repeat("#", level)
+
+	title := "Session"
+	if level > 1 {
+		title = "Subagent"
+	}
+	if s.ID != "" {
+		title += " " + s.ID
+	}
+	fmt.Fprintf(b, "%s %s\n\n", h, title)
+
+	if s.AgentName != "" {
+		fmt.Fprintf(b, "- Agent: %s\n", s.AgentName)
+	}
+	if !s.StartedAt.IsZero() {
+		fmt.Fprintf(b, "- Started: %s\n", formatTime(s.StartedAt))
+	}
+	if s.EndedAt != nil && !s.EndedAt.IsZero() {
+		fmt.Fprintf(b, "- Ended: %s\n", formatTime(*s.EndedAt))
+	}
+	fmt.Fprintf(b, "- Prompts: %d, responses: %d, tool calls: %d\n", len(s.Prompts), len(s.Responses), len(s.ToolCalls))
+	if usage := formatUsage(s.TokenUsage); usage != "" {
+		fmt.Fprintf(b, "- Tokens: %s\n", usage)
+	}
+
+	n := 0
+	for _, t := range groupTurns(s) {
+		if t.prompt != nil {
+			n++
+		}
+		b.WriteString("\n")
+		renderTurn(b, t, n, level+1)
+	}
+
+	for i := range s.NestedSessions {
+		b.WriteString("\n")
+		renderSession(b, &s.NestedSessions[i], level+1)
+	}
+}
+
+func renderTurn(b *strings.Builder, t turn, number, level int) {
+	h := strings.Repeat("#", level)
+
+	if t.prompt == nil {
+		fmt.Fprintf(b, "%s Continued from previous checkpoint\n", h)
+	} else {
+		fmt.Fprintf(b, "%s Turn %d", h, number)
+		if !t.prompt.Timestamp.IsZero() {
+			fmt.Fprintf(b, " · %s", formatTime(t.prompt.Timestamp))
+		}
+		b.WriteString("\n")
+	}
+
+	var usage types.TokenUsage
+	for _, r := range t.responses {
+		addUsage(&usage, r.TokenUsage)
+	}
+	if u := formatUsage(usage); u != "" {
+		fmt.Fprintf(b, "\n_Tokens: %s_\n", u)
+	}
+
+	if t.prompt != nil {
+		b.WriteString("\n**Prompt**\n\n")
+		b.WriteString(quote(t.prompt.Content))
+	}
+
+	for _, r := range t.responses {
+		content := strings.TrimSpace(r.Content)
+		if content == "" {
+			continue
+		}
+		b.WriteString("\n" + ResponseLabel + "\n\n")
+		b.WriteString(content)
+		b.WriteString("\n")
+	}
+
+	if len(t.toolCalls) > 0 {
+		fmt.Fprintf(b, "\n**Tool calls (%d)**\n\n", len(t.toolCalls))
+		for _, tc := range t.toolCalls {
+			b.WriteString(formatToolCall(tc))
+			b.WriteString("\n")
+		}
+	}
+}
+
+// groupTurns assigns each response and tool call to the prompt it followed.
+// Timestamps decide when present; otherwise responses pair with prompts in
+// order and untimed tool calls go with the last turn.
+func groupTurns(s *types.SessionData) []turn {
+	turns := make([]turn, len(s.Prompts))
+	starts := make([]time.Time, len(s.Prompts))
+	for i := range s.Prompts {
+		turns[i].prompt = &s.Prompts[i]
+		starts[i] = s.Prompts[i].Timestamp
+	}
+
+	// lead collects activity from before the first prompt, as happens when
+	// a checkpoint starts partway through a turn
+	var lead turn
+	target := func(ts time.Time, fallback int) *turn {
+		if len(turns) == 0 {
+			return &lead
+		}
+		if !ts.IsZero() && !starts[0].IsZero() {
+			i := sort.Search(len(starts), func(i int) bool {
+				return starts[i].After(ts)
+			}) - 1
+			if i < 0 {
+				return &lead
+			}
+			return &turns[i]
+		}
+		return &turns[min(fallback, len(turns)-1)]
+	}
+
+	for i, r := range s.Responses {
+		t := target(r.Timestamp, i)
+		t.responses = append(t.responses, r)
+	}
+	for _, tc := range s.ToolCalls {
+		t := target(tc.Timestamp, len(turns)-1)
+		t.toolCalls = append(t.toolCalls, tc)
+	}
+
+	if len(lead.responses) > 0 || len(lead.toolCalls) > 0 {
+		turns = append([]turn{lead}, turns...)
+	}
+	return turns
+}
+
+// formatToolCall renders a tool call as one collapsed list line.
+func formatToolCall(tc types.ToolCall) string {
+	line := "- " + tc.Name
+	if target := toolTarget(tc.Input); target != "" {
+		line += " `" + target + "`"
+	}
+	if tc.DurationMS > 0 {
+		line += " · " + (time.Duration(tc.DurationMS) * time.Millisecond).String()
+	}
+	if tc.IsError {
+		line += " · error"
+	}
+	return line
+}
+
+// toolTarget returns the file, command or pattern a tool call acted on.
+func toolTarget(input string) string {
+	var fields map[string]any
+	if json.Unmarshal([]byte(input), &fields) != nil {
+		return ""
+	}
+	for _, key := range toolTargetKeys {
+		v, ok := fields[key].(string)
+		if !ok || v == "" {
+			continue
+		}
+		v = strings.Join(strings.Fields(v), " ")
+		v = strings.ReplaceAll(v, "`", "'")
+		if r := []rune(v); len(r) > maxTargetLen {
+			v = string(r[:maxTargetLen-1]) + "…"
+		}
+		return v
+	}
+	return ""
+}
+
+func quote(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight("> "+l, " ")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func formatUsage(u types.TokenUsage) string {
+	if u == (types.TokenUsage{}) {
+		return ""
+	}
+	s := fmt.Sprintf("%d input, %d output", u.InputTokens, u.OutputTokens)
+	if u.CacheCreation > 0 {
+		s += fmt.Sprintf(", %d cache write", u.CacheCreation)
+	}
+	if u.CacheReads > 0 {
+		s += fmt.Sprintf(", %d cache read", u.CacheReads)
+	}
+	if u.CostUSD > 0 {
+		s += ", " + pricing.Format(u.CostUSD)
+	}
+	return s
+}
+
+func addUsage(dst *types.TokenUsage, u types.TokenUsage) {
+	dst.InputTokens += u.InputTokens
+	dst.OutputTokens += u.OutputTokens
+	dst.CacheCreation += u.CacheCreation
+	dst.CacheReads += u.CacheReads
+	dst.APICalls += u.APICalls
+	dst.CostUSD += u.CostUSD
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
@@ -0,0 +1,168 @@
+package checkpoint
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func at(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+func TestRenderContext(t *testing.T) {
+	ended := at("2025-01-15T10:01:10Z")
+	session := &types.SessionData{
+		ID:        "sess-1",
+		AgentName: "claude-code",
+		StartedAt: at("2025-01-15T10:00:00Z"),
+		EndedAt:   &ended,
+		Prompts: []types.Prompt{
+			{Content: "Create a hello world function", Timestamp: at("2025-01-15T10:00:00Z")},
+			{Content: "Add a test\nand run it", Timestamp: at("2025-01-15T10:01:00Z")},
+		},
+		Responses: []types.Response{
+			{Content: "Here is the function.", Timestamp: at("2025-01-15T10:00:05Z"),
+				TokenUsage: types.TokenUsage{InputTokens: 150, OutputTokens: 75, APICalls: 1}},
+			{Content: "Tests pass.", Timestamp: at("2025-01-15T10:01:10Z"),
+				TokenUsage: types.TokenUsage{InputTokens: 200, OutputTokens: 100, CacheReads: 20, APICalls: 1}},
+		},
+		ToolCalls: []types.ToolCall{
+			{Name: "Write", Input: `{"file_path":"main.go","content":"package main"}`, Timestamp: at("2025-01-15T10:00:05Z")},
+			{Name: "Bash", Input: `{"command":"go test ./..."}`, Timestamp: at("2025-01-15T10:01:05Z"), DurationMS: 3500, IsError: true},
+		},
+		TokenUsage: types.TokenUsage{InputTokens: 350, OutputTokens: 175, CacheReads: 20, APICalls: 2},
+		NestedSessions: []types.SessionData{{
+			ID:        "agent-1",
+			Prompts:   []types.Prompt{{Content: "Find the tests", Timestamp: at("2025-01-15T10:01:02Z")}},
+			ToolCalls: []types.ToolCall{{Name: "Glob", Input: `{"pattern":"**/*_test.go"}`, Timestamp: at("2025-01-15T10:01:03Z")}},
+		}},
+	}
+
+	out := string(RenderContext(session))
+
+	assert.True(t, strings.HasPrefix(out, "# Session sess-1\n\n- Agent: claude-code\n"))
+	assert.Contains(t, out, "- Started: 2025-01-15 10:00:00 UTC\n")
+	assert.Contains(t, out, "- Tokens: 350 input, 175 output, 20 cache read\n")
+
+	turn1 := between(t, out, "## Turn 1 · 2025-01-15 10:00:00 UTC\n", "## Turn 2")
+	assert.Contains(t, turn1, "_Tokens: 150 input, 75 output_")
+	assert.Contains(t, turn1, "> Create a hello world function\n")
+	assert.Contains(t, turn1, "Here is the function.")
+	assert.Contains(t, turn1, "- Write `main.go`\n")
+	assert.NotContains(t, turn1, "Bash")
+
+	turn2 := between(t, out, "## Turn 2", "## Subagent")
+	assert.Contains(t, turn2, "> Add a test\n> and run it\n")
+	assert.Contains(t, turn2, "- Bash `go test ./...` · 3.5s · error\n")
+	assert.Contains(t, turn2, "_Tokens: 200 input, 100 output, 20 cache read_")
+
+	sub := out[strings.Index(out, "## Subagent agent-1"):]
+	assert.Contains(t, sub, "### Turn 1")
+	assert.Contains(t, sub, "- Glob `**/*_test.go`")
+}
+
+func TestRenderContextContinuedTurn(t *testing.T) {
+	session := &types.SessionData{
+		Prompts: []types.Prompt{{Content: "Next", Timestamp: at("2025-01-15T10:05:00Z")}},
+		Responses: []types.Response{
+			{Content: "Finishing the earlier task.", Timestamp: at("2025-01-15T10:04:00Z")},
+			{Content: "On it.", Timestamp: at("2025-01-15T10:05:03Z")},
+		},
+	}
+
+	out := string(RenderContext(session))
+	continued := between(t, out, "## Continued from previous checkpoint", "## Turn 1")
+	assert.Contains(t, continued, "Finishing the earlier task.")
+	assert.Contains(t, out[strings.Index(out, "## Turn 1"):], "On it.")
+}
+
+func TestRenderContextWithoutTimestamps(t *testing.T) {
+	session := &types.SessionData{
+		Prompts:   []types.Prompt{{Content: "one"}, {Content: "two"}},
+		Responses: []types.Response{{Content: "first answer"}, {Content: "second answer"}},
+		ToolCalls: []types.ToolCall{{Name: "read_file", Input: `{"path":"a.go"}`}},
+	}
+
+	out := string(RenderContext(session))
+	turn1 := between(t, out, "## Turn 1", "## Turn 2")
+	assert.Contains(t, turn1, "first answer")
+	turn2 := out[strings.Index(out, "## Turn 2"):]
+	assert.Contains(t, turn2, "second answer")
+	assert.Contains(t, turn2, "- read_file `a.go`")
+}
+
+func TestParseContext(t *testing.T) {
+	session := &types.SessionData{
+		ID: "sess-1",
+		Prompts: []types.Prompt{
+			{Content: "Drop Redis\n\nuse the DB instead", Timestamp: at("2025-01-15T10:00:00Z")},
+			{Content: "Now test it", Timestamp: at("2025-01-15T10:01:00Z")},
+		},
+		Responses: []types.Response{
+			{Content: "Earlier work.", Timestamp: at("2025-01-15T09:59:00Z")},
+			{Content: "## Plan\n\nRemoving the cache.", Timestamp: at("2025-01-15T10:00:05Z")},
+			{Content: "Tests pass.", Timestamp: at("2025-01-15T10:01:10Z")},
+		},
+		ToolCalls: []types.ToolCall{
+			{Name: "Edit", Input: `{"file_path":"cache.go"}`, Timestamp: at("2025-01-15T10:00:06Z")},
+			{Name: "Bash", Input: `{"command":"go test ./..."}`, Timestamp: at("2025-01-15T10:01:05Z"), DurationMS: 1200},
+		},
+		NestedSessions: []types.SessionData{{
+			ID:      "agent-1",
+			Prompts: []types.Prompt{{Content: "Find Redis usages", Timestamp: at("2025-01-15T10:00:02Z")}},
+		}},
+	}
+
+	entries := ParseContext(RenderContext(session))
+
+	assert.Equal(t, []ContextEntry{
+		{Turn: 0, Kind: "response", Text: "Earlier work."},
+		{Turn: 1, Kind: "prompt", Text: "Drop Redis\n\nuse the DB instead"},
+		{Turn: 1, Kind: "response", Text: "## Plan\n\nRemoving the cache."},
+		{Turn: 1, Kind: "tool", Tool: "Edit", Text: "cache.go"},
+		{Turn: 2, Kind: "prompt", Text: "Now test it"},
+		{Turn: 2, Kind: "response", Text: "Tests pass."},
+		{Turn: 2, Kind: "tool", Tool: "Bash", Text: "go test ./..."},
+		{Subagent: "agent-1", Turn: 1, Kind: "prompt", Text: "Find Redis usages"},
+	}, entries)
+}
+
+func TestToolTargetTruncates(t *testing.T) {
+	long := strings.Repeat("é", 100)
+	got := toolTarget(`{"command":"` + long + `"}`)
+	assert.Equal(t, maxTargetLen, len([]rune(got)))
+	assert.Equal(t, "", toolTarget(`not json`))
+}
+
+func TestCreateRendersContext(t *testing.T) {
+	repo := initTestRepo(t)
+	store := NewStore(repo)
+
+	meta := NewMetadata("c0ffee123456", "abc", "main", "tester", "msg", "manual-commit")
+	bundle := SessionBundle{
+		Metadata: &types.SessionMetadata{AgentName: "codex", SessionID: "rollout-1"},
+		Session:  &types.SessionData{Prompts: []types.Prompt{{Content: "hi"}}},
+	}
+	require.NoError(t, store.Create(meta, []SessionBundle{bundle}))
+
+	context, err := repo.ReadFileFromBranch(git.CheckpointsBranch, SessionFiles("c0ffee123456", 0)["context"])
+	require.NoError(t, err)
+	assert.Contains(t, string(context), "# Session rollout-1\n\n- Agent: codex\n")
+}
+
+func between(t *testing.T, s, start, end string) string {
+	t.Helper()
+	i := strings.Index(s, start)
+	require.GreaterOrEqual(t, i, 0, "missing %q in:\n%s", start, s)
+	rest := s[i:]
+	j := strings.Index(rest, end)
+	require.GreaterOrEqual(t, j, 0, "missing %q in:\n%s", end, s)
+	return rest[:j]
+}
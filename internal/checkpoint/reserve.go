@@ -0,0 +1,133 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yibudak/open-entire/internal/lockfile"
+)
+
+// ReservationTTL is how long a reserved ID waits for its commit. Commits
+// that are aborted, or whose trailer is deleted, never claim theirs.
+const ReservationTTL = 24 * time.Hour
+
+// Reservation is a checkpoint ID written into a commit message before the
+// commit exists. The checkpoint is created under it once the commit is made.
+type Reservation struct {
+	ID         string    `json:"id"`
+	ReservedAt time.Time `json:"reserved_at"`
+}
+
+// Reservations tracks reserved checkpoint IDs in
+// .open-entire/reservations.json.
+type Reservations struct {
+	path string
+	list []Reservation
+}
+
+// LoadReservations reads the reservations of a repository, dropping those
+// that have expired.
+func LoadReservations(repoDir string) (*Reservations, error) {
+	r := &Reservations{path: filepath.Join(repoDir, ".open-entire", "reservations.json")}
+	list, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+	r.list = list
+	return r, nil
+}
+
+// read reads the live reservations from disk.
+func (r *Reservations) read() ([]Reservation, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var all []Reservation
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	var list []Reservation
+	for _, res := range all {
+		if time.Since(res.ReservedAt) < ReservationTTL {
+			list = append(list, res)
+		}
+	}
+	return list, nil
+}
+
+// Reserve generates a checkpoint ID and holds it for a commit.
+func (r *Reservations) Reserve() (string, error) {
+	id, err := GenerateID()
+	if err != nil {
+		return "", err
+	}
+	err = r.update(func(list []Reservation) []Reservation {
+		return append(list, Reservation{ID: id, ReservedAt: time.Now()})
+	})
+	return id, err
+}
+
+// Reserved reports whether an ID is held for a commit.
+func (r *Reservations) Reserved(id string) bool {
+	for _, res := range r.list {
+		if res.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Claim releases a reserved ID for its commit's checkpoint. It reports
+// whether the ID was reserved; IDs copied from other commits are not.
+func (r *Reservations) Claim(id string) (bool, error) {
+	claimed := false
+	err := r.update(func(list []Reservation) []Reservation {
+		for i, res := range list {
+			if res.ID == id {
+				claimed = true
+				return append(list[:i], list[i+1:]...)
+			}
+		}
+		return list
+	})
+	return claimed, err
+}
+
+// update changes the reservations on disk under a lock, starting from what
+// is there now rather than what was loaded. The post-commit hook runs in
+// the background and can overlap the next commit's hooks.
+func (r *Reservations) update(change func([]Reservation) []Reservation) error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	unlock, err := lockfile.Acquire(r.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	list, err := r.read()
+	if err != nil {
+		return err
+	}
+	list = change(list)
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return err
+	}
+	r.list = list
+	return nil
+}
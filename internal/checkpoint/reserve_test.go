@@ -0,0 +1,81 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservations(t *testing.T) {
+	dir := t.TempDir()
+
+	r, err := LoadReservations(dir)
+	require.NoError(t, err)
+	id, err := r.Reserve()
+	require.NoError(t, err)
+	assert.Len(t, id, 12)
+
+	r, err = LoadReservations(dir)
+	require.NoError(t, err)
+	assert.True(t, r.Reserved(id))
+	assert.False(t, r.Reserved("aabbccddeeff"))
+
+	claimed, err := r.Claim("aabbccddeeff")
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	claimed, err = r.Claim(id)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	r, err = LoadReservations(dir)
+	require.NoError(t, err)
+	assert.False(t, r.Reserved(id))
+}
+
+func TestReservationsExpire(t *testing.T) {
+	dir := t.TempDir()
+	stale := []Reservation{{ID: "aabbccddeeff", ReservedAt: time.Now().Add(-ReservationTTL - time.Minute)}}
+	data, err := json.Marshal(stale)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".open-entire"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".open-entire", "reservations.json"), data, 0o644))
+
+	r, err := LoadReservations(dir)
+	require.NoError(t, err)
+	assert.False(t, r.Reserved("aabbccddeeff"))
+}
+
+func TestReservationsConcurrent(t *testing.T) {
+	dir := t.TempDir()
+
+	const n = 20
+	ids := make(chan string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := LoadReservations(dir)
+			if !assert.NoError(t, err) {
+				return
+			}
+			id, err := r.Reserve()
+			assert.NoError(t, err)
+			ids <- id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	r, err := LoadReservations(dir)
+	require.NoError(t, err)
+	for id := range ids {
+		assert.True(t, r.Reserved(id), "reservation %s was lost", id)
+	}
+}
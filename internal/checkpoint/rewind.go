@@ -0,0 +1,275 @@
+package checkpoint
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/yibudak/open-entire/internal/agent"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// RewindMode is how Rewind applies a checkpoint.
+type RewindMode string
+
+const (
+	// RewindFiles restores the files of the checkpoint's commit, leaving
+	// HEAD and the current branch where they are.
+	RewindFiles RewindMode = "files"
+	// RewindBranch creates a branch at the checkpoint's commit and switches
+	// to it.
+	RewindBranch RewindMode = "branch"
+	// RewindHard resets the current branch to the checkpoint's commit.
+	RewindHard RewindMode = "hard"
+)
+
+// ParseRewindMode validates a rewind mode given by name.
+func ParseRewindMode(name string) (RewindMode, error) {
+	switch mode := RewindMode(name); mode {
+	case RewindFiles, RewindBranch, RewindHard:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown rewind mode %q (use files, branch or hard)", name)
+}
+
+// RewindOptions configures Rewind.
+type RewindOptions struct {
+	Mode RewindMode
+	// Branch names the branch RewindBranch creates, rewind/<id> by default.
+	Branch string
+	// Snapshot, when set, rewinds to the checkpoint's nth snapshot of an
+	// agent turn (counting from 1) instead of its commit. Only RewindFiles
+	// applies, since a snapshot is not on any branch.
+	Snapshot int
+}
+
+// RewindResult is what a rewind did.
+type RewindResult struct {
+	Commit string
+	// RecoveryRef holds the work from before the rewind, or is empty if
+	// there was nothing to save.
+	RecoveryRef string
+	// Branch is the branch RewindBranch created.
+	Branch string
+}
+
+// PreviewRewind reports what rewinding to a checkpoint would change,
+// without changing anything.
+func (s *Store) PreviewRewind(id string, opts RewindOptions) (*types.CheckpointMetadata, *git.RestorePreview, error) {
+	meta, commit, err := s.rewindTarget(id, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	preview, err := s.repo.PreviewRestore(commit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return meta, preview, nil
+}
+
+// Rewind returns the working tree to the state of a checkpoint's commit.
+// Uncommitted work is first snapshotted onto a recovery ref, so nothing a
+// rewind replaces is lost.
+func (s *Store) Rewind(id string, opts RewindOptions) (*RewindResult, error) {
+	if opts.Mode == "" {
+		opts.Mode = RewindFiles
+	}
+	if _, err := ParseRewindMode(string(opts.Mode)); err != nil {
+		return nil, err
+	}
+	meta, commit, err := s.rewindTarget(id, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := s.repo.Status()
+	if err != nil {
+		return nil, err
+	}
+	result := &RewindResult{Commit: commit}
+
+	// A hard reset also moves the branch away from its commits, so it saves
+	// a recovery ref even when the tree is clean; the snapshot's parent is
+	// the old tip
+	if !status.Clean() || opts.Mode == RewindHard {
+		msg := fmt.Sprintf("open-entire: before rewind to %s", meta.ID)
+		if result.RecoveryRef, err = s.repo.SaveRecovery(msg); err != nil {
+			return nil, fmt.Errorf("failed to save uncommitted changes: %w", err)
+		}
+	}
+
+	switch opts.Mode {
+	case RewindFiles:
+		if opts.Snapshot > 0 {
+			err = s.repo.RestoreSnapshot(commit)
+		} else {
+			err = s.repo.RestoreFiles(commit)
+		}
+	case RewindBranch:
+		result.Branch = opts.Branch
+		if result.Branch == "" {
+			result.Branch = "rewind/" + meta.ID
+		}
+		err = s.repo.SwitchNewBranch(result.Branch, commit)
+	case RewindHard:
+		err = s.repo.ResetHard(commit)
+	}
+	if err != nil {
+		return result, err
+	}
+	slog.Debug("rewound to checkpoint", "id", meta.ID, "mode", opts.Mode, "recovery", result.RecoveryRef)
+	return result, nil
+}
+
+// rewindTarget returns the commit a rewind restores.
+func (s *Store) rewindTarget(id string, opts RewindOptions) (*types.CheckpointMetadata, string, error) {
+	meta, err := s.Get(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if opts.Snapshot > 0 {
+		if opts.Snapshot > len(meta.Snapshots) {
+			return nil, "", fmt.Errorf("checkpoint %s has %d snapshot(s)", id, len(meta.Snapshots))
+		}
+		if opts.Mode != "" && opts.Mode != RewindFiles {
+			return nil, "", fmt.Errorf("a snapshot can only be rewound to with --mode files")
+		}
+		return meta, meta.Snapshots[opts.Snapshot-1].Commit, nil
+	}
+	if meta.CommitHash == "" {
+		return nil, "", fmt.Errorf("checkpoint %s has no associated commit", id)
+	}
+	return meta, meta.CommitHash, nil
+}
+
+// RestoredLog is the outcome of restoring one session's transcript.
+type RestoredLog struct {
+	AgentName string
+	SessionID string
+	// Path is where the transcript was written.
+	Path string
+	Err  error
+}
+
+// RestoreLogs writes the transcripts of a checkpoint's sessions back where
+// their agents keep them, so the sessions can be resumed. The working tree
+// is not touched.
+func (s *Store) RestoreLogs(id string) ([]RestoredLog, error) {
+	meta, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var restored []RestoredLog
+	for _, sess := range meta.Sessions {
+		r := RestoredLog{AgentName: sess.AgentName, SessionID: sess.SessionID}
+		r.Path, r.Err = s.restoreLog(meta, sess)
+		restored = append(restored, r)
+	}
+	return restored, nil
+}
+
+func (s *Store) restoreLog(meta *types.CheckpointMetadata, sess types.SessionSummary) (string, error) {
+	a, err := agent.Get(sess.AgentName)
+	if err != nil {
+		return "", err
+	}
+	restorer, ok := a.(agent.TranscriptRestorer)
+	if !ok {
+		return "", fmt.Errorf("%s sessions cannot be restored", sess.AgentName)
+	}
+	transcript, err := s.SessionTranscript(meta.ID, sess.Index)
+	if err != nil {
+		return "", err
+	}
+	return restorer.RestoreTranscript(sess.SessionID, s.repo.Dir, transcript)
+}
+
+// SessionTranscript reassembles a session's transcript as it was at a
+// checkpoint. Agents that record deltas store only the slice appended
+// since the previous checkpoint, so the slices of the same session in
+// earlier checkpoints are joined in front, in the order they were taken.
+func (s *Store) SessionTranscript(id string, sessionIndex int) ([]byte, error) {
+	meta, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	var target *types.SessionSummary
+	for i := range meta.Sessions {
+		if meta.Sessions[i].Index == sessionIndex {
+			target = &meta.Sessions[i]
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("checkpoint %s has no session %d", id, sessionIndex)
+	}
+
+	sm, err := s.SessionMetadata(id, sessionIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session %d of %s: %w", sessionIndex, id, err)
+	}
+	raw, err := s.RawTranscript(id, sessionIndex)
+	if err != nil {
+		return nil, fmt.Errorf("no transcript stored for session %d of %s: %w", sessionIndex, id, err)
+	}
+	if sm.TranscriptOffset == 0 {
+		return []byte(raw), nil
+	}
+
+	all, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	// Oldest first; List sorts newest first
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].CreatedAt.Before(all[j].CreatedAt)
+	})
+
+	// Replay the slices as they were taken: a slice at offset zero starts
+	// the transcript over, any other must continue it exactly. Redaction
+	// changes a slice's length, so the slices after it no longer line up
+	var buf []byte
+	started := false
+	for _, cp := range all {
+		if cp.ID == id {
+			break
+		}
+		if cp.CreatedAt.After(meta.CreatedAt) {
+			continue
+		}
+		for _, other := range cp.Sessions {
+			if other.AgentName != target.AgentName || other.SessionID != target.SessionID {
+				continue
+			}
+			om, err := s.SessionMetadata(cp.ID, other.Index)
+			if err != nil {
+				continue
+			}
+			slice, err := s.RawTranscript(cp.ID, other.Index)
+			if err != nil {
+				continue
+			}
+			if om.TranscriptOffset == 0 {
+				buf, started = nil, true
+			} else if started && om.TranscriptOffset != int64(len(buf)) {
+				return nil, sliceError(target.SessionID, cp.ID, om.TranscriptOffset, len(buf))
+			}
+			buf = append(buf, slice...)
+		}
+	}
+	if !started {
+		return nil, fmt.Errorf("the start of session %s is not in any checkpoint", target.SessionID)
+	}
+	if sm.TranscriptOffset != int64(len(buf)) {
+		return nil, sliceError(target.SessionID, id, sm.TranscriptOffset, len(buf))
+	}
+	return append(buf, raw...), nil
+}
+
+// sliceError reports a transcript slice that does not continue the slices
+// before it, because one is missing or was redacted.
+func sliceError(sessionID, id string, offset int64, have int) error {
+	return fmt.Errorf("transcript of session %s cannot be rebuilt: the slice in checkpoint %s starts at byte %d "+
+		"but the earlier slices hold %d bytes (one is missing or was redacted)", sessionID, id, offset, have)
+}
@@ -0,0 +1,132 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func createSlice(t *testing.T, store *Store, id, sessionID string, offset int64, transcript string) {
+	t.Helper()
+	meta := NewMetadata(id, "", "main", "tester", "msg", "manual-commit")
+	meta.Sessions = []types.SessionSummary{{Index: 0, AgentName: "claude-code", SessionID: sessionID}}
+	require.NoError(t, store.Create(meta, []SessionBundle{{
+		Metadata:       &types.SessionMetadata{AgentName: "claude-code", SessionID: sessionID, TranscriptOffset: offset},
+		FullTranscript: []byte(transcript),
+	}}))
+}
+
+func TestSessionTranscriptJoinsSlices(t *testing.T) {
+	repo := initTestRepo(t)
+	store := NewStore(repo)
+
+	createSlice(t, store, "aaaaaaaaaaaa", "sess-1", 0, "one\n")
+	createSlice(t, store, "bbbbbbbbbbbb", "other", 0, "unrelated\n")
+	createSlice(t, store, "cccccccccccc", "sess-1", 4, "two\n")
+	createSlice(t, store, "dddddddddddd", "sess-1", 8, "three\n")
+
+	got, err := store.SessionTranscript("cccccccccccc", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\n", string(got))
+
+	got, err = store.SessionTranscript("dddddddddddd", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\nthree\n", string(got))
+}
+
+func TestSessionTranscriptRejectsGaps(t *testing.T) {
+	repo := initTestRepo(t)
+	store := NewStore(repo)
+
+	// The middle slice was "two TOKEN\n" when taken, before redaction
+	createSlice(t, store, "aaaaaaaaaaaa", "sess-1", 0, "one\n")
+	createSlice(t, store, "bbbbbbbbbbbb", "sess-1", 4, "two [REDACTED]\n")
+	createSlice(t, store, "cccccccccccc", "sess-1", 14, "three\n")
+
+	_, err := store.SessionTranscript("cccccccccccc", 0)
+	assert.ErrorContains(t, err, "starts at byte 14 but the earlier slices hold 19 bytes")
+
+	// A missing slice leaves a gap
+	createSlice(t, store, "dddddddddddd", "sess-2", 0, "one\n")
+	createSlice(t, store, "eeeeeeeeeeee", "sess-2", 8, "three\n")
+	_, err = store.SessionTranscript("eeeeeeeeeeee", 0)
+	assert.ErrorContains(t, err, "cannot be rebuilt")
+}
+
+func TestSessionTranscriptMissingStart(t *testing.T) {
+	repo := initTestRepo(t)
+	store := NewStore(repo)
+
+	createSlice(t, store, "cccccccccccc", "sess-1", 4, "two\n")
+
+	_, err := store.SessionTranscript("cccccccccccc", 0)
+	assert.ErrorContains(t, err, "start of session sess-1")
+}
+
+func TestRewindToNewBranchSavesWork(t *testing.T) {
+	repo := initTestRepo(t)
+	store := NewStore(repo)
+	target := gitCmd(t, repo.Dir, "rev-parse", "HEAD")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("second\n"), 0o644))
+	gitCmd(t, repo.Dir, "commit", "-q", "-am", "second")
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("dirty\n"), 0o644))
+
+	meta := NewMetadata("aabbccddeeff", target, "main", "tester", "initial", "manual-commit")
+	require.NoError(t, store.Create(meta, nil))
+
+	_, preview, err := store.PreviewRewind(meta.ID, RewindOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md"}, preview.Overwritten)
+
+	result, err := store.Rewind(meta.ID, RewindOptions{Mode: RewindBranch})
+	require.NoError(t, err)
+	assert.Equal(t, "rewind/aabbccddeeff", result.Branch)
+	assert.True(t, strings.HasPrefix(result.RecoveryRef, "refs/entire/recovery/"))
+
+	assert.Equal(t, "rewind/aabbccddeeff", gitCmd(t, repo.Dir, "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Equal(t, target, gitCmd(t, repo.Dir, "rev-parse", "HEAD"))
+	assert.Equal(t, "dirty", gitCmd(t, repo.Dir, "show", result.RecoveryRef+":README.md"))
+}
+
+func TestParseRewindMode(t *testing.T) {
+	mode, err := ParseRewindMode("hard")
+	require.NoError(t, err)
+	assert.Equal(t, RewindHard, mode)
+
+	_, err = ParseRewindMode("soft")
+	assert.Error(t, err)
+}
+
+func TestRewindToSnapshot(t *testing.T) {
+	repo := initTestRepo(t)
+	store := NewStore(repo)
+	head := gitCmd(t, repo.Dir, "rev-parse", "HEAD")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("agent turn\n"), 0o644))
+	snap, err := repo.SnapshotWorktree("snapshot", head)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("later\n"), 0o644))
+
+	meta := NewMetadata("aabbccddeeff", head, "main", "tester", "initial", "manual-commit")
+	meta.Snapshots = []types.Snapshot{{Commit: snap, AgentName: "claude-code", SessionID: "sess-1"}}
+	require.NoError(t, store.Create(meta, nil))
+
+	_, err = store.Rewind(meta.ID, RewindOptions{Snapshot: 2})
+	assert.ErrorContains(t, err, "has 1 snapshot")
+	_, err = store.Rewind(meta.ID, RewindOptions{Snapshot: 1, Mode: RewindHard})
+	assert.Error(t, err)
+
+	result, err := store.Rewind(meta.ID, RewindOptions{Snapshot: 1})
+	require.NoError(t, err)
+	assert.Equal(t, snap, result.Commit)
+	assert.Equal(t, head, gitCmd(t, repo.Dir, "rev-parse", "HEAD"))
+	data, err := os.ReadFile(filepath.Join(repo.Dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "agent turn\n", string(data))
+}
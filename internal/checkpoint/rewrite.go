@@ -0,0 +1,168 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// minHashLen is the shortest abbreviated commit hash looked up.
+const minHashLen = 7
+
+// Rewrite is a commit replaced by another, as when it is amended or rebased.
+type Rewrite struct {
+	Old string
+	New string
+}
+
+// RecordRewrites points the checkpoints of rewritten commits at the commits
+// that replaced them. The old hash is kept in RewrittenFrom, and the lines
+// the agents wrote are renumbered as they are in the new commit. It returns
+// how many checkpoints changed.
+func (s *Store) RecordRewrites(rewrites []Rewrite) (int, error) {
+	replaced := make(map[string]string, len(rewrites))
+	for _, rw := range rewrites {
+		if rw.Old != "" && rw.New != "" && rw.Old != rw.New {
+			replaced[rw.Old] = rw.New
+		}
+	}
+	if len(replaced) == 0 {
+		return 0, nil
+	}
+
+	checkpoints, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	files := make(map[string][]byte)
+	changed := 0
+	for _, cp := range checkpoints {
+		newHash, ok := replaced[cp.CommitHash]
+		if !ok {
+			continue
+		}
+		oldHash := cp.CommitHash
+		cp.RewrittenFrom = append(cp.RewrittenFrom, oldHash)
+		cp.CommitHash = newHash
+
+		data, err := json.MarshalIndent(cp, "", "  ")
+		if err != nil {
+			return 0, err
+		}
+		files[MetadataPath(cp.ID)] = data
+		changed++
+
+		if lines, err := s.Lines(cp.ID); err == nil {
+			if data, err := s.rewriteLines(lines, oldHash, newHash); err == nil {
+				files[LinesPath(cp.ID)] = data
+			} else {
+				slog.Debug("keeping line attribution of rewritten commit", "id", cp.ID, "error", err)
+			}
+		}
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+
+	msg := fmt.Sprintf("Record %d rewritten commit(s)", changed)
+	if err := s.repo.CommitOnBranch(git.CheckpointsBranch, msg, files); err != nil {
+		return 0, fmt.Errorf("failed to record rewritten commits: %w", err)
+	}
+	return changed, nil
+}
+
+// rewriteLines renumbers a checkpoint's agent lines from the commit that
+// was rewritten to the commit that replaced it.
+func (s *Store) rewriteLines(lines *types.LineAttribution, oldHash, newHash string) ([]byte, error) {
+	before, err := s.repo.AddedLines(oldHash)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.repo.AddedLines(newHash)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(remapLines(lines, before, after))
+}
+
+// remapLines renumbers agent lines numbered as added in before to where
+// after adds the same text. Lines are matched in order, so a rebase that
+// shifts a file's lines keeps each one; lines after no longer adds are
+// dropped.
+func remapLines(lines *types.LineAttribution, before, after map[string][]git.AddedLine) *types.LineAttribution {
+	remapped := &types.LineAttribution{Files: make(map[string][]types.AgentLine)}
+	for path, agentLines := range lines.Files {
+		text := make(map[int]string, len(before[path]))
+		for _, l := range before[path] {
+			text[l.Number] = l.Text
+		}
+		sorted := append([]types.AgentLine(nil), agentLines...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Line < sorted[j].Line })
+
+		added := after[path]
+		next := 0
+		for _, l := range sorted {
+			t, ok := text[l.Line]
+			if !ok {
+				continue
+			}
+			for k := next; k < len(added); k++ {
+				if added[k].Text == t {
+					l.Line = added[k].Number
+					remapped.Files[path] = append(remapped.Files[path], l)
+					next = k + 1
+					break
+				}
+			}
+		}
+	}
+	return remapped
+}
+
+// ForCommit finds the checkpoint of a commit, from the trailer on the commit
+// or else from the hashes recorded on the checkpoints, which include those
+// of commits since amended or rebased away. hash may be abbreviated.
+func (s *Store) ForCommit(hash string) (*types.CheckpointMetadata, error) {
+	if full, err := s.repo.ResolveCommit(hash); err == nil {
+		if id, err := s.repo.CheckpointFromCommit(full); err == nil {
+			if meta, err := s.read(id); err == nil {
+				return meta, nil
+			}
+		}
+		hash = full
+	}
+	if len(hash) < minHashLen || !isHex(hash) {
+		return nil, fmt.Errorf("no checkpoint found for commit %s", hash)
+	}
+
+	checkpoints, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, cp := range checkpoints {
+		if strings.HasPrefix(cp.CommitHash, hash) {
+			return cp, nil
+		}
+		for _, old := range cp.RewrittenFrom {
+			if strings.HasPrefix(old, hash) {
+				return cp, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no checkpoint found for commit %s", hash)
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func TestRemapLines(t *testing.T) {
+	lines := &types.LineAttribution{Files: map[string][]types.AgentLine{
+		"main.go": {{Line: 4, Session: 1}, {Line: 2, Prompt: 2}, {Line: 9}},
+	}}
+	before := map[string][]git.AddedLine{
+		"main.go": {{Number: 2, Text: "a"}, {Number: 3, Text: "b"}, {Number: 4, Text: "a"}},
+	}
+	// The rebase moved the lines down and dropped the human one
+	after := map[string][]git.AddedLine{
+		"main.go": {{Number: 12, Text: "a"}, {Number: 14, Text: "a"}},
+	}
+
+	remapped := remapLines(lines, before, after)
+	assert.Equal(t, []types.AgentLine{{Line: 12, Prompt: 2}, {Line: 14, Session: 1}}, remapped.Files["main.go"])
+}
+
+func TestRecordRewrites(t *testing.T) {
+	repo := initTestRepo(t)
+	store := NewStore(repo)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "main.go"), []byte("package main\n"), 0o644))
+	gitCmd(t, repo.Dir, "add", "main.go")
+	gitCmd(t, repo.Dir, "commit", "-q", "-m", "add main")
+	oldHash := gitCmd(t, repo.Dir, "rev-parse", "HEAD")
+
+	meta := NewMetadata("112233445566", oldHash, "main", "tester", "add main", "manual-commit")
+	meta.Lines = &types.LineAttribution{Files: map[string][]types.AgentLine{"main.go": {{Line: 1}}}}
+	require.NoError(t, store.Create(meta, nil))
+
+	// Amending adds a line above the agent's
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "main.go"), []byte("// doc\npackage main\n"), 0o644))
+	gitCmd(t, repo.Dir, "commit", "-q", "-a", "--amend", "-m", "add main")
+	newHash := gitCmd(t, repo.Dir, "rev-parse", "HEAD")
+
+	changed, err := store.RecordRewrites([]Rewrite{{Old: oldHash, New: newHash}, {Old: "aaaa", New: "bbbb"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, changed)
+
+	cp, err := store.Get(meta.ID)
+	require.NoError(t, err)
+	assert.Equal(t, newHash, cp.CommitHash)
+	assert.Equal(t, []string{oldHash}, cp.RewrittenFrom)
+
+	lines, err := store.Lines(meta.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []types.AgentLine{{Line: 2}}, lines.Files["main.go"])
+
+	// Either hash, even abbreviated, finds the checkpoint
+	for _, ref := range []string{oldHash, newHash, oldHash[:10]} {
+		cp, err := store.Get(ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, meta.ID, cp.ID)
+	}
+	_, err = store.Get("0000000000")
+	assert.Error(t, err)
+
+	// Nothing left to rewrite
+	changed, err = store.RecordRewrites([]Rewrite{{Old: oldHash, New: newHash}})
+	require.NoError(t, err)
+	assert.Zero(t, changed)
+}
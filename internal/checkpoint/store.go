@@ -11,12 +11,14 @@ import (
 	"time"
 
 	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/redact"
 	"github.com/yibudak/open-entire/pkg/types"
 )
 
 // Store reads/writes checkpoints on the entire/checkpoints/v1 branch.
 type Store struct {
-	repo *git.Repository
+	repo     *git.Repository
+	redactor *redact.Redactor
 }
 
 // NewStore creates a new checkpoint store.
@@ -24,10 +26,23 @@ func NewStore(repo *git.Repository) *Store {
 	return &Store{repo: repo}
 }
 
+// SetRedactor makes Create scrub secrets from every session, and
+// SaveSummary from every summary, before it is written.
+func (s *Store) SetRedactor(r *redact.Redactor) {
+	s.redactor = r
+}
+
 // Create writes a new checkpoint to the checkpoints branch.
 func (s *Store) Create(meta *types.CheckpointMetadata, sessions []SessionBundle) error {
 	meta.CreatedAt = time.Now()
 
+	for i := range sessions {
+		renderContext(&sessions[i])
+	}
+	if s.redactor != nil {
+		meta.Redaction = s.redactSessions(meta.ID, sessions)
+	}
+
 	files := make(map[string][]byte)
 
 	// Write checkpoint metadata
@@ -37,6 +52,15 @@ func (s *Store) Create(meta *types.CheckpointMetadata, sessions []SessionBundle)
 	}
 	files[MetadataPath(meta.ID)] = metaData
 
+	// Line-level attribution
+	if meta.Lines != nil {
+		linesData, err := json.Marshal(meta.Lines)
+		if err != nil {
+			return err
+		}
+		files[LinesPath(meta.ID)] = linesData
+	}
+
 	// Write each session
 	for i, sess := range sessions {
 		paths := SessionFiles(meta.ID, i)
@@ -77,8 +101,160 @@ func (s *Store) Create(meta *types.CheckpointMetadata, sessions []SessionBundle)
 	return nil
 }
 
-// Get reads a checkpoint's metadata from the checkpoints branch.
-func (s *Store) Get(id string) (*types.CheckpointMetadata, error) {
+// renderContext fills in a session's context.md from its parsed data,
+// unless the caller supplied one.
+func renderContext(sess *SessionBundle) {
+	if len(sess.Context) > 0 || sess.Session == nil {
+		return
+	}
+	data := *sess.Session
+	if data.ID == "" && sess.Metadata != nil {
+		data.ID = sess.Metadata.SessionID
+	}
+	if data.AgentName == "" && sess.Metadata != nil {
+		data.AgentName = sess.Metadata.AgentName
+	}
+	sess.Context = RenderContext(&data)
+}
+
+// redactSessions scrubs the transcript, context and prompts of each session
+// in place and reports what was removed.
+func (s *Store) redactSessions(id string, sessions []SessionBundle) *types.RedactionReport {
+	report := &types.RedactionReport{}
+	for i := range sessions {
+		sess := &sessions[i]
+		paths := SessionFiles(id, i)
+		for _, f := range []struct {
+			path string
+			data *[]byte
+		}{
+			{paths["full"], &sess.FullTranscript},
+			{paths["context"], &sess.Context},
+			{paths["prompt"], &sess.Prompts},
+		} {
+			var counts map[string]int
+			*f.data, counts = s.redactor.Redact(*f.data)
+			redact.AddToReport(report, f.path, counts)
+		}
+	}
+	if report.Total > 0 {
+		slog.Info("redacted secrets from checkpoint", "id", id, "count", report.Total)
+	}
+	return report
+}
+
+// Redact scrubs secrets from a checkpoint that is already on the branch.
+// It returns what this pass removed; the checkpoint's stored report is
+// updated to include it. Earlier commits on the branch keep the originals.
+func (s *Store) Redact(id string, r *redact.Redactor) (*types.RedactionReport, error) {
+	meta, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &types.RedactionReport{}
+	files := make(map[string][]byte)
+	for _, summary := range meta.Sessions {
+		paths := SessionFiles(id, summary.Index)
+		for _, key := range []string{"full", "context", "prompt"} {
+			data, err := s.repo.ReadFileFromBranch(git.CheckpointsBranch, paths[key])
+			if err != nil {
+				continue // Not every session has every file
+			}
+			scrubbed, counts := r.Redact(data)
+			if len(counts) == 0 {
+				continue
+			}
+			files[paths[key]] = scrubbed
+			redact.AddToReport(report, paths[key], counts)
+			if key == "full" {
+				hash := sha256.Sum256(scrubbed)
+				files[paths["content_hash"]] = []byte(hex.EncodeToString(hash[:]))
+			}
+		}
+	}
+	if data, err := s.repo.ReadFileFromBranch(git.CheckpointsBranch, SummaryPath(id)); err == nil {
+		if scrubbed, counts := r.Redact(data); len(counts) > 0 {
+			files[SummaryPath(id)] = scrubbed
+			redact.AddToReport(report, SummaryPath(id), counts)
+		}
+	}
+	if meta.Summary != nil {
+		redact.AddToReport(report, MetadataPath(id), redactSummary(r, meta.Summary))
+	}
+	if report.Total == 0 {
+		return report, nil
+	}
+
+	if meta.Redaction == nil {
+		meta.Redaction = &types.RedactionReport{}
+	}
+	mergeReport(meta.Redaction, report)
+
+	metaData, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files[MetadataPath(id)] = metaData
+
+	msg := fmt.Sprintf("redact checkpoint %s", id)
+	if err := s.repo.CommitOnBranch(git.CheckpointsBranch, msg, files); err != nil {
+		return nil, fmt.Errorf("failed to write redacted checkpoint: %w", err)
+	}
+	return report, nil
+}
+
+// redactSummary scrubs the free text of a summary in place and returns
+// the per-rule counts.
+func redactSummary(r *redact.Redactor, summary *types.Summary) map[string]int {
+	total := make(map[string]int)
+	for _, field := range []*string{&summary.Intent, &summary.Outcome} {
+		scrubbed, counts := r.Redact([]byte(*field))
+		if len(counts) == 0 {
+			continue
+		}
+		*field = string(scrubbed)
+		for rule, n := range counts {
+			total[rule] += n
+		}
+	}
+	return total
+}
+
+// mergeReport adds the counts of src to dst.
+func mergeReport(dst, src *types.RedactionReport) {
+	if dst.ByRule == nil {
+		dst.ByRule = make(map[string]int)
+	}
+	for rule, n := range src.ByRule {
+		dst.ByRule[rule] += n
+	}
+	dst.Total += src.Total
+	dst.Files = append(dst.Files, src.Files...)
+}
+
+// Get reads a checkpoint's metadata from the checkpoints branch. ref is a
+// checkpoint ID or, when no checkpoint has that ID, the hash of its commit
+// before or after a rewrite, as ForCommit finds it.
+func (s *Store) Get(ref string) (*types.CheckpointMetadata, error) {
+	meta, err := s.read(ref)
+	if err == nil || s.Exists(ref) || len(ref) < minHashLen || !isHex(ref) {
+		return meta, err
+	}
+	if cp, ferr := s.ForCommit(ref); ferr == nil {
+		return cp, nil
+	}
+	return nil, err
+}
+
+// Exists reports whether a checkpoint with the ID is on the branch.
+func (s *Store) Exists(id string) bool {
+	_, err := s.repo.ReadFileFromBranch(git.CheckpointsBranch, MetadataPath(id))
+	return err == nil
+}
+
+// read reads the metadata of a checkpoint by ID.
+func (s *Store) read(id string) (*types.CheckpointMetadata, error) {
 	data, err := s.repo.ReadFileFromBranch(git.CheckpointsBranch, MetadataPath(id))
 	if err != nil {
 		return nil, fmt.Errorf("checkpoint %s not found: %w", id, err)
@@ -111,7 +287,7 @@ func (s *Store) List() ([]*types.CheckpointMetadata, error) {
 			}
 			seen[id] = true
 
-			meta, err := s.Get(id)
+			meta, err := s.read(id)
 			if err != nil {
 				slog.Debug("skipping invalid checkpoint", "id", id, "error", err)
 				continue
@@ -127,6 +303,20 @@ func (s *Store) List() ([]*types.CheckpointMetadata, error) {
 	return checkpoints, nil
 }
 
+// Lines returns which lines of its commit the agents of a checkpoint wrote.
+// Checkpoints made before lines were recorded have none.
+func (s *Store) Lines(id string) (*types.LineAttribution, error) {
+	data, err := s.repo.ReadFileFromBranch(git.CheckpointsBranch, LinesPath(id))
+	if err != nil {
+		return nil, err
+	}
+	var lines types.LineAttribution
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return nil, err
+	}
+	return &lines, nil
+}
+
 // RawTranscript returns the raw JSONL transcript for a session within a checkpoint.
 func (s *Store) RawTranscript(checkpointID string, sessionIndex int) (string, error) {
 	paths := SessionFiles(checkpointID, sessionIndex)
@@ -148,36 +338,110 @@ func (s *Store) FormattedTranscript(checkpointID string, sessionIndex int) (stri
 	return string(data), nil
 }
 
-// Rewind restores the working tree to the state at a checkpoint.
-func (s *Store) Rewind(repoDir, checkpointID string, hard bool) error {
-	meta, err := s.Get(checkpointID)
+// SessionMetadata reads a session's metadata within a checkpoint.
+func (s *Store) SessionMetadata(checkpointID string, sessionIndex int) (*types.SessionMetadata, error) {
+	paths := SessionFiles(checkpointID, sessionIndex)
+	data, err := s.repo.ReadFileFromBranch(git.CheckpointsBranch, paths["metadata"])
 	if err != nil {
-		return err
+		return nil, err
+	}
+	var meta types.SessionMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// Files returns every file of a checkpoint, keyed by its path on the
+// checkpoints branch.
+func (s *Store) Files(id string) (map[string][]byte, error) {
+	paths, err := s.repo.ListFilesOnBranch(git.CheckpointsBranch, ShardPath(id))
+	if err != nil {
+		return nil, err
 	}
-	if meta.CommitHash == "" {
-		return fmt.Errorf("checkpoint %s has no associated commit", checkpointID)
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("checkpoint %s not found", id)
 	}
+	files := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		data, err := s.repo.ReadFileFromBranch(git.CheckpointsBranch, path)
+		if err != nil {
+			return nil, err
+		}
+		files[path] = data
+	}
+	return files, nil
+}
 
-	if hard {
-		return s.repo.Checkout(meta.CommitHash, true)
+// Prompts returns the prompts stored for a session, in order.
+func (s *Store) Prompts(checkpointID string, sessionIndex int) ([]string, error) {
+	paths := SessionFiles(checkpointID, sessionIndex)
+	data, err := s.repo.ReadFileFromBranch(git.CheckpointsBranch, paths["prompt"])
+	if err != nil {
+		return nil, err
 	}
-	return s.repo.Checkout(meta.CommitHash, false)
+	var prompts []string
+	for _, p := range strings.Split(string(data), PromptSeparator) {
+		if p = strings.TrimSpace(p); p != "" {
+			prompts = append(prompts, p)
+		}
+	}
+	return prompts, nil
 }
 
-// RestoreLogs restores only the session logs from a checkpoint.
-func (s *Store) RestoreLogs(repoDir, checkpointID string) error {
-	// Read session data and write to local .open-entire/ directory
-	_, err := s.Get(checkpointID)
+// SaveSummary stores a checkpoint's summary: the structured form in its
+// metadata and the rendered Markdown as summary.md. An earlier summary is
+// replaced. With a redactor set, secrets the summarizer repeated are
+// scrubbed from both before they are written.
+func (s *Store) SaveSummary(id string, summary *types.Summary, markdown []byte) error {
+	meta, err := s.Get(id)
 	if err != nil {
 		return err
 	}
-	slog.Info("logs restored from checkpoint", "id", checkpointID)
+	if s.redactor != nil {
+		report := &types.RedactionReport{}
+		redact.AddToReport(report, MetadataPath(id), redactSummary(s.redactor, summary))
+		var counts map[string]int
+		markdown, counts = s.redactor.Redact(markdown)
+		redact.AddToReport(report, SummaryPath(id), counts)
+		if report.Total > 0 {
+			if meta.Redaction == nil {
+				meta.Redaction = &types.RedactionReport{}
+			}
+			mergeReport(meta.Redaction, report)
+		}
+	}
+	meta.Summary = summary
+
+	metaData, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	files := map[string][]byte{
+		MetadataPath(id): metaData,
+		SummaryPath(id):  markdown,
+	}
+
+	msg := fmt.Sprintf("summarize checkpoint %s", id)
+	if err := s.repo.CommitOnBranch(git.CheckpointsBranch, msg, files); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
 	return nil
 }
 
+// Summary returns a checkpoint's rendered summary.md.
+func (s *Store) Summary(id string) (string, error) {
+	data, err := s.repo.ReadFileFromBranch(git.CheckpointsBranch, SummaryPath(id))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 // SessionBundle contains all the data for a session to be stored.
 type SessionBundle struct {
 	Metadata       *types.SessionMetadata
+	Session        *types.SessionData
 	FullTranscript []byte
 	Context        []byte
 	Prompts        []byte
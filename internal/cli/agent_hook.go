@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/agent"
+	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/internal/hooks"
+	"github.com/yibudak/open-entire/internal/strategy"
+)
+
+func newAgentHookCmd() *cobra.Command {
+	var agentName string
+
+	cmd := &cobra.Command{
+		Use:    "_agent-hook <event>",
+		Short:  "Handle an agent hook event",
+		Long:   "Entry point for the agent hooks installed by 'open-entire enable --agent'. Reads the hook's JSON payload on stdin. Not meant to be run by hand.",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Agent hook failures are logged but never block the agent
+			if err := runAgentHook(cmd, agentName, args[0]); err != nil {
+				slog.Warn("agent hook failed", "agent", agentName, "event", args[0], "error", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&agentName, "agent", "claude-code", "agent that fired the hook")
+
+	return cmd
+}
+
+func runAgentHook(cmd *cobra.Command, agentName, event string) error {
+	a, err := agent.Get(agentName)
+	if err != nil {
+		return err
+	}
+	integration, ok := a.(agent.HookIntegration)
+	if !ok {
+		return fmt.Errorf("%s has no hook integration", agentName)
+	}
+	ev, err := integration.ParseHookEvent(event, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	// The agent says where it runs, which may be below the repository root
+	repoDir, err := findRepoRoot()
+	if ev.Dir != "" {
+		repoDir, err = findRepoRootFrom(ev.Dir)
+	}
+	if err != nil {
+		return fmt.Errorf("not a git repository: %w", err)
+	}
+
+	cfg, err := config.Load(repoDir)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	strat, err := strategy.New(cfg.Strategy, repoDir, cfg)
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("agent hook", "agent", agentName, "event", ev.Name, "session", ev.SessionID)
+	return hooks.NewHandler(repoDir, cfg, strat).HandleAgentHook(context.Background(), agentName, ev)
+}
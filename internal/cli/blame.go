@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/blame"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+)
+
+func newBlameCmd() *cobra.Command {
+	var (
+		rev        string
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "blame <file>",
+		Short: "Show which lines of a file agents wrote",
+		Long: `Run git blame on a file and join each line's commit to its checkpoint.
+Lines are marked A when an agent wrote them, H when a human did, ? when the
+checkpoint predates line-level attribution and + when they are not
+committed yet. Agent lines show the prompt that produced them.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoDir, err := findRepoRoot()
+			if err != nil {
+				return fmt.Errorf("not a git repository: %w", err)
+			}
+
+			repo, err := git.Open(repoDir)
+			if err != nil {
+				return fmt.Errorf("failed to open repository: %w", err)
+			}
+
+			path, err := repoRelPath(repoDir, args[0])
+			if err != nil {
+				return err
+			}
+
+			lines, err := blame.File(repo, checkpoint.NewStore(repo), path, rev)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(lines)
+			}
+
+			width := len(fmt.Sprint(len(lines)))
+			for _, l := range lines {
+				fmt.Printf("%s %-12s %-32s %*d  %s\n",
+					blameMarker(l.Origin), l.Checkpoint, blamePrompt(l.Prompt, 32), width, l.Number, l.Text)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rev, "rev", "", "blame the file at this revision instead of the working tree")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
+
+	return cmd
+}
+
+// repoRelPath turns a path given on the command line into one relative to
+// the repository root.
+func repoRelPath(repoDir, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(repoDir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the repository", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func blameMarker(o blame.Origin) string {
+	switch o {
+	case blame.Agent:
+		return "A"
+	case blame.Human:
+		return "H"
+	case blame.Uncommitted:
+		return "+"
+	}
+	return "?"
+}
+
+// blamePrompt fits a prompt's first line into n characters.
+func blamePrompt(prompt string, n int) string {
+	prompt, _, _ = strings.Cut(strings.TrimSpace(prompt), "\n")
+	if r := []rune(prompt); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return prompt
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/session"
 )
 
 func newCleanCmd() *cobra.Command {
@@ -31,6 +32,7 @@ func newCleanCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to list shadow branches: %w", err)
 			}
+			branches = withoutActiveShadows(repoDir, branches)
 
 			if len(branches) == 0 {
 				fmt.Println("No orphaned data found.")
@@ -64,3 +66,23 @@ func newCleanCmd() *cobra.Command {
 
 	return cmd
 }
+
+// withoutActiveShadows drops the shadow branches of sessions that have not
+// ended; their snapshots have yet to be condensed into a checkpoint.
+func withoutActiveShadows(repoDir string, branches []string) []string {
+	store, err := session.NewStore(repoDir)
+	if err != nil {
+		return branches
+	}
+	active := make(map[string]bool)
+	for _, s := range store.LiveSessions() {
+		active[s.ShadowBranch] = true
+	}
+	var orphaned []string
+	for _, b := range branches {
+		if !active[b] {
+			orphaned = append(orphaned, b)
+		}
+	}
+	return orphaned
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/pricing"
+	"github.com/yibudak/open-entire/internal/search"
+)
+
+func newCostCmd() *cobra.Command {
+	var (
+		since      string
+		by         string
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "cost",
+		Short: "Report what agent sessions cost",
+		Long: `Total the cost of the checkpointed agent sessions, grouped by author,
+branch or agent. Costs are priced when a checkpoint is made, from the cost
+the agent reports or else the model's price (built in, or set under
+"pricing" in the config).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupBy, err := pricing.ParseGroupBy(by)
+			if err != nil {
+				return err
+			}
+			var from time.Time
+			if since != "" {
+				if from, err = search.ParseTime(since); err != nil {
+					return err
+				}
+			}
+
+			repoDir, err := findRepoRoot()
+			if err != nil {
+				return fmt.Errorf("not a git repository: %w", err)
+			}
+
+			repo, err := git.Open(repoDir)
+			if err != nil {
+				return fmt.Errorf("failed to open repository: %w", err)
+			}
+
+			checkpoints, err := checkpoint.NewStore(repo).List()
+			if err != nil {
+				return fmt.Errorf("failed to list checkpoints: %w", err)
+			}
+			report := pricing.Report(checkpoints, groupBy, from)
+
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+
+			if len(report) == 0 {
+				fmt.Println("No checkpoints found.")
+				return nil
+			}
+
+			var total float64
+			unpriced := 0
+			fmt.Printf("%-30s %11s %8s %12s %12s %10s\n", by, "checkpoints", "sessions", "input", "output", "cost")
+			for _, g := range report {
+				fmt.Printf("%-30s %11d %8d %12d %12d %10s\n", g.Key, g.Checkpoints, g.Sessions,
+					g.TokenUsage.InputTokens, g.TokenUsage.OutputTokens, pricing.Format(g.TokenUsage.CostUSD))
+				total += g.TokenUsage.CostUSD
+				unpriced += g.Unpriced
+			}
+			fmt.Printf("\nTotal: %s\n", pricing.Format(total))
+			if unpriced > 0 {
+				fmt.Printf("%d session(s) used a model without a price; add it under \"pricing\" in the config.\n", unpriced)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "only checkpoints created on or after this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().StringVar(&by, "by", string(pricing.ByAgent), "group by author, branch or agent")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print the report as JSON")
+
+	return cmd
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/agent"
 	"github.com/yibudak/open-entire/internal/hooks"
 )
 
@@ -27,6 +28,13 @@ func newDisableCmd() *cobra.Command {
 			if err := hooks.Remove(repoDir); err != nil {
 				return fmt.Errorf("failed to remove hooks: %w", err)
 			}
+			for name, a := range agent.All() {
+				if integration, ok := a.(agent.HookIntegration); ok {
+					if err := integration.RemoveHooks(repoDir); err != nil {
+						return fmt.Errorf("failed to remove %s hooks: %w", name, err)
+					}
+				}
+			}
 
 			fmt.Println("Open-Entire hooks removed.")
 
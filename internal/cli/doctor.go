@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/yibudak/open-entire/internal/session"
@@ -14,7 +15,12 @@ func newDoctorCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "doctor",
 		Short: "Scan and fix stuck sessions",
-		Long:  "Find sessions stuck in ACTIVE state and offer to fix them.",
+		Long: `Find sessions left in a bad state and offer to fix them.
+
+A live session is stuck when its agent process has exited, or, when no
+process was recorded, when it has had no activity for an hour. A finished
+session whose snapshots were never condensed into a checkpoint is reported
+too; commit to condense them or run 'open-entire clean' to drop them.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			repoDir, err := findRepoRoot()
 			if err != nil {
@@ -26,19 +32,28 @@ func newDoctorCmd() *cobra.Command {
 				return fmt.Errorf("failed to open session store: %w", err)
 			}
 
-			stuck := store.StuckSessions()
-			if len(stuck) == 0 {
+			problems := store.Diagnose(time.Now())
+			if len(problems) == 0 {
 				fmt.Println("No stuck sessions found. Everything looks good!")
 				return nil
 			}
 
-			fmt.Printf("Found %d stuck session(s):\n", len(stuck))
-			for _, s := range stuck {
-				fmt.Printf("  - %s (%s, started %s)\n", s.ID, s.AgentName, s.StartedAt.Format("2006-01-02 15:04"))
+			fmt.Printf("Found %d problem(s):\n", len(problems))
+			var stuck []session.Session
+			for _, d := range problems {
+				s := d.Session
+				fmt.Printf("  - %s (%s, %s, started %s): %s, %s\n", s.ID, s.AgentName, s.Phase,
+					s.StartedAt.Format("2006-01-02 15:04"), d.Problem, d.Detail)
+				if d.Problem != session.ProblemNeverCondensed {
+					stuck = append(stuck, s)
+				}
 			}
 
+			if len(stuck) == 0 {
+				return nil
+			}
 			if !force {
-				fmt.Println("\nUse --force to automatically end stuck sessions.")
+				fmt.Println("\nUse --force to end stuck sessions.")
 				return nil
 			}
 
@@ -46,7 +61,7 @@ func newDoctorCmd() *cobra.Command {
 				if err := store.EndSession(s.ID); err != nil {
 					slog.Warn("failed to end session", "id", s.ID, "error", err)
 				} else {
-					fmt.Printf("  Fixed: %s\n", s.ID)
+					fmt.Printf("  Ended: %s\n", s.ID)
 				}
 			}
 
@@ -54,7 +69,7 @@ func newDoctorCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().BoolVar(&force, "force", false, "automatically fix stuck sessions")
+	cmd.Flags().BoolVar(&force, "force", false, "end stuck sessions")
 
 	return cmd
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/agent"
 	"github.com/yibudak/open-entire/internal/config"
 	"github.com/yibudak/open-entire/internal/git"
 	"github.com/yibudak/open-entire/internal/hooks"
@@ -13,10 +14,10 @@ import (
 
 func newEnableCmd() *cobra.Command {
 	var (
-		strategy string
-		agent    string
-		local    bool
-		force    bool
+		strategy  string
+		agentName string
+		local     bool
+		force     bool
 	)
 
 	cmd := &cobra.Command{
@@ -52,6 +53,9 @@ func newEnableCmd() *cobra.Command {
 			if strategy != "" {
 				cfg.Strategy = strategy
 			}
+			if local {
+				cfg.StrategyOptions.PushCheckpoints = false
+			}
 			if err := config.Save(repoDir, &cfg); err != nil {
 				return fmt.Errorf("failed to save config: %w", err)
 			}
@@ -61,26 +65,59 @@ func newEnableCmd() *cobra.Command {
 				return fmt.Errorf("failed to install hooks: %w", err)
 			}
 
+			// Pick up checkpoints teammates already pushed, e.g. after a clone
+			if !local {
+				if changed, err := repo.SyncCheckpoints("origin"); err != nil {
+					slog.Debug("could not fetch checkpoints from origin", "error", err)
+				} else if changed {
+					fmt.Println("Fetched existing checkpoints from origin.")
+				}
+			}
+
 			// Initialize checkpoints branch
 			if err := repo.EnsureCheckpointsBranch(); err != nil {
 				slog.Warn("could not create checkpoints branch", "error", err)
 			}
 
-			_ = local
-			_ = agent
+			// Let the agent report its sessions through its own hooks
+			if agentName != "" {
+				if err := installAgentHooks(repoDir, agentName); err != nil {
+					return err
+				}
+			}
 
 			fmt.Println("Open-Entire enabled successfully!")
 			fmt.Printf("  Strategy: %s\n", cfg.Strategy)
 			fmt.Printf("  Config:   %s/.open-entire/settings.json\n", repoDir)
+			if agentName != "" {
+				fmt.Printf("  Agent:    %s (session hooks installed)\n", agentName)
+			}
 			fmt.Println("\nYour AI coding sessions will now be captured as checkpoints.")
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVar(&strategy, "strategy", "", "capture strategy (manual-commit or auto-commit)")
-	cmd.Flags().StringVar(&agent, "agent", "", "AI agent to detect (default: auto)")
+	cmd.Flags().StringVar(&agentName, "agent", "", "install session hooks for this agent (e.g. claude-code)")
 	cmd.Flags().BoolVar(&local, "local", false, "store data locally only")
 	cmd.Flags().BoolVar(&force, "force", false, "force re-initialization")
 
 	return cmd
 }
+
+// installAgentHooks makes an agent report its session events through its
+// own hooks.
+func installAgentHooks(repoDir, name string) error {
+	a, err := agent.Get(name)
+	if err != nil {
+		return err
+	}
+	integration, ok := a.(agent.HookIntegration)
+	if !ok {
+		return fmt.Errorf("%s has no hook integration; its sessions are detected from transcript activity", name)
+	}
+	if err := integration.InstallHooks(repoDir); err != nil {
+		return fmt.Errorf("failed to install %s hooks: %w", name, err)
+	}
+	return nil
+}
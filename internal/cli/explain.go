@@ -2,10 +2,15 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/config"
 	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/pricing"
+	"github.com/yibudak/open-entire/internal/summary"
+	"github.com/yibudak/open-entire/pkg/types"
 )
 
 func newExplainCmd() *cobra.Command {
@@ -35,23 +40,41 @@ func newExplainCmd() *cobra.Command {
 
 			store := checkpoint.NewStore(repo)
 
-			// Resolve checkpoint ID
-			id := cpID
-			if commitHash != "" {
-				resolved, err := repo.CheckpointFromCommit(commitHash)
+			// Resolve the checkpoint; commits rewritten since it was made
+			// are found by their old hash as well as their new one
+			var cp *types.CheckpointMetadata
+			switch {
+			case commitHash != "":
+				cp, err = store.ForCommit(commitHash)
 				if err != nil {
-					return fmt.Errorf("no checkpoint found for commit %s: %w", commitHash, err)
+					return err
 				}
-				id = resolved
-			}
-
-			if id == "" {
+			case cpID != "":
+				cp, err = store.Get(cpID)
+				if err != nil {
+					return fmt.Errorf("checkpoint %s not found: %w", cpID, err)
+				}
+			default:
 				return fmt.Errorf("specify --checkpoint or --commit")
 			}
+			id := cp.ID
 
-			cp, err := store.Get(id)
-			if err != nil {
-				return fmt.Errorf("checkpoint %s not found: %w", id, err)
+			if generate {
+				cfg, err := config.Load(repoDir)
+				if err != nil {
+					return fmt.Errorf("failed to load config: %w", err)
+				}
+				s, err := summary.New(cfg.StrategyOptions.Summarize)
+				if err != nil {
+					return err
+				}
+				if err := setSummaryRedactor(store, cfg); err != nil {
+					return err
+				}
+				cp.Summary, err = summary.Checkpoint(cmd.Context(), s, store, repo, id)
+				if err != nil {
+					return fmt.Errorf("failed to generate summary: %w", err)
+				}
 			}
 
 			if rawTranscript {
@@ -66,6 +89,9 @@ func newExplainCmd() *cobra.Command {
 			// Display checkpoint info
 			fmt.Printf("Checkpoint: %s\n", cp.ID)
 			fmt.Printf("Commit:     %s\n", cp.CommitHash)
+			if len(cp.RewrittenFrom) > 0 {
+				fmt.Printf("Rewritten:  from %s\n", strings.Join(cp.RewrittenFrom, ", "))
+			}
 			fmt.Printf("Branch:     %s\n", cp.Branch)
 			fmt.Printf("Created:    %s\n", cp.CreatedAt.Format("2006-01-02 15:04:05"))
 			fmt.Printf("Message:    %s\n", cp.Message)
@@ -73,6 +99,29 @@ func newExplainCmd() *cobra.Command {
 			if cp.Attribution != nil {
 				fmt.Printf("Attribution: %.0f%% agent (%d/%d lines)\n",
 					cp.Attribution.AgentPercent, cp.Attribution.AgentLines, cp.Attribution.TotalLines)
+				for _, f := range cp.Attribution.Files {
+					fmt.Printf("  %s: %d agent, %d human\n", f.Path, f.AgentLines, f.HumanLines)
+				}
+			}
+
+			if cp.Redaction != nil && cp.Redaction.Total > 0 {
+				fmt.Printf("Redacted:   %d secret(s) (%s)\n", cp.Redaction.Total, formatRuleCounts(cp.Redaction))
+			}
+
+			if cp.Summary != nil {
+				fmt.Printf("\nSummary (%s):\n", cp.Summary.Generator)
+				fmt.Printf("  Intent:  %s\n", cp.Summary.Intent)
+				fmt.Printf("  Outcome: %s\n", cp.Summary.Outcome)
+				if len(cp.Summary.Files) > 0 {
+					fmt.Printf("  Files:   %s\n", strings.Join(cp.Summary.Files, ", "))
+				}
+			}
+
+			if len(cp.Snapshots) > 0 {
+				fmt.Printf("\nSnapshots (rewind --to %s --snapshot N):\n", cp.ID)
+				for i, snap := range cp.Snapshots {
+					fmt.Printf("  %d. %s  %s  %d file(s)\n", i+1, snap.CreatedAt.Format("15:04:05"), snap.AgentName, len(snap.Files))
+				}
 			}
 
 			if full {
@@ -90,19 +139,23 @@ func newExplainCmd() *cobra.Command {
 			if short {
 				fmt.Printf("\nSessions: %d\n", len(cp.Sessions))
 				for _, s := range cp.Sessions {
-					fmt.Printf("  - %s: %d input, %d output tokens\n",
-						s.AgentName, s.TokenUsage.InputTokens, s.TokenUsage.OutputTokens)
+					fmt.Printf("  - %s: %d input, %d output tokens, %s",
+						s.AgentName, s.TokenUsage.InputTokens, s.TokenUsage.OutputTokens, pricing.Format(s.TokenUsage.CostUSD))
+					if len(s.Models) > 0 {
+						fmt.Printf(" (%s)", strings.Join(s.Models, ", "))
+					}
+					fmt.Println()
 				}
+				fmt.Printf("Cost: %s\n", pricing.Format(cp.CostUSD))
 			}
 
-			_ = generate
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVar(&cpID, "checkpoint", "", "checkpoint ID")
-	cmd.Flags().StringVar(&commitHash, "commit", "", "commit hash")
-	cmd.Flags().BoolVar(&generate, "generate", false, "generate AI summary")
+	cmd.Flags().StringVar(&commitHash, "commit", "", "commit hash, also one since amended or rebased")
+	cmd.Flags().BoolVar(&generate, "generate", false, "generate and store a summary with the configured summarizer")
 	cmd.Flags().BoolVar(&full, "full", false, "show full transcript")
 	cmd.Flags().BoolVarP(&short, "short", "s", false, "show summary only")
 	cmd.Flags().BoolVar(&rawTranscript, "raw-transcript", false, "show raw JSONL transcript")
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/export"
+	"github.com/yibudak/open-entire/internal/git"
+)
+
+func newExportCmd() *cobra.Command {
+	var (
+		sel    export.Selector
+		format string
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export [checkpoint-id...]",
+		Short: "Export checkpoints to Markdown, HTML, JSON or a bundle",
+		Long: `Export checkpoints for attaching to pull requests or audits.
+
+Formats:
+  markdown  checkpoint details and each session's context.md
+  html      a self-contained page rendered like the web viewer
+  json      checkpoint and session metadata with parsed sessions (versioned schema)
+  bundle    a .tar.gz of the raw checkpoint tree, for open-entire import
+
+Select checkpoints by ID, by the commits in a range (--range main..feature)
+or by branch; criteria given together must all match. The format defaults
+to the output file's extension, or markdown.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoDir, err := findRepoRoot()
+			if err != nil {
+				return fmt.Errorf("not a git repository: %w", err)
+			}
+
+			repo, err := git.Open(repoDir)
+			if err != nil {
+				return fmt.Errorf("failed to open repository: %w", err)
+			}
+
+			if format == "" {
+				format = export.FormatFromPath(output)
+			}
+			if format == "" {
+				format = export.FormatMarkdown
+			}
+
+			store := checkpoint.NewStore(repo)
+			sel.IDs = args
+			checkpoints, err := export.Select(store, repo, sel)
+			if err != nil {
+				return err
+			}
+			if len(checkpoints) == 0 {
+				return fmt.Errorf("no checkpoints match")
+			}
+
+			var w io.Writer = os.Stdout
+			if output != "" && output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+
+			if err := export.Write(w, format, store, repo, checkpoints); err != nil {
+				return fmt.Errorf("export failed: %w", err)
+			}
+			if output != "" && output != "-" {
+				fmt.Fprintf(os.Stderr, "Exported %d checkpoint(s) to %s.\n", len(checkpoints), output)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&sel.Range, "range", "", "checkpoints of the commits in a range, e.g. main..feature")
+	cmd.Flags().StringVar(&sel.Branch, "branch", "", "checkpoints made on a branch")
+	cmd.Flags().BoolVar(&sel.All, "all", false, "export every checkpoint")
+	cmd.Flags().StringVarP(&format, "format", "f", "", "markdown, html, json or bundle")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "output file (default: stdout)")
+
+	return cmd
+}
+
+func newImportCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "import <bundle.tar.gz>",
+		Short: "Import a checkpoint bundle into this repository",
+		Long: `Add the checkpoints of a bundle made with "open-entire export --format bundle"
+to this repository's checkpoints branch. Checkpoints that already exist are
+skipped unless --force is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoDir, err := findRepoRoot()
+			if err != nil {
+				return fmt.Errorf("not a git repository: %w", err)
+			}
+
+			repo, err := git.Open(repoDir)
+			if err != nil {
+				return fmt.Errorf("failed to open repository: %w", err)
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			result, err := export.Import(checkpoint.NewStore(repo), repo, f, force)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Imported %d checkpoint(s).\n", len(result.Imported))
+			for _, id := range result.Skipped {
+				fmt.Printf("  skipped %s (already exists; use --force to overwrite)\n", id)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite checkpoints that already exist")
+
+	return cmd
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/internal/hooks"
+	"github.com/yibudak/open-entire/internal/strategy"
+)
+
+func newHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "_hook <event> [args...]",
+		Short:  "Handle a Git hook event",
+		Long:   "Entry point for the Git hooks installed by 'open-entire enable'. Not meant to be run by hand.",
+		Hidden: true,
+		Args:   cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoDir, err := findRepoRoot()
+			if err != nil {
+				return fmt.Errorf("not a git repository: %w", err)
+			}
+
+			cfg, err := config.Load(repoDir)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			strat, err := strategy.New(cfg.Strategy, repoDir, cfg)
+			if err != nil {
+				return err
+			}
+
+			handler := hooks.NewHandler(repoDir, cfg, strat)
+			ctx := context.Background()
+
+			// Hook failures are logged but never block the git operation
+			switch event := args[0]; event {
+			case "prepare-commit-msg":
+				err = handler.HandlePrepareCommitMsg(ctx, args[1:])
+			case "commit-msg":
+				err = handler.HandleCommitMsg(ctx, args[1:])
+			case "post-commit":
+				err = handler.HandlePostCommit(ctx, args[1:])
+			case "post-rewrite":
+				err = handler.HandlePostRewrite(ctx, args[1:], cmd.InOrStdin())
+			case "pre-push":
+				err = handler.HandlePrePush(ctx, args[1:], cmd.InOrStdin())
+			default:
+				return fmt.Errorf("unknown hook event: %s", event)
+			}
+			if err != nil {
+				slog.Warn("hook failed", "event", args[0], "error", err)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/redact"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func newRedactCmd() *cobra.Command {
+	var (
+		cpIDs        []string
+		all          bool
+		purgeHistory bool
+		remote       string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "redact",
+		Short: "Scrub secrets from existing checkpoints",
+		Long: `Run the redaction rules over checkpoints that are already stored and rewrite
+any secrets they contain. New checkpoints are redacted as they are created.
+
+The scrubbed files are written as a new commit on the checkpoints branch, so
+earlier commits still hold the originals. Use --purge-history to squash the
+branch so they are no longer reachable. The remote's checkpoints are merged
+in first, so they are scrubbed too, and the remote's branch is then replaced
+with a lease-protected force push. Other clones refuse to merge their old
+history back into the purged branch; they need to drop their local branch
+and sync again.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(cpIDs) == 0 && !all {
+				return fmt.Errorf("specify --checkpoint or --all")
+			}
+
+			repoDir, err := findRepoRoot()
+			if err != nil {
+				return fmt.Errorf("not a git repository: %w", err)
+			}
+
+			cfg, err := config.Load(repoDir)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			repo, err := git.Open(repoDir)
+			if err != nil {
+				return fmt.Errorf("failed to open repository: %w", err)
+			}
+
+			r, err := redact.New(cfg.Redaction)
+			if err != nil {
+				return err
+			}
+
+			// Bring in the remote's checkpoints, whose history is replaced
+			var remoteTip string
+			if purgeHistory && remote != "" && repo.HasRemote(remote) {
+				remoteTip, err = repo.FetchCheckpoints(remote)
+				if err != nil {
+					return err
+				}
+				if remoteTip != "" {
+					// A remote still on history from before an earlier purge
+					// is replaced without merging
+					if _, err := repo.MergeCheckpoints(remoteTip); err != nil && !errors.Is(err, git.ErrPurgedHistory) {
+						return fmt.Errorf("failed to merge checkpoints from %s: %w", remote, err)
+					}
+				}
+			}
+
+			store := checkpoint.NewStore(repo)
+			if all {
+				checkpoints, err := store.List()
+				if err != nil {
+					return fmt.Errorf("failed to list checkpoints: %w", err)
+				}
+				for _, cp := range checkpoints {
+					cpIDs = append(cpIDs, cp.ID)
+				}
+			}
+
+			total := 0
+			for _, id := range cpIDs {
+				report, err := store.Redact(id, r)
+				if err != nil {
+					return fmt.Errorf("failed to redact checkpoint %s: %w", id, err)
+				}
+				if report.Total == 0 {
+					fmt.Printf("%s: clean\n", id)
+					continue
+				}
+				fmt.Printf("%s: redacted %d (%s)\n", id, report.Total, formatRuleCounts(report))
+				total += report.Total
+			}
+
+			if purgeHistory {
+				if err := repo.PurgeCheckpointsHistory("squash checkpoints after redaction"); err != nil {
+					return fmt.Errorf("failed to purge history: %w", err)
+				}
+				fmt.Println("\nCheckpoint history squashed.")
+				if remoteTip != "" {
+					if err := repo.ReplaceRemoteCheckpoints(remote, remoteTip); err != nil {
+						return err
+					}
+					fmt.Printf("Replaced the checkpoints branch on %s.\n", remote)
+				}
+				fmt.Println("Run 'git gc --prune=now' to delete the old objects.")
+			} else if total > 0 {
+				fmt.Println("\nEarlier commits on the checkpoints branch still contain the originals; use --purge-history to drop them.")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&cpIDs, "checkpoint", nil, "checkpoint ID to redact (repeatable)")
+	cmd.Flags().BoolVar(&all, "all", false, "redact every checkpoint")
+	cmd.Flags().BoolVar(&purgeHistory, "purge-history", false, "squash the checkpoints branch so unredacted commits become unreachable")
+	cmd.Flags().StringVar(&remote, "remote", "origin", "remote whose checkpoints branch --purge-history replaces (empty for none)")
+
+	return cmd
+}
+
+func formatRuleCounts(report *types.RedactionReport) string {
+	rules := make([]string, 0, len(report.ByRule))
+	for rule := range report.ByRule {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	parts := make([]string, len(rules))
+	for i, rule := range rules {
+		parts[i] = fmt.Sprintf("%s: %d", rule, report.ByRule[rule])
+	}
+	return strings.Join(parts, ", ")
+}
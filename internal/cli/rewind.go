@@ -12,14 +12,33 @@ func newRewindCmd() *cobra.Command {
 	var (
 		to       string
 		list     bool
+		mode     string
+		branch   string
 		reset    bool
 		logsOnly bool
+		dryRun   bool
+		snapshot int
 	)
 
 	cmd := &cobra.Command{
 		Use:   "rewind",
 		Short: "Rewind to a previous checkpoint",
-		Long:  "List and restore to a previous checkpoint state.",
+		Long: `List and restore to a previous checkpoint state.
+
+A rewind first shows what it will change. Uncommitted work, untracked files
+included, is saved to a recovery ref under refs/entire/recovery/ before
+anything is overwritten.
+
+Modes:
+  files   restore the checkpoint's files, keeping HEAD and the branch (default)
+  branch  create a branch at the checkpoint and switch to it
+  hard    reset the current branch to the checkpoint
+
+--snapshot N restores the files as an agent left them at the end of the
+checkpoint's Nth turn, as listed by 'explain', instead of the commit.
+
+--logs-only restores the checkpoint's session transcripts instead, so the
+agent can resume them.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			repoDir, err := findRepoRoot()
 			if err != nil {
@@ -43,7 +62,11 @@ func newRewindCmd() *cobra.Command {
 					return nil
 				}
 				for _, cp := range checkpoints {
-					fmt.Printf("  %s  %s  %s\n", cp.ID, cp.CreatedAt.Format("2006-01-02 15:04"), cp.Message)
+					fmt.Printf("  %s  %s  %s", cp.ID, cp.CreatedAt.Format("2006-01-02 15:04"), cp.Message)
+					if n := len(cp.Snapshots); n > 0 {
+						fmt.Printf("  (%d snapshot(s))", n)
+					}
+					fmt.Println()
 				}
 				return nil
 			}
@@ -53,24 +76,105 @@ func newRewindCmd() *cobra.Command {
 			}
 
 			if logsOnly {
-				fmt.Printf("Restoring logs from checkpoint %s...\n", to)
-				return store.RestoreLogs(repoDir, to)
+				return restoreLogs(store, to)
+			}
+
+			switch {
+			case reset:
+				mode = string(checkpoint.RewindHard)
+			case branch != "":
+				mode = string(checkpoint.RewindBranch)
+			}
+			rewindMode, err := checkpoint.ParseRewindMode(mode)
+			if err != nil {
+				return err
 			}
 
-			if reset {
-				fmt.Printf("Hard resetting to checkpoint %s...\n", to)
+			opts := checkpoint.RewindOptions{Mode: rewindMode, Branch: branch, Snapshot: snapshot}
+			meta, preview, err := store.PreviewRewind(to, opts)
+			if err != nil {
+				return err
+			}
+			if snapshot > 0 {
+				snap := meta.Snapshots[snapshot-1]
+				fmt.Printf("Checkpoint %s, snapshot %d of %s session %s (%s)\n",
+					meta.ID, snapshot, snap.AgentName, snap.SessionID, snap.CreatedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("Checkpoint %s (commit %s)\n", meta.ID, meta.CommitHash)
+			}
+			if preview.DiffStat == "" {
+				fmt.Println("\nThe working tree already matches the checkpoint.")
 			} else {
-				fmt.Printf("Rewinding to checkpoint %s...\n", to)
+				fmt.Printf("\nChanges:\n%s\n", preview.DiffStat)
+			}
+			if len(preview.Overwritten) > 0 {
+				fmt.Println("\nUncommitted changes that will be replaced:")
+				for _, p := range preview.Overwritten {
+					fmt.Printf("  %s\n", p)
+				}
+			}
+			if !preview.Status.Clean() {
+				fmt.Println("\nUncommitted work will be saved to a recovery ref first.")
 			}
 
-			return store.Rewind(repoDir, to, reset)
+			if dryRun {
+				return nil
+			}
+
+			result, err := store.Rewind(to, opts)
+			if result != nil && result.RecoveryRef != "" {
+				fmt.Printf("\nSaved previous state to %s\n", result.RecoveryRef)
+				fmt.Printf("  bring it back with: git restore --source=%s --worktree -- :/\n", result.RecoveryRef)
+			}
+			if err != nil {
+				return err
+			}
+
+			switch rewindMode {
+			case checkpoint.RewindFiles:
+				fmt.Printf("\nRestored files from checkpoint %s. HEAD is unchanged; review with git status.\n", meta.ID)
+			case checkpoint.RewindBranch:
+				fmt.Printf("\nSwitched to new branch %s at checkpoint %s.\n", result.Branch, meta.ID)
+			case checkpoint.RewindHard:
+				fmt.Printf("\nReset current branch to checkpoint %s.\n", meta.ID)
+			}
+			return nil
 		},
 	}
 
 	cmd.Flags().StringVar(&to, "to", "", "checkpoint ID to rewind to")
 	cmd.Flags().BoolVar(&list, "list", false, "list available checkpoints")
-	cmd.Flags().BoolVar(&reset, "reset", false, "hard reset to checkpoint")
-	cmd.Flags().BoolVar(&logsOnly, "logs-only", false, "restore logs only")
+	cmd.Flags().StringVar(&mode, "mode", string(checkpoint.RewindFiles), "how to rewind: files, branch or hard")
+	cmd.Flags().StringVar(&branch, "branch", "", "name of the branch to create (implies --mode branch)")
+	cmd.Flags().BoolVar(&reset, "reset", false, "hard reset to checkpoint (same as --mode hard)")
+	cmd.Flags().IntVar(&snapshot, "snapshot", 0, "rewind to the checkpoint's Nth agent-turn snapshot")
+	cmd.Flags().BoolVar(&logsOnly, "logs-only", false, "restore session transcripts only")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "show what would change without rewinding")
 
 	return cmd
 }
+
+func restoreLogs(store *checkpoint.Store, id string) error {
+	restored, err := store.RestoreLogs(id)
+	if err != nil {
+		return err
+	}
+	if len(restored) == 0 {
+		fmt.Printf("Checkpoint %s has no sessions.\n", id)
+		return nil
+	}
+
+	failed := 0
+	for _, r := range restored {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("  %s %s: %v\n", r.AgentName, r.SessionID, r.Err)
+			continue
+		}
+		fmt.Printf("  %s %s -> %s\n", r.AgentName, r.SessionID, r.Path)
+	}
+	if failed == len(restored) {
+		return fmt.Errorf("no session logs could be restored")
+	}
+	return nil
+}
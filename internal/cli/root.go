@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/agent"
 	"github.com/yibudak/open-entire/internal/config"
 	"github.com/yibudak/open-entire/internal/logging"
 )
@@ -35,6 +36,9 @@ It creates versioned, searchable checkpoints of your AI interactions.`,
 				if !cfgDetailed {
 					level = cfg.LogLevel
 				}
+				for name, opts := range cfg.Agents {
+					agent.SetPriority(name, opts.Priority)
+				}
 			}
 
 			logging.Setup(level, cfgQuiet)
@@ -57,6 +61,17 @@ It creates versioned, searchable checkpoints of your AI interactions.`,
 		newDoctorCmd(),
 		newResetCmd(),
 		newServeCmd(),
+		newRedactCmd(),
+		newSyncCmd(),
+		newSearchCmd(),
+		newCostCmd(),
+		newStatsCmd(),
+		newBlameCmd(),
+		newExportCmd(),
+		newImportCmd(),
+		newHookCmd(),
+		newAgentHookCmd(),
+		newSummarizeCmd(),
 	)
 
 	return rootCmd
@@ -68,6 +83,11 @@ func findRepoRoot() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return findRepoRootFrom(dir)
+}
+
+// findRepoRootFrom walks up from dir to find a .git directory.
+func findRepoRootFrom(dir string) (string, error) {
 	for {
 		if _, err := os.Stat(dir + "/.git"); err == nil {
 			return dir, nil
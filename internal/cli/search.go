@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/search"
+)
+
+func newSearchCmd() *cobra.Command {
+	var (
+		q          search.Query
+		since      string
+		until      string
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "search [query]",
+		Short: "Search prompts, responses, tool calls and commit messages",
+		Long: `Search every checkpoint on the checkpoints branch. All words must match;
+wrap the query in double quotes to match it as a phrase. A local index in
+.open-entire/index/ is brought up to date with new checkpoints first.
+With --tool alone, lists calls of that tool.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoDir, err := findRepoRoot()
+			if err != nil {
+				return fmt.Errorf("not a git repository: %w", err)
+			}
+
+			repo, err := git.Open(repoDir)
+			if err != nil {
+				return fmt.Errorf("failed to open repository: %w", err)
+			}
+
+			q.Text = strings.Join(args, " ")
+			if strings.TrimSpace(q.Text) == "" && q.Tool == "" {
+				return fmt.Errorf("specify a query or --tool")
+			}
+			if since != "" {
+				if q.Since, err = search.ParseTime(since); err != nil {
+					return err
+				}
+			}
+			if until != "" {
+				if q.Until, err = search.ParseTime(until); err != nil {
+					return err
+				}
+			}
+
+			idx, err := search.Load(repoDir, repo)
+			if err != nil {
+				return err
+			}
+			results := idx.Search(q)
+
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(results)
+			}
+
+			if len(results) == 0 {
+				fmt.Println("No matches.")
+				return nil
+			}
+			for _, r := range results {
+				fmt.Printf("%s  %s  %s  %s  %s\n", r.CheckpointID, formatLocation(r),
+					r.CreatedAt.Format("2006-01-02"), r.Branch, r.Author)
+				fmt.Printf("    %s\n\n", r.Snippet)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&q.Agent, "agent", "", "only sessions of this agent")
+	cmd.Flags().StringVar(&q.Branch, "branch", "", "only checkpoints made on this branch")
+	cmd.Flags().StringVar(&q.Author, "author", "", "only checkpoints whose author contains this")
+	cmd.Flags().StringVar(&q.Tool, "tool", "", "only calls of this tool")
+	cmd.Flags().StringVar(&since, "since", "", "only checkpoints created on or after this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().StringVar(&until, "until", "", "only checkpoints created before this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().IntVarP(&q.Limit, "limit", "n", 20, "maximum number of results")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print results as JSON")
+
+	return cmd
+}
+
+// formatLocation says where in a checkpoint a search result is.
+func formatLocation(r search.Result) string {
+	if r.Session < 0 {
+		return "commit message"
+	}
+	loc := fmt.Sprintf("session %d", r.Session)
+	if r.Subagent != "" {
+		loc += " subagent " + r.Subagent
+	}
+	if r.Turn > 0 {
+		loc += fmt.Sprintf(" turn %d", r.Turn)
+	}
+	loc += " " + r.Kind
+	if r.Tool != "" {
+		loc += " " + r.Tool
+	}
+	return loc
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/pricing"
+	"github.com/yibudak/open-entire/internal/search"
+	"github.com/yibudak/open-entire/internal/stats"
+)
+
+func newStatsCmd() *cobra.Command {
+	var (
+		interval string
+		by       string
+		since    string
+		until    string
+		format   string
+		top      int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show usage analytics across checkpoints",
+		Long: `Aggregate every checkpoint into activity per day or week: checkpoints,
+sessions, prompts per commit, tokens and cost, mean agent attribution and
+cache hit ratio (cache reads against uncached input tokens), plus the files
+agents wrote to most. --by splits each period by author, branch or agent.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opts stats.Options
+			var err error
+			if opts.Interval, err = stats.ParseInterval(interval); err != nil {
+				return err
+			}
+			if by != "" {
+				if opts.GroupBy, err = pricing.ParseGroupBy(by); err != nil {
+					return err
+				}
+			}
+			if since != "" {
+				if opts.Since, err = search.ParseTime(since); err != nil {
+					return err
+				}
+			}
+			if until != "" {
+				if opts.Until, err = search.ParseTime(until); err != nil {
+					return err
+				}
+			}
+			opts.TopFiles = top
+
+			repoDir, err := findRepoRoot()
+			if err != nil {
+				return fmt.Errorf("not a git repository: %w", err)
+			}
+
+			repo, err := git.Open(repoDir)
+			if err != nil {
+				return fmt.Errorf("failed to open repository: %w", err)
+			}
+
+			checkpoints, err := stats.Load(checkpoint.NewStore(repo))
+			if err != nil {
+				return fmt.Errorf("failed to list checkpoints: %w", err)
+			}
+			report := stats.Build(checkpoints, opts)
+
+			switch format {
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			case "csv":
+				return stats.WriteCSV(os.Stdout, report)
+			case "table":
+				printStats(report)
+				return nil
+			}
+			return fmt.Errorf("unknown format %q (use table, json or csv)", format)
+		},
+	}
+
+	cmd.Flags().StringVar(&interval, "interval", string(stats.Day), "period length: day or week")
+	cmd.Flags().StringVar(&by, "by", "", "split periods by author, branch or agent")
+	cmd.Flags().StringVar(&since, "since", "", "only checkpoints created on or after this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().StringVar(&until, "until", "", "only checkpoints created before this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().StringVar(&format, "format", "table", "output format: table, json or csv")
+	cmd.Flags().IntVar(&top, "top", 10, "number of agent-written files to list")
+
+	return cmd
+}
+
+func printStats(r *stats.Report) {
+	if r.Total.Checkpoints == 0 {
+		fmt.Println("No checkpoints found.")
+		return
+	}
+
+	label := "group"
+	if r.GroupBy != "" {
+		label = string(r.GroupBy)
+	}
+	fmt.Printf("%-10s  %-20s %5s %8s %7s %7s %12s %10s %6s %7s\n",
+		r.Interval, label, "ckpts", "sessions", "prompts", "p/commit", "tokens", "cost", "agent", "cache")
+	printRow := func(period, group string, row stats.Row) {
+		fmt.Printf("%-10s  %-20s %5d %8d %7d %7.1f %12d %10s %5.0f%% %6.0f%%\n",
+			period, group, row.Checkpoints, row.Sessions, row.Prompts, row.PromptsPerCommit,
+			row.TokenUsage.InputTokens+row.TokenUsage.OutputTokens, pricing.Format(row.TokenUsage.CostUSD),
+			row.AgentPercent, row.CacheHitRatio*100)
+	}
+	for _, row := range r.Rows {
+		printRow(row.Period, row.Group, row)
+	}
+	if len(r.Groups) > 0 {
+		fmt.Println()
+		for _, row := range r.Groups {
+			printRow("all", row.Group, row)
+		}
+	}
+	fmt.Println()
+	printRow("total", "", r.Total)
+
+	if len(r.TopFiles) > 0 {
+		fmt.Println("\nFiles agents wrote to most:")
+		for _, f := range r.TopFiles {
+			fmt.Printf("  %6d lines  %3d checkpoint(s)  %s\n", f.AgentLines, f.Checkpoints, f.Path)
+		}
+	}
+}
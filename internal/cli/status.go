@@ -5,8 +5,11 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/agent"
+	"github.com/yibudak/open-entire/internal/checkpoint"
 	"github.com/yibudak/open-entire/internal/config"
 	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/pricing"
 	"github.com/yibudak/open-entire/internal/session"
 )
 
@@ -46,20 +49,39 @@ func newStatusCmd() *cobra.Command {
 			// Show active sessions
 			store, err := session.NewStore(repoDir)
 			if err == nil {
-				sessions := store.ActiveSessions()
+				sessions := store.LiveSessions()
 				if len(sessions) > 0 {
 					fmt.Printf("\nActive Sessions: %d\n", len(sessions))
 					for _, s := range sessions {
-						fmt.Printf("  - %s (%s)\n", s.ID, s.AgentName)
+						fmt.Printf("  - %s (%s, %s)\n", s.ID, s.AgentName, s.Phase)
 					}
 				}
 			}
 
-			// Show checkpoint count
+			// Show agent sessions detected on disk
+			if running := agent.DetectRunning(repoDir); len(running) > 0 {
+				fmt.Printf("\nRunning Agents: %d\n", len(running))
+				for _, d := range running {
+					fmt.Printf("  - %s %s (last active %s)\n", d.Agent.Name(), d.SessionID, d.LastActivity.Local().Format("15:04:05"))
+					if d.Reason != "" {
+						fmt.Printf("    %s\n", d.Reason)
+					}
+				}
+			}
+
+			// Show checkpoint count and what they cost
+			fmt.Println()
 			count, err := repo.CheckpointCount()
 			if err == nil {
 				fmt.Printf("Checkpoints: %d\n", count)
 			}
+			if checkpoints, err := checkpoint.NewStore(repo).List(); err == nil && len(checkpoints) > 0 {
+				var total float64
+				for _, cp := range checkpoints {
+					total += cp.CostUSD
+				}
+				fmt.Printf("Cost:        %s\n", pricing.Format(total))
+			}
 
 			if cfgDetailed {
 				data, _ := json.MarshalIndent(cfg, "", "  ")
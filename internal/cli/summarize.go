@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/redact"
+	"github.com/yibudak/open-entire/internal/summary"
+)
+
+func newSummarizeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "_summarize <checkpoint>",
+		Short:  "Summarize a checkpoint in the background",
+		Long:   "Started by the hooks to summarize a new checkpoint without holding up the agent or the commit. Not meant to be run by hand; use 'explain --generate' instead.",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := runSummarize(args[0]); err != nil {
+				slog.Warn("failed to summarize checkpoint", "id", args[0], "error", err)
+			}
+			return nil
+		},
+	}
+}
+
+func runSummarize(id string) error {
+	repoDir, err := findRepoRoot()
+	if err != nil {
+		return fmt.Errorf("not a git repository: %w", err)
+	}
+	cfg, err := config.Load(repoDir)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	repo, err := git.Open(repoDir)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+	s, err := summary.New(cfg.StrategyOptions.Summarize)
+	if err != nil {
+		return err
+	}
+	store := checkpoint.NewStore(repo)
+	if err := setSummaryRedactor(store, cfg); err != nil {
+		return err
+	}
+	_, err = summary.Checkpoint(context.Background(), s, store, repo, id)
+	return err
+}
+
+// setSummaryRedactor has the store scrub secrets from summaries, which a
+// summarizer may repeat from the sessions or make up, when redaction is on.
+func setSummaryRedactor(store *checkpoint.Store, cfg *config.Config) error {
+	if !cfg.Redaction.Enabled {
+		return nil
+	}
+	r, err := redact.New(cfg.Redaction)
+	if err != nil {
+		return err
+	}
+	store.SetRedactor(r)
+	return nil
+}
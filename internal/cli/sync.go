@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+)
+
+func newSyncCmd() *cobra.Command {
+	var push bool
+
+	cmd := &cobra.Command{
+		Use:   "sync [remote]",
+		Short: "Fetch and merge checkpoints from a remote",
+		Long: `Fetch the remote's checkpoints branch and merge it into the local one, so
+checkpoints teammates pushed can be explained and browsed locally.
+The remote defaults to origin.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			remote := "origin"
+			if len(args) > 0 {
+				remote = args[0]
+			}
+
+			repoDir, err := findRepoRoot()
+			if err != nil {
+				return fmt.Errorf("not a git repository: %w", err)
+			}
+
+			repo, err := git.Open(repoDir)
+			if err != nil {
+				return fmt.Errorf("failed to open repository: %w", err)
+			}
+
+			store := checkpoint.NewStore(repo)
+			before, _ := store.List()
+			changed, err := repo.SyncCheckpoints(remote)
+			if err != nil {
+				return err
+			}
+
+			if changed {
+				after, _ := store.List()
+				fmt.Printf("Merged checkpoints from %s: %d new, %d total.\n", remote, len(after)-len(before), len(after))
+			} else {
+				fmt.Printf("Checkpoints are up to date with %s.\n", remote)
+			}
+
+			if push {
+				if err := repo.PushCheckpoints(remote); err != nil {
+					return err
+				}
+				fmt.Printf("Pushed checkpoints to %s.\n", remote)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&push, "push", false, "also push local checkpoints to the remote")
+
+	return cmd
+}
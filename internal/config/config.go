@@ -8,21 +8,77 @@ import (
 
 // Config represents the merged configuration.
 type Config struct {
-	Enabled         bool            `json:"enabled"`
-	Strategy        string          `json:"strategy"`
-	LogLevel        string          `json:"log_level"`
-	Telemetry       bool            `json:"telemetry"`
-	StrategyOptions StrategyOptions `json:"strategy_options"`
+	Enabled         bool             `json:"enabled"`
+	Strategy        string           `json:"strategy"`
+	LogLevel        string           `json:"log_level"`
+	Telemetry       bool             `json:"telemetry"`
+	StrategyOptions StrategyOptions  `json:"strategy_options"`
+	Redaction       RedactionOptions `json:"redaction"`
+	// Agents holds per-agent options, keyed by agent name.
+	Agents map[string]AgentOptions `json:"agents,omitempty"`
+	// Pricing adds to or overrides the built-in model prices, keyed by
+	// model name prefix.
+	Pricing map[string]ModelPrice `json:"pricing,omitempty"`
+}
+
+// ModelPrice is what a model charges, in USD per million tokens.
+type ModelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cache_write"`
+	CacheRead  float64 `json:"cache_read"`
+}
+
+// RedactionOptions controls how secrets are scrubbed from transcripts
+// before they are written to the checkpoints branch.
+type RedactionOptions struct {
+	Enabled bool `json:"enabled"`
+	// Rules are extra patterns to redact, on top of the built-in detectors.
+	Rules []RedactionRule `json:"rules,omitempty"`
+	// Allow lists patterns that are never redacted, even when a rule matches.
+	Allow []string `json:"allow,omitempty"`
+	// MinEntropy is the bits-per-character threshold for high-entropy
+	// strings. Zero uses the default; a negative value disables the check.
+	MinEntropy float64 `json:"min_entropy,omitempty"`
+}
+
+// RedactionRule is a named regular expression to redact.
+type RedactionRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// AgentOptions holds agent-specific configuration.
+type AgentOptions struct {
+	// Priority orders the agents' sessions, ahead of how recently they were
+	// active; higher wins.
+	Priority int `json:"priority"`
 }
 
 // StrategyOptions holds strategy-specific configuration.
 type StrategyOptions struct {
 	Summarize SummarizeOptions `json:"summarize"`
+	// PushCheckpoints pushes the checkpoints branch alongside every git push.
+	PushCheckpoints bool `json:"push_checkpoints"`
 }
 
-// SummarizeOptions controls automatic summarization.
+// SummarizeOptions controls checkpoint summaries.
 type SummarizeOptions struct {
+	// Enabled summarizes every new checkpoint. explain --generate works
+	// either way.
 	Enabled bool `json:"enabled"`
+	// Backend is "local" (default), "command" or "http".
+	Backend string `json:"backend,omitempty"`
+	// Command is the executable and arguments the command backend runs,
+	// e.g. ["claude", "-p"]. The prompt is written to its stdin.
+	Command []string `json:"command,omitempty"`
+	// URL is the base URL of an OpenAI-compatible API for the http backend.
+	URL   string `json:"url,omitempty"`
+	Model string `json:"model,omitempty"`
+	// APIKeyEnv names the environment variable holding the API key.
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+	// TimeoutSeconds bounds one summary request.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
 }
 
 // Load reads and merges configuration from all layers.
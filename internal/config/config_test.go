@@ -15,6 +15,7 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, "manual-commit", cfg.Strategy)
 	assert.Equal(t, "info", cfg.LogLevel)
 	assert.False(t, cfg.Telemetry)
+	assert.True(t, cfg.Redaction.Enabled)
 }
 
 func TestLoadDefaults(t *testing.T) {
@@ -53,6 +54,22 @@ func TestLoadLocalOverridesProject(t *testing.T) {
 	assert.Equal(t, "auto-commit", cfg.Strategy) // local wins
 }
 
+func TestLoadMergesAgentOptions(t *testing.T) {
+	dir := t.TempDir()
+	entireDir := filepath.Join(dir, ".open-entire")
+	require.NoError(t, os.MkdirAll(entireDir, 0o755))
+
+	projectJSON := `{"agents": {"claude-code": {"priority": 10}, "codex": {"priority": 5}}}`
+	localJSON := `{"agents": {"codex": {"priority": 20}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(entireDir, "settings.json"), []byte(projectJSON), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(entireDir, "settings.local.json"), []byte(localJSON), 0o644))
+
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 10, cfg.Agents["claude-code"].Priority)
+	assert.Equal(t, 20, cfg.Agents["codex"].Priority) // local wins
+}
+
 func TestSaveAndLoad(t *testing.T) {
 	dir := t.TempDir()
 	cfg := &Config{
@@ -68,3 +85,16 @@ func TestSaveAndLoad(t *testing.T) {
 	assert.Equal(t, "auto-commit", loaded.Strategy)
 	assert.Equal(t, "debug", loaded.LogLevel)
 }
+
+func TestLoadPricing(t *testing.T) {
+	dir := t.TempDir()
+	entireDir := filepath.Join(dir, ".open-entire")
+	require.NoError(t, os.MkdirAll(entireDir, 0o755))
+
+	configJSON := `{"pricing": {"my-model": {"input": 2, "output": 8, "cache_read": 0.5}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(entireDir, "settings.json"), []byte(configJSON), 0o644))
+
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, ModelPrice{Input: 2, Output: 8, CacheRead: 0.5}, cfg.Pricing["my-model"])
+}
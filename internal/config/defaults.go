@@ -11,6 +11,10 @@ func DefaultConfig() Config {
 			Summarize: SummarizeOptions{
 				Enabled: false,
 			},
+			PushCheckpoints: true,
+		},
+		Redaction: RedactionOptions{
+			Enabled: true,
 		},
 	}
 }
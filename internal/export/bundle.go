@@ -0,0 +1,154 @@
+package export
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// maxBundleFile caps the size of a single file read from a bundle.
+const maxBundleFile = 512 << 20
+
+// bundlePath matches a file inside a checkpoint's sharded directory.
+var bundlePath = regexp.MustCompile(`^([0-9a-f]{2})/([0-9a-f]{10})/.+`)
+
+// WriteBundle exports the raw checkpoint trees as a .tar.gz, with paths as
+// they are on the checkpoints branch.
+func WriteBundle(w io.Writer, store *checkpoint.Store, checkpoints []*types.CheckpointMetadata) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, meta := range checkpoints {
+		files, err := store.Files(meta.ID)
+		if err != nil {
+			return err
+		}
+		paths := make([]string, 0, len(files))
+		for p := range files {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+
+		for _, p := range paths {
+			hdr := &tar.Header{
+				Name:    p,
+				Mode:    0o644,
+				Size:    int64(len(files[p])),
+				ModTime: meta.CreatedAt,
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if _, err := tw.Write(files[p]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ImportResult lists the checkpoints an import added and those it skipped
+// because they already existed.
+type ImportResult struct {
+	Imported []string
+	Skipped  []string
+}
+
+// Import adds the checkpoints of a bundle to the checkpoints branch in one
+// commit. Checkpoints that already exist are skipped unless force is set,
+// in which case the bundle's copy replaces them whole.
+func Import(store *checkpoint.Store, repo *git.Repository, r io.Reader, force bool) (*ImportResult, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a checkpoint bundle: %w", err)
+	}
+	defer gz.Close()
+
+	byID := make(map[string]map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected entry %s in bundle", hdr.Name)
+		}
+		m := bundlePath.FindStringSubmatch(hdr.Name)
+		if m == nil || path.Clean(hdr.Name) != hdr.Name {
+			return nil, fmt.Errorf("unexpected path %s in bundle", hdr.Name)
+		}
+		if hdr.Size > maxBundleFile {
+			return nil, fmt.Errorf("%s in bundle is too large", hdr.Name)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from bundle: %w", hdr.Name, err)
+		}
+		id := m[1] + m[2]
+		if byID[id] == nil {
+			byID[id] = make(map[string][]byte)
+		}
+		byID[id][hdr.Name] = data
+	}
+
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result := &ImportResult{}
+	files := make(map[string][]byte)
+	var replaced []string
+	for _, id := range ids {
+		if _, ok := byID[id][checkpoint.MetadataPath(id)]; !ok {
+			return nil, fmt.Errorf("checkpoint %s in bundle has no metadata.json", id)
+		}
+		if store.Exists(id) {
+			if !force {
+				result.Skipped = append(result.Skipped, id)
+				continue
+			}
+			// Files the bundle lacks, such as a session or summary added
+			// since it was exported, must not outlive the overwrite
+			replaced = append(replaced, checkpoint.ShardPath(id))
+		}
+		for p, data := range byID[id] {
+			files[p] = data
+		}
+		result.Imported = append(result.Imported, id)
+	}
+	if len(files) == 0 {
+		return result, nil
+	}
+
+	if err := repo.EnsureCheckpointsBranch(); err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("import %d checkpoint(s)", len(result.Imported))
+	if err := repo.ReplaceOnBranch(git.CheckpointsBranch, msg, replaced, files); err != nil {
+		return nil, fmt.Errorf("failed to import checkpoints: %w", err)
+	}
+	return result, nil
+}
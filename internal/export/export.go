@@ -0,0 +1,225 @@
+// Package export writes checkpoints to portable formats and imports
+// checkpoint bundles into another repository.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yibudak/open-entire/internal/agent"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/web"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// SchemaVersion is the version of the JSON export format. It changes only
+// when a field is removed or changes meaning.
+const SchemaVersion = 1
+
+// Export formats.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+	FormatBundle   = "bundle"
+)
+
+// FormatFromPath guesses the format from an output file name, or returns ""
+// if the extension is not recognised.
+func FormatFromPath(path string) string {
+	switch {
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return FormatBundle
+	}
+	switch filepath.Ext(path) {
+	case ".md", ".markdown":
+		return FormatMarkdown
+	case ".html", ".htm":
+		return FormatHTML
+	case ".json":
+		return FormatJSON
+	}
+	return ""
+}
+
+// Selector chooses which checkpoints to export. The criteria that are set
+// must all match.
+type Selector struct {
+	IDs []string
+	// Range is a commit range such as main..feature. Checkpoints of the
+	// commits in it are selected.
+	Range  string
+	Branch string
+	// All must be set to select every checkpoint when no criteria are given.
+	All bool
+}
+
+// Select returns the checkpoints matching the selector, oldest first.
+func Select(store *checkpoint.Store, repo *git.Repository, sel Selector) ([]*types.CheckpointMetadata, error) {
+	if len(sel.IDs) == 0 && sel.Range == "" && sel.Branch == "" && !sel.All {
+		return nil, fmt.Errorf("select checkpoints by ID, commit range or branch")
+	}
+
+	var candidates []*types.CheckpointMetadata
+	if len(sel.IDs) > 0 {
+		for _, id := range sel.IDs {
+			meta, err := store.Get(id)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, meta)
+		}
+	} else {
+		all, err := store.List()
+		if err != nil {
+			return nil, err
+		}
+		candidates = all
+	}
+
+	var inRange func(*types.CheckpointMetadata) bool
+	if sel.Range != "" {
+		commits, err := repo.RevList(sel.Range)
+		if err != nil {
+			return nil, err
+		}
+		hashes := make(map[string]bool, len(commits))
+		ids := make(map[string]bool)
+		for _, c := range commits {
+			hashes[c] = true
+			if id, err := repo.CheckpointFromCommit(c); err == nil {
+				ids[id] = true
+			}
+		}
+		inRange = func(m *types.CheckpointMetadata) bool {
+			return ids[m.ID] || hashes[m.CommitHash]
+		}
+	}
+
+	var selected []*types.CheckpointMetadata
+	for _, m := range candidates {
+		if inRange != nil && !inRange(m) {
+			continue
+		}
+		if sel.Branch != "" && m.Branch != sel.Branch {
+			continue
+		}
+		selected = append(selected, m)
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].CreatedAt.Before(selected[j].CreatedAt)
+	})
+	return selected, nil
+}
+
+// Write exports the checkpoints in the given format.
+func Write(w io.Writer, format string, store *checkpoint.Store, repo *git.Repository, checkpoints []*types.CheckpointMetadata) error {
+	switch format {
+	case FormatMarkdown:
+		return WriteMarkdown(w, store, checkpoints)
+	case FormatHTML:
+		return WriteHTML(w, store, repo, checkpoints)
+	case FormatJSON:
+		return WriteJSON(w, store, checkpoints)
+	case FormatBundle:
+		return WriteBundle(w, store, checkpoints)
+	default:
+		return fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+// Document is the JSON export format.
+type Document struct {
+	SchemaVersion int          `json:"schema_version"`
+	ExportedAt    time.Time    `json:"exported_at"`
+	Checkpoints   []Checkpoint `json:"checkpoints"`
+}
+
+// Checkpoint is an exported checkpoint with its sessions.
+type Checkpoint struct {
+	Metadata *types.CheckpointMetadata `json:"metadata"`
+	Sessions []Session                 `json:"sessions"`
+}
+
+// Session is an exported session. Data is parsed from the stored
+// transcript, and is missing if the agent's parser is unavailable.
+type Session struct {
+	Metadata *types.SessionMetadata `json:"metadata"`
+	Data     *types.SessionData     `json:"data,omitempty"`
+}
+
+// WriteJSON exports checkpoints as a versioned JSON document.
+func WriteJSON(w io.Writer, store *checkpoint.Store, checkpoints []*types.CheckpointMetadata) error {
+	doc := Document{
+		SchemaVersion: SchemaVersion,
+		ExportedAt:    time.Now().UTC(),
+		Checkpoints:   make([]Checkpoint, 0, len(checkpoints)),
+	}
+	for _, meta := range checkpoints {
+		cp := Checkpoint{Metadata: meta, Sessions: []Session{}}
+		for _, s := range meta.Sessions {
+			sm, err := store.SessionMetadata(meta.ID, s.Index)
+			if err != nil {
+				sm = &types.SessionMetadata{AgentName: s.AgentName, SessionID: s.SessionID, TokenUsage: s.TokenUsage}
+			}
+			cp.Sessions = append(cp.Sessions, Session{
+				Metadata: sm,
+				Data:     parseStoredSession(store, meta.ID, s),
+			})
+		}
+		doc.Checkpoints = append(doc.Checkpoints, cp)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// parseStoredSession parses a session's stored transcript with its agent's
+// parser.
+func parseStoredSession(store *checkpoint.Store, id string, s types.SessionSummary) *types.SessionData {
+	transcript, err := store.RawTranscript(id, s.Index)
+	if err != nil || transcript == "" {
+		return nil
+	}
+	data, err := agent.ParseStoredTranscript(s.AgentName, []byte(transcript))
+	if err != nil {
+		slog.Debug("not parsing stored transcript", "checkpoint", id, "session", s.Index, "error", err)
+		return nil
+	}
+	data.ID = s.SessionID
+	return data
+}
+
+// WriteHTML exports checkpoints as one self-contained HTML page, rendered
+// with the web viewer's templates.
+func WriteHTML(w io.Writer, store *checkpoint.Store, repo *git.Repository, checkpoints []*types.CheckpointMetadata) error {
+	pages := make([]web.ExportCheckpoint, 0, len(checkpoints))
+	for _, meta := range checkpoints {
+		page := web.ExportCheckpoint{Checkpoint: meta}
+		if meta.CommitHash != "" {
+			page.Diff, _ = repo.DiffContent(meta.CommitHash)
+		}
+		for _, s := range meta.Sessions {
+			transcript, _ := store.FormattedTranscript(meta.ID, s.Index)
+			page.Transcripts = append(page.Transcripts, transcript)
+		}
+		pages = append(pages, page)
+	}
+	return web.RenderExport(w, exportTitle(checkpoints), pages)
+}
+
+func exportTitle(checkpoints []*types.CheckpointMetadata) string {
+	if len(checkpoints) == 1 {
+		return "Checkpoint " + checkpoints[0].ID
+	}
+	return fmt.Sprintf("%d checkpoints", len(checkpoints))
+}
@@ -0,0 +1,268 @@
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+
+	_ "github.com/yibudak/open-entire/internal/agent/claude"
+)
+
+func initTestRepo(t *testing.T) (*git.Repository, string) {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "tester")
+	t.Setenv("GIT_AUTHOR_EMAIL", "tester@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "tester")
+	t.Setenv("GIT_COMMITTER_EMAIL", "tester@example.com")
+
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q", "-b", "main")
+	gitCmd(t, dir, "commit", "-q", "--allow-empty", "-m", "initial")
+
+	repo, err := git.Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, repo.EnsureCheckpointsBranch())
+	return repo, dir
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
+// commitWithCheckpoint makes a commit on the current branch and stores a
+// checkpoint for it from the sample Claude transcript.
+func commitWithCheckpoint(t *testing.T, repo *git.Repository, dir, id, message string) {
+	t.Helper()
+	gitCmd(t, dir, "commit", "-q", "--allow-empty", "-m", message, "--trailer", git.TrailerCheckpoint+": "+id)
+	hash := gitCmd(t, dir, "rev-parse", "HEAD")
+	branch := gitCmd(t, dir, "branch", "--show-current")
+
+	transcript, err := os.ReadFile(filepath.Join("..", "..", "testdata", "sample_session.jsonl"))
+	require.NoError(t, err)
+
+	meta := checkpoint.NewMetadata(id, hash, branch, "tester", message, "manual-commit")
+	meta.Sessions = []types.SessionSummary{{Index: 0, AgentName: "claude-code", SessionID: "sess-" + id}}
+	bundle := checkpoint.SessionBundle{
+		Metadata:       &types.SessionMetadata{AgentName: "claude-code", SessionID: "sess-" + id},
+		FullTranscript: transcript,
+		Context:        []byte("# Session sess-" + id + "\n\n## Turn 1\n\n**Prompt**\n\n> " + message + "\n\n```sh\n# not a heading\n```\n"),
+		Prompts:        []byte(message + "\n"),
+	}
+	require.NoError(t, checkpoint.NewStore(repo).Create(meta, []checkpoint.SessionBundle{bundle}))
+}
+
+func ids(checkpoints []*types.CheckpointMetadata) []string {
+	out := make([]string, len(checkpoints))
+	for i, c := range checkpoints {
+		out[i] = c.ID
+	}
+	return out
+}
+
+func TestSelect(t *testing.T) {
+	repo, dir := initTestRepo(t)
+	store := checkpoint.NewStore(repo)
+	commitWithCheckpoint(t, repo, dir, "aaaaaaaaaaaa", "first")
+	gitCmd(t, dir, "checkout", "-q", "-b", "feature")
+	commitWithCheckpoint(t, repo, dir, "bbbbbbbbbbbb", "second")
+	commitWithCheckpoint(t, repo, dir, "cccccccccccc", "third")
+
+	_, err := Select(store, repo, Selector{})
+	assert.Error(t, err, "an empty selector must be explicit")
+
+	got, err := Select(store, repo, Selector{All: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc"}, ids(got))
+
+	got, err = Select(store, repo, Selector{Range: "main..feature"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bbbbbbbbbbbb", "cccccccccccc"}, ids(got))
+
+	got, err = Select(store, repo, Selector{Branch: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aaaaaaaaaaaa"}, ids(got))
+
+	got, err = Select(store, repo, Selector{IDs: []string{"cccccccccccc"}, Range: "main..feature"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cccccccccccc"}, ids(got))
+
+	_, err = Select(store, repo, Selector{IDs: []string{"ffffffffffff"}})
+	assert.Error(t, err)
+	_, err = Select(store, repo, Selector{Range: "--all"})
+	assert.Error(t, err)
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	repo, dir := initTestRepo(t)
+	store := checkpoint.NewStore(repo)
+	commitWithCheckpoint(t, repo, dir, "aaaaaaaaaaaa", "Add hello")
+	require.NoError(t, store.SaveSummary("aaaaaaaaaaaa", &types.Summary{Intent: "Say hello", Outcome: "Done"}, nil))
+
+	cps, err := Select(store, repo, Selector{All: true})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatMarkdown, store, repo, cps))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "# Checkpoint aaaaaaaaaaaa\n"))
+	assert.Contains(t, out, "- Message: Add hello\n")
+	assert.Contains(t, out, "**Intent:** Say hello")
+	assert.Contains(t, out, "\n## Session sess-aaaaaaaaaaaa\n")
+	assert.Contains(t, out, "\n### Turn 1\n")
+	assert.Contains(t, out, "\n# not a heading\n", "code blocks are left alone")
+}
+
+func TestWriteJSON(t *testing.T) {
+	repo, dir := initTestRepo(t)
+	store := checkpoint.NewStore(repo)
+	commitWithCheckpoint(t, repo, dir, "aaaaaaaaaaaa", "Add hello")
+
+	cps, err := Select(store, repo, Selector{IDs: []string{"aaaaaaaaaaaa"}})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatJSON, store, repo, cps))
+
+	var doc Document
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, SchemaVersion, doc.SchemaVersion)
+	require.Len(t, doc.Checkpoints, 1)
+	cp := doc.Checkpoints[0]
+	assert.Equal(t, "aaaaaaaaaaaa", cp.Metadata.ID)
+	require.Len(t, cp.Sessions, 1)
+	assert.Equal(t, "sess-aaaaaaaaaaaa", cp.Sessions[0].Metadata.SessionID)
+
+	data := cp.Sessions[0].Data
+	require.NotNil(t, data, "the stored transcript is parsed")
+	assert.Equal(t, "sess-aaaaaaaaaaaa", data.ID)
+	assert.Equal(t, "claude-code", data.AgentName)
+	assert.Equal(t, "Create a hello world function in Go", data.Prompts[0].Content)
+	assert.Empty(t, data.TranscriptPath)
+}
+
+func TestWriteHTML(t *testing.T) {
+	repo, dir := initTestRepo(t)
+	store := checkpoint.NewStore(repo)
+	commitWithCheckpoint(t, repo, dir, "aaaaaaaaaaaa", "Add hello")
+	commitWithCheckpoint(t, repo, dir, "bbbbbbbbbbbb", "Add test")
+
+	cps, err := Select(store, repo, Selector{All: true})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatHTML, store, repo, cps))
+	out := buf.String()
+
+	assert.Contains(t, out, "<title>2 checkpoints</title>")
+	assert.Contains(t, out, `id="checkpoint-aaaaaaaaaaaa"`)
+	assert.Contains(t, out, `id="checkpoint-bbbbbbbbbbbb-session-0"`)
+	assert.Contains(t, out, `href="#checkpoint-aaaaaaaaaaaa-session-0"`)
+	assert.Contains(t, out, "--bg:", "the stylesheet is inlined")
+	assert.NotContains(t, out, `href="/`, "no links need a server")
+	assert.NotContains(t, out, `src="/`)
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	repo, dir := initTestRepo(t)
+	store := checkpoint.NewStore(repo)
+	commitWithCheckpoint(t, repo, dir, "aaaaaaaaaaaa", "Add hello")
+	commitWithCheckpoint(t, repo, dir, "bbbbbbbbbbbb", "Add test")
+
+	cps, err := Select(store, repo, Selector{All: true})
+	require.NoError(t, err)
+	var bundle bytes.Buffer
+	require.NoError(t, Write(&bundle, FormatBundle, store, repo, cps))
+
+	other, _ := initTestRepo(t)
+	otherStore := checkpoint.NewStore(other)
+	result, err := Import(otherStore, other, bytes.NewReader(bundle.Bytes()), false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aaaaaaaaaaaa", "bbbbbbbbbbbb"}, result.Imported)
+
+	for _, id := range []string{"aaaaaaaaaaaa", "bbbbbbbbbbbb"} {
+		want, err := store.Files(id)
+		require.NoError(t, err)
+		got, err := otherStore.Files(id)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	// Importing again skips what is there
+	result, err = Import(otherStore, other, bytes.NewReader(bundle.Bytes()), false)
+	require.NoError(t, err)
+	assert.Empty(t, result.Imported)
+	assert.Equal(t, []string{"aaaaaaaaaaaa", "bbbbbbbbbbbb"}, result.Skipped)
+
+	result, err = Import(otherStore, other, bytes.NewReader(bundle.Bytes()), true)
+	require.NoError(t, err)
+	assert.Len(t, result.Imported, 2)
+}
+
+func TestImportForceReplacesCheckpoint(t *testing.T) {
+	repo, dir := initTestRepo(t)
+	store := checkpoint.NewStore(repo)
+	commitWithCheckpoint(t, repo, dir, "aaaaaaaaaaaa", "Add hello")
+	cps, err := Select(store, repo, Selector{All: true})
+	require.NoError(t, err)
+	var bundle bytes.Buffer
+	require.NoError(t, Write(&bundle, FormatBundle, store, repo, cps))
+	want, err := store.Files("aaaaaaaaaaaa")
+	require.NoError(t, err)
+
+	// The local copy gained a session and a summary since the export
+	stale := checkpoint.SessionFiles("aaaaaaaaaaaa", 1)["full"]
+	require.NoError(t, repo.CommitOnBranch(git.CheckpointsBranch, "add session", map[string][]byte{stale: []byte("{}\n")}))
+	require.NoError(t, store.SaveSummary("aaaaaaaaaaaa", &types.Summary{Intent: "old"}, []byte("# Old\n")))
+
+	result, err := Import(store, repo, bytes.NewReader(bundle.Bytes()), true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aaaaaaaaaaaa"}, result.Imported)
+	got, err := store.Files("aaaaaaaaaaaa")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	_, err = store.Summary("aaaaaaaaaaaa")
+	assert.Error(t, err)
+}
+
+func TestImportRejectsUnexpectedPaths(t *testing.T) {
+	repo, _ := initTestRepo(t)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	data := []byte("{}")
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "aa/bbbbbbbbbb/../../../etc/passwd", Mode: 0o644, Size: int64(len(data))}))
+	_, err := tw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	_, err = Import(checkpoint.NewStore(repo), repo, &buf, false)
+	assert.ErrorContains(t, err, "unexpected path")
+}
+
+func TestFormatFromPath(t *testing.T) {
+	assert.Equal(t, FormatBundle, FormatFromPath("out.tar.gz"))
+	assert.Equal(t, FormatHTML, FormatFromPath("report.html"))
+	assert.Equal(t, FormatMarkdown, FormatFromPath("pr.md"))
+	assert.Equal(t, FormatJSON, FormatFromPath("audit.json"))
+	assert.Equal(t, "", FormatFromPath(""))
+}
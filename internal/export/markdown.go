@@ -0,0 +1,100 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// WriteMarkdown exports checkpoints as Markdown: the details of each
+// checkpoint followed by the context.md of its sessions.
+func WriteMarkdown(w io.Writer, store *checkpoint.Store, checkpoints []*types.CheckpointMetadata) error {
+	var b strings.Builder
+	for i, meta := range checkpoints {
+		if i > 0 {
+			b.WriteString("\n---\n\n")
+		}
+		writeCheckpointMarkdown(&b, store, meta)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeCheckpointMarkdown(b *strings.Builder, store *checkpoint.Store, meta *types.CheckpointMetadata) {
+	fmt.Fprintf(b, "# Checkpoint %s\n\n", meta.ID)
+	if meta.CommitHash != "" {
+		fmt.Fprintf(b, "- Commit: `%s`\n", meta.CommitHash)
+	}
+	fmt.Fprintf(b, "- Branch: %s\n", meta.Branch)
+	fmt.Fprintf(b, "- Author: %s\n", meta.Author)
+	fmt.Fprintf(b, "- Created: %s\n", meta.CreatedAt.UTC().Format("2006-01-02 15:04:05 UTC"))
+	fmt.Fprintf(b, "- Strategy: %s\n", meta.Strategy)
+	if msg := strings.TrimSpace(meta.Message); msg != "" {
+		fmt.Fprintf(b, "- Message: %s\n", strings.SplitN(msg, "\n", 2)[0])
+	}
+	if a := meta.Attribution; a != nil {
+		fmt.Fprintf(b, "- Attribution: %.0f%% agent (%d/%d lines)\n", a.AgentPercent, a.AgentLines, a.TotalLines)
+	}
+	if r := meta.Redaction; r != nil && r.Total > 0 {
+		fmt.Fprintf(b, "- Redacted: %d secret(s) (%s)\n", r.Total, ruleCounts(r.ByRule))
+	}
+
+	if s := meta.Summary; s != nil {
+		b.WriteString("\n## Summary\n\n")
+		fmt.Fprintf(b, "**Intent:** %s\n\n", s.Intent)
+		fmt.Fprintf(b, "**Outcome:** %s\n", s.Outcome)
+	}
+
+	if a := meta.Attribution; a != nil && len(a.Files) > 0 {
+		b.WriteString("\n## Attribution\n\n| File | Agent lines | Human lines |\n|------|-------------|-------------|\n")
+		for _, f := range a.Files {
+			fmt.Fprintf(b, "| `%s` | %d | %d |\n", f.Path, f.AgentLines, f.HumanLines)
+		}
+	}
+
+	for _, s := range meta.Sessions {
+		transcript, err := store.FormattedTranscript(meta.ID, s.Index)
+		b.WriteString("\n")
+		if err != nil || strings.TrimSpace(transcript) == "" {
+			fmt.Fprintf(b, "## Session %d (%s)\n\n_No transcript stored._\n", s.Index, s.AgentName)
+			continue
+		}
+		b.WriteString(demoteHeadings(strings.TrimSpace(transcript)))
+		b.WriteString("\n")
+	}
+}
+
+// demoteHeadings nests a Markdown document one level deeper, leaving code
+// blocks alone.
+func demoteHeadings(md string) string {
+	lines := strings.Split(md, "\n")
+	fenced := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if !fenced && strings.HasPrefix(line, "#") {
+			lines[i] = "#" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func ruleCounts(byRule map[string]int) string {
+	rules := make([]string, 0, len(byRule))
+	for rule := range byRule {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	parts := make([]string, len(rules))
+	for i, rule := range rules {
+		parts[i] = fmt.Sprintf("%s: %d", rule, byRule[rule])
+	}
+	return strings.Join(parts, ", ")
+}
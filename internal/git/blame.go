@@ -0,0 +1,114 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NotCommitted is the commit git blame gives lines that are only in the
+// working tree.
+const NotCommitted = "0000000000000000000000000000000000000000"
+
+// BlameLine is a line of a file and the commit that last changed it.
+type BlameLine struct {
+	// Number is the line's number in the blamed file.
+	Number int
+	Text   string
+	Commit string
+	// OrigPath and OrigLine locate the line in the commit, which may have
+	// had the file under another name.
+	OrigPath   string
+	OrigLine   int
+	Author     string
+	AuthorTime time.Time
+	Summary    string
+}
+
+// blameCommit is what git blame tells about a commit the first time it
+// names it.
+type blameCommit struct {
+	author     string
+	authorTime time.Time
+	summary    string
+	path       string
+}
+
+// Blame runs git blame on a file at a revision, or in the working tree
+// when rev is empty. The path is relative to the repository root.
+func (r *Repository) Blame(path, rev string) ([]BlameLine, error) {
+	if strings.HasPrefix(rev, "-") {
+		return nil, fmt.Errorf("invalid revision %q", rev)
+	}
+	args := []string{"blame", "--porcelain"}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	args = append(args, "--", path)
+	out, err := r.run("git", args...)
+	if err != nil {
+		return nil, fmt.Errorf("git blame %s: %w", path, err)
+	}
+	return ParseBlame(out)
+}
+
+// ParseBlame parses the output of git blame --porcelain. Commit details
+// are only given the first time a commit appears, so they are carried over
+// to its later lines.
+func ParseBlame(out string) ([]BlameLine, error) {
+	var lines []BlameLine
+	commits := make(map[string]*blameCommit)
+	var cur *BlameLine
+	var info *blameCommit
+
+	for _, line := range strings.Split(out, "\n") {
+		if cur == nil {
+			if line == "" {
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) < 3 || len(fields[0]) != 40 {
+				return nil, fmt.Errorf("unexpected blame header %q", line)
+			}
+			orig, err1 := strconv.Atoi(fields[1])
+			final, err2 := strconv.Atoi(fields[2])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("unexpected blame header %q", line)
+			}
+			info = commits[fields[0]]
+			if info == nil {
+				info = &blameCommit{}
+				commits[fields[0]] = info
+			}
+			cur = &BlameLine{Number: final, Commit: fields[0], OrigLine: orig}
+			continue
+		}
+
+		if text, ok := strings.CutPrefix(line, "\t"); ok {
+			cur.Text = text
+			cur.OrigPath = info.path
+			cur.Author = info.author
+			cur.AuthorTime = info.authorTime
+			cur.Summary = info.summary
+			lines = append(lines, *cur)
+			cur = nil
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			info.author = value
+		case "author-time":
+			if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+				info.authorTime = time.Unix(secs, 0)
+			}
+		case "summary":
+			info.summary = value
+		case "filename":
+			info.path = value
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,58 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBlame(t *testing.T) {
+	out := "1111111111111111111111111111111111111111 1 1 2\n" +
+		"author Ada\n" +
+		"author-time 1700000000\n" +
+		"summary first\n" +
+		"filename old.go\n" +
+		"\tpackage main\n" +
+		"1111111111111111111111111111111111111111 2 2\n" +
+		"\t\n" +
+		"2222222222222222222222222222222222222222 3 3 1\n" +
+		"author Bob\n" +
+		"summary second\n" +
+		"filename main.go\n" +
+		"\tfunc main() {}\n"
+
+	lines, err := ParseBlame(out)
+	require.NoError(t, err)
+	require.Len(t, lines, 3)
+	assert.Equal(t, BlameLine{Number: 1, Text: "package main", Commit: "1111111111111111111111111111111111111111",
+		OrigPath: "old.go", OrigLine: 1, Author: "Ada", AuthorTime: lines[0].AuthorTime, Summary: "first"}, lines[0])
+	assert.Equal(t, int64(1700000000), lines[0].AuthorTime.Unix())
+	assert.Equal(t, "", lines[1].Text)
+	assert.Equal(t, "old.go", lines[1].OrigPath)
+	assert.Equal(t, "Bob", lines[2].Author)
+	assert.Equal(t, "main.go", lines[2].OrigPath)
+
+	_, err = ParseBlame("garbage\n")
+	assert.Error(t, err)
+}
+
+func TestBlame(t *testing.T) {
+	repo := initTestRepo(t)
+	head, err := repo.HeadCommitHash()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("hello\nworld\n"), 0o644))
+
+	lines, err := repo.Blame("README.md", "")
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, head, lines[0].Commit)
+	assert.Equal(t, "hello", lines[0].Text)
+	assert.Equal(t, NotCommitted, lines[1].Commit)
+
+	lines, err = repo.Blame("README.md", "HEAD")
+	require.NoError(t, err)
+	assert.Len(t, lines, 1)
+}
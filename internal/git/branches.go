@@ -1,13 +1,40 @@
 package git
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 )
 
+// ShadowBranchName returns the shadow branch of a session in a worktree.
+// Characters not allowed in a ref are replaced.
+func ShadowBranchName(sessionID, worktreeID string) string {
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '-'
+	}, sessionID)
+	return fmt.Sprintf("%s%s-%s", ShadowBranchPrefix, clean, worktreeID)
+}
+
+// WorktreeID returns a short ID for the current worktree, so sessions in
+// linked worktrees of the same repository get their own shadow branches.
+func (r *Repository) WorktreeID() (string, error) {
+	out, err := r.run("git", "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(out)))
+	return hex.EncodeToString(sum[:])[:8], nil
+}
+
 // CreateShadowBranch creates a temporary shadow branch for a session.
 func (r *Repository) CreateShadowBranch(sessionID, worktreeID string) (string, error) {
-	name := fmt.Sprintf("entire/%s-%s", sessionID, worktreeID)
+	name := ShadowBranchName(sessionID, worktreeID)
 	_, err := r.run("git", "branch", name)
 	if err != nil {
 		return "", fmt.Errorf("failed to create shadow branch %s: %w", name, err)
@@ -38,3 +65,21 @@ func (r *Repository) HasCheckpointsBranch() bool {
 	_, err := r.run("git", "rev-parse", "--verify", CheckpointsBranch)
 	return err == nil
 }
+
+// LastCheckpointTime returns the commit time of the tip of the checkpoints branch.
+func (r *Repository) LastCheckpointTime() (time.Time, error) {
+	out, err := r.run("git", "log", "-1", "--format=%cI", CheckpointsBranch)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, strings.TrimSpace(out))
+}
+
+// BranchHead returns the commit a branch points to.
+func (r *Repository) BranchHead(branch string) (string, error) {
+	out, err := r.run("git", "rev-parse", "--verify", branch+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -2,6 +2,7 @@ package git
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -58,3 +59,85 @@ func (r *Repository) DiffLinesChanged(commitHash string) (added, removed int, er
 	}
 	return added, removed, nil
 }
+
+// AddedLine is a line added by a commit, numbered as in the committed file.
+type AddedLine struct {
+	Number int
+	Text   string
+}
+
+// AddedLines returns the lines added by a commit, keyed by file path.
+// It handles root commits, which have no parent to diff against.
+func (r *Repository) AddedLines(commitHash string) (map[string][]AddedLine, error) {
+	out, err := r.run("git", "diff-tree", "-p", "--root", "--no-commit-id", "--no-color", "--no-ext-diff", commitHash)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAddedLines(out), nil
+}
+
+// ParseAddedLines extracts the added lines from a unified diff, keyed by the
+// post-image file path. Deleted files are omitted.
+func ParseAddedLines(diff string) map[string][]AddedLine {
+	added := make(map[string][]AddedLine)
+	var file string
+	inHeader := false
+	next := 0
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file = ""
+			inHeader = true
+		case inHeader && strings.HasPrefix(line, "+++ "):
+			path := strings.TrimPrefix(line, "+++ ")
+			if path == "/dev/null" {
+				file = ""
+			} else {
+				file = strings.TrimPrefix(path, "b/")
+				if _, ok := added[file]; !ok {
+					added[file] = nil
+				}
+			}
+		case strings.HasPrefix(line, "@@"):
+			inHeader = false
+			next = hunkStart(line)
+		case inHeader || file == "":
+		case strings.HasPrefix(line, "+"):
+			added[file] = append(added[file], AddedLine{Number: next, Text: line[1:]})
+			next++
+		case strings.HasPrefix(line, " "):
+			next++
+		}
+	}
+	return added
+}
+
+// hunkStart returns the first post-image line number of a hunk header such
+// as "@@ -1,3 +4,5 @@".
+func hunkStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0
+	}
+	start, _, _ := strings.Cut(fields[2][1:], ",")
+	n, _ := strconv.Atoi(start)
+	return n
+}
+
+// ChangedFilesBetween lists the files that differ between two commits,
+// which need not be related.
+func (r *Repository) ChangedFilesBetween(from, to string) ([]string, error) {
+	out, err := r.run("git", "diff", "--name-only", "--no-renames", from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,48 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddedLines(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
+-func old() {}
++func New() {}
++++counter
+ // end
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++first
++
+`
+	added := ParseAddedLines(diff)
+	assert.Equal(t, []AddedLine{{2, "func New() {}"}, {3, "++counter"}}, added["main.go"])
+	assert.Equal(t, []AddedLine{{1, "first"}, {2, ""}}, added["new.txt"])
+	assert.NotContains(t, added, "gone.txt")
+}
+
+func TestAddedLinesRootCommit(t *testing.T) {
+	repo := initTestRepo(t)
+	head, err := repo.HeadCommitHash()
+	assert.NoError(t, err)
+
+	added, err := repo.AddedLines(head)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]AddedLine{"README.md": {{1, "hello"}}}, added)
+}
@@ -1,15 +1,17 @@
 package git
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
 const (
-	CheckpointsBranch = "entire/checkpoints/v1"
+	CheckpointsBranch  = "entire/checkpoints/v1"
 	ShadowBranchPrefix = "entire/"
 )
 
@@ -57,38 +59,29 @@ func (r *Repository) Checkout(branch string, force bool) error {
 }
 
 // EnsureCheckpointsBranch creates the orphan checkpoints branch if it doesn't exist.
+// The branch is created with plumbing commands so the working tree is never touched.
 func (r *Repository) EnsureCheckpointsBranch() error {
-	// Check if branch already exists
-	_, err := r.run("git", "rev-parse", "--verify", CheckpointsBranch)
-	if err == nil {
-		return nil // Already exists
+	if r.HasCheckpointsBranch() {
+		return nil
 	}
 
-	// Create orphan branch with an empty commit
-	currentBranch, _ := r.CurrentBranch()
-
-	_, err = r.run("git", "checkout", "--orphan", CheckpointsBranch)
+	tree, err := r.run("git", "mktree")
 	if err != nil {
-		return fmt.Errorf("failed to create orphan branch: %w", err)
+		return fmt.Errorf("failed to create empty tree: %w", err)
 	}
 
-	// Remove all files from index
-	_ = r.runSilent("git", "rm", "-rf", "--cached", ".")
-	_ = r.runSilent("git", "clean", "-fd")
-
-	// Create initial commit
-	_, err = r.run("git", "commit", "--allow-empty", "--no-verify", "-m", "Initialize entire checkpoints")
+	commit, err := r.run("git", "commit-tree", strings.TrimSpace(tree), "-m", "Initialize entire checkpoints")
 	if err != nil {
-		// Try to go back
-		_, _ = r.run("git", "checkout", currentBranch)
 		return fmt.Errorf("failed to create initial commit: %w", err)
 	}
 
-	// Switch back to original branch
-	if currentBranch != "" && currentBranch != "HEAD" {
-		_, _ = r.run("git", "checkout", currentBranch)
+	// An empty old value makes update-ref fail if the branch appeared meanwhile
+	if _, err := r.run("git", "update-ref", "refs/heads/"+CheckpointsBranch, strings.TrimSpace(commit), ""); err != nil {
+		if r.HasCheckpointsBranch() {
+			return nil
+		}
+		return fmt.Errorf("failed to create orphan branch: %w", err)
 	}
-
 	return nil
 }
 
@@ -125,15 +118,36 @@ func (r *Repository) CheckpointFromCommit(hash string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	for _, line := range strings.Split(out, "\n") {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "Entire-Checkpoint:") {
-			return strings.TrimSpace(strings.TrimPrefix(line, "Entire-Checkpoint:")), nil
-		}
+	if id := ParseCheckpointTrailer(out); id != "" {
+		return id, nil
 	}
 	return "", fmt.Errorf("no checkpoint trailer on commit %s", hash)
 }
 
+// ResolveCommit returns the full hash of the commit a revision names.
+func (r *Repository) ResolveCommit(rev string) (string, error) {
+	if strings.HasPrefix(rev, "-") {
+		return "", fmt.Errorf("invalid revision %q", rev)
+	}
+	out, err := r.run("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown commit %q", rev)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// RevList returns the commits in a revision range such as A..B, newest first.
+func (r *Repository) RevList(revRange string) ([]string, error) {
+	if strings.HasPrefix(revRange, "-") {
+		return nil, fmt.Errorf("invalid revision range %q", revRange)
+	}
+	out, err := r.run("git", "rev-list", revRange)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision range %q: %w", revRange, err)
+	}
+	return strings.Fields(out), nil
+}
+
 // OrphanedShadowBranches returns Entire shadow branches that no longer have active sessions.
 func (r *Repository) OrphanedShadowBranches() ([]string, error) {
 	out, err := r.run("git", "branch", "--list", ShadowBranchPrefix+"*")
@@ -158,41 +172,131 @@ func (r *Repository) DeleteBranch(name string) error {
 	return err
 }
 
+// commitRetries bounds how often CommitOnBranch rebuilds its commit when the
+// branch moves underneath it.
+const commitRetries = 3
+
 // CommitOnBranch creates a commit on the specified branch without changing the working tree.
+// Blobs and trees are written with plumbing against a temporary index, so the
+// user's working tree, index and HEAD are never touched. The branch is only
+// advanced if it still points at the parent the commit was built on.
 func (r *Repository) CommitOnBranch(branch, message string, files map[string][]byte) error {
-	// Save current branch
-	currentBranch, err := r.CurrentBranch()
-	if err != nil {
-		return err
+	return r.ReplaceOnBranch(branch, message, nil, files)
+}
+
+// ReplaceOnBranch is CommitOnBranch with everything under dirs removed from
+// the branch first, so the files written replace those directories whole.
+func (r *Repository) ReplaceOnBranch(branch, message string, dirs []string, files map[string][]byte) error {
+	var err error
+	for attempt := 0; attempt < commitRetries; attempt++ {
+		var moved bool
+		moved, err = r.commitOnBranch(branch, message, dirs, files)
+		if err == nil || !moved {
+			return err
+		}
+	}
+	return err
+}
+
+// commitOnBranch makes one attempt at ReplaceOnBranch. moved reports whether
+// the attempt failed because the branch changed concurrently.
+func (r *Repository) commitOnBranch(branch, message string, dirs []string, files map[string][]byte) (moved bool, err error) {
+	ref := "refs/heads/" + branch
+
+	parent := ""
+	if out, err := r.run("git", "rev-parse", "--verify", "--quiet", ref); err == nil {
+		parent = strings.TrimSpace(out)
 	}
 
-	// Checkout target branch
-	if _, err := r.run("git", "checkout", branch); err != nil {
-		return fmt.Errorf("failed to checkout %s: %w", branch, err)
+	tmpDir, err := os.MkdirTemp("", "open-entire-index-")
+	if err != nil {
+		return false, err
 	}
+	defer os.RemoveAll(tmpDir)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmpDir, "index")}
 
-	// Write files
-	for path, data := range files {
-		fullPath := filepath.Join(r.Dir, path)
-		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
-			_, _ = r.run("git", "checkout", currentBranch)
-			return err
+	if parent != "" {
+		if _, err := r.runWith(nil, env, "git", "read-tree", parent); err != nil {
+			return false, fmt.Errorf("failed to read tree of %s: %w", branch, err)
 		}
-		if err := os.WriteFile(fullPath, data, 0o644); err != nil {
-			_, _ = r.run("git", "checkout", currentBranch)
-			return err
+		for _, dir := range dirs {
+			if _, err := r.runWith(nil, env, "git", "rm", "-r", "-q", "--cached", "--ignore-unmatch", "--", dir); err != nil {
+				return false, fmt.Errorf("failed to remove %s from %s: %w", dir, branch, err)
+			}
 		}
 	}
 
-	// Stage and commit
-	_, _ = r.run("git", "add", "-A")
-	if _, err := r.run("git", "commit", "--no-verify", "-m", message); err != nil {
-		_, _ = r.run("git", "checkout", currentBranch)
+	// Sort paths so the index-info input is deterministic
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var indexInfo strings.Builder
+	for _, path := range paths {
+		blob, err := r.runWith(files[path], nil, "git", "hash-object", "-w", "--stdin")
+		if err != nil {
+			return false, fmt.Errorf("failed to write blob for %s: %w", path, err)
+		}
+		fmt.Fprintf(&indexInfo, "100644 %s\t%s\n", strings.TrimSpace(blob), path)
+	}
+	if _, err := r.runWith([]byte(indexInfo.String()), env, "git", "update-index", "--index-info"); err != nil {
+		return false, fmt.Errorf("failed to update index: %w", err)
+	}
+
+	tree, err := r.runWith(nil, env, "git", "write-tree")
+	if err != nil {
+		return false, fmt.Errorf("failed to write tree: %w", err)
+	}
+
+	args := []string{"commit-tree", strings.TrimSpace(tree), "-m", message}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	commit, err := r.run("git", args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	// Compare-and-swap: only advance the branch if it still points at parent
+	if _, err := r.run("git", "update-ref", "-m", message, ref, strings.TrimSpace(commit), parent); err != nil {
+		return true, fmt.Errorf("failed to update %s: %w", branch, err)
+	}
+	return false, nil
+}
+
+// SquashBranch replaces a branch's history with a single root commit of its
+// current tree, dated like the old tip, and expires the branch's reflog so
+// the old commits are no longer reachable. They are deleted by the next gc.
+func (r *Repository) SquashBranch(branch, message string) error {
+	ref := "refs/heads/" + branch
+
+	out, err := r.run("git", "rev-parse", "--verify", ref)
+	if err != nil {
+		return fmt.Errorf("branch %s not found: %w", branch, err)
+	}
+	tip := strings.TrimSpace(out)
+
+	date, err := r.run("git", "log", "-1", "--format=%cI", tip)
+	if err != nil {
+		return err
+	}
+	env := []string{
+		"GIT_AUTHOR_DATE=" + strings.TrimSpace(date),
+		"GIT_COMMITTER_DATE=" + strings.TrimSpace(date),
+	}
+	commit, err := r.runWith(nil, env, "git", "commit-tree", tip+"^{tree}", "-m", message)
+	if err != nil {
 		return fmt.Errorf("failed to commit: %w", err)
 	}
 
-	// Return to original branch
-	_, _ = r.run("git", "checkout", currentBranch)
+	if _, err := r.run("git", "update-ref", "-m", message, ref, strings.TrimSpace(commit), tip); err != nil {
+		return fmt.Errorf("failed to update %s: %w", branch, err)
+	}
+	if _, err := r.run("git", "reflog", "expire", "--expire=now", "--expire-unreachable=now", ref); err != nil {
+		return fmt.Errorf("failed to expire reflog of %s: %w", branch, err)
+	}
 	return nil
 }
 
@@ -239,6 +343,29 @@ func (r *Repository) LastCommitMessage() (string, error) {
 	return strings.TrimSpace(out), nil
 }
 
+// CommitMessage returns the full message of a specific commit.
+func (r *Repository) CommitMessage(hash string) (string, error) {
+	out, err := r.run("git", "log", "-1", "--format=%B", hash)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// CommitIdentities returns the author and committer names of a commit.
+func (r *Repository) CommitIdentities(hash string) (author, committer string, err error) {
+	out, err := r.run("git", "log", "-1", "--format=%an%n%cn", hash)
+	if err != nil {
+		return "", "", err
+	}
+	lines := strings.SplitN(strings.TrimSpace(out), "\n", 2)
+	author = lines[0]
+	if len(lines) > 1 {
+		committer = lines[1]
+	}
+	return author, committer, nil
+}
+
 // DiffStat returns the diff stat for a commit.
 func (r *Repository) DiffStat(commitHash string) (string, error) {
 	out, err := r.run("git", "diff", "--stat", commitHash+"^", commitHash)
@@ -259,8 +386,17 @@ func (r *Repository) Author() string {
 }
 
 func (r *Repository) run(name string, args ...string) (string, error) {
+	return r.runWith(nil, nil, name, args...)
+}
+
+// runWith runs a command with the given stdin and extra environment variables.
+func (r *Repository) runWith(input []byte, env []string, name string, args ...string) (string, error) {
 	cmd := exec.Command(name, args...)
 	cmd.Dir = r.Dir
+	cmd.Env = append(hookSafeEnv(), env...)
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
 	out, err := cmd.Output()
 	if err != nil {
 		if ee, ok := err.(*exec.ExitError); ok {
@@ -271,10 +407,8 @@ func (r *Repository) run(name string, args ...string) (string, error) {
 	return string(out), nil
 }
 
-func (r *Repository) runSilent(name string, args ...string) error {
-	cmd := exec.Command(name, args...)
-	cmd.Dir = r.Dir
-	cmd.Stdout = nil
-	cmd.Stderr = nil
-	return cmd.Run()
+// hookSafeEnv returns the process environment with Open-Entire's own hooks
+// disabled, so commits we create never re-enter the post-commit hook.
+func hookSafeEnv() []string {
+	return append(os.Environ(), "ENTIRE_ENABLED=false")
 }
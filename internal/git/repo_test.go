@@ -0,0 +1,99 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initTestRepo creates a repository with a single commit on main.
+func initTestRepo(t *testing.T) *Repository {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "tester")
+	t.Setenv("GIT_AUTHOR_EMAIL", "tester@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "tester")
+	t.Setenv("GIT_COMMITTER_EMAIL", "tester@example.com")
+
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\n"), 0o644))
+	gitCmd(t, dir, "add", "README.md")
+	gitCmd(t, dir, "commit", "-q", "-m", "initial")
+
+	repo, err := Open(dir)
+	require.NoError(t, err)
+	return repo
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
+func TestCommitOnBranchLeavesWorkingTreeAlone(t *testing.T) {
+	repo := initTestRepo(t)
+	require.NoError(t, repo.EnsureCheckpointsBranch())
+
+	// Dirty the working tree and index
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("staged\n"), 0o644))
+	gitCmd(t, repo.Dir, "add", "README.md")
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("unstaged\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "untracked.txt"), []byte("new\n"), 0o644))
+
+	headBefore := gitCmd(t, repo.Dir, "rev-parse", "HEAD")
+	statusBefore := gitCmd(t, repo.Dir, "status", "--porcelain")
+
+	err := repo.CommitOnBranch(CheckpointsBranch, "checkpoint one", map[string][]byte{
+		"ab/cdef/metadata.json": []byte(`{"id":"abcdef"}`),
+	})
+	require.NoError(t, err)
+
+	err = repo.CommitOnBranch(CheckpointsBranch, "checkpoint two", map[string][]byte{
+		"12/3456/metadata.json": []byte(`{"id":"123456"}`),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, headBefore, gitCmd(t, repo.Dir, "rev-parse", "HEAD"))
+	assert.Equal(t, "main", gitCmd(t, repo.Dir, "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Equal(t, statusBefore, gitCmd(t, repo.Dir, "status", "--porcelain"))
+
+	data, err := os.ReadFile(filepath.Join(repo.Dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "unstaged\n", string(data))
+
+	// Both checkpoints are on the branch, and nothing from the working tree leaked in
+	files, err := repo.ListFilesOnBranch(CheckpointsBranch, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"12/3456/metadata.json", "ab/cdef/metadata.json"}, files)
+
+	content, err := repo.ReadFileFromBranch(CheckpointsBranch, "ab/cdef/metadata.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"abcdef"}`, string(content))
+
+	count, err := repo.CheckpointCount()
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestEnsureCheckpointsBranchIsOrphan(t *testing.T) {
+	repo := initTestRepo(t)
+	require.NoError(t, repo.EnsureCheckpointsBranch())
+	require.NoError(t, repo.EnsureCheckpointsBranch())
+
+	assert.True(t, repo.HasCheckpointsBranch())
+	parents := gitCmd(t, repo.Dir, "log", "--format=%P", CheckpointsBranch)
+	assert.Equal(t, "", parents)
+
+	files, err := repo.ListFilesOnBranch(CheckpointsBranch, "")
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
@@ -0,0 +1,292 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PurgedTrailer marks the root commit PurgeCheckpointsHistory leaves.
+// History from before a purge still holds the files it dropped, so it is
+// never merged back in.
+const PurgedTrailer = "Entire-Purged-History"
+
+// ErrPurgedHistory is returned when merging checkpoints would join history
+// from before a purge to history after it.
+var ErrPurgedHistory = errors.New("checkpoint history was purged")
+
+// RemoteCheckpointsRef returns the local ref that tracks a remote's
+// checkpoints branch.
+func RemoteCheckpointsRef(remote string) string {
+	return "refs/remotes/" + remoteRefName(remote) + "/" + CheckpointsBranch
+}
+
+// remoteRefName turns a remote name or URL into something usable in a ref.
+func remoteRefName(remote string) string {
+	name := strings.Map(func(c rune) rune {
+		switch c {
+		case ':', '/', '\\', '@', '?', '*', '[', '~', '^', ' ':
+			return '-'
+		}
+		return c
+	}, remote)
+	return strings.TrimLeft(strings.ReplaceAll(name, "..", "-"), ".")
+}
+
+// FetchCheckpoints fetches the remote's checkpoints branch into its tracking
+// ref and returns the fetched commit, or "" if the remote has no checkpoints.
+func (r *Repository) FetchCheckpoints(remote string) (string, error) {
+	branchRef := "refs/heads/" + CheckpointsBranch
+
+	out, err := r.run("git", "ls-remote", "--heads", remote, branchRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to query %s: %w", remote, err)
+	}
+	if strings.TrimSpace(out) == "" {
+		return "", nil
+	}
+
+	tracking := RemoteCheckpointsRef(remote)
+	if _, err := r.run("git", "fetch", "--quiet", "--no-tags", remote, "+"+branchRef+":"+tracking); err != nil {
+		return "", fmt.Errorf("failed to fetch checkpoints from %s: %w", remote, err)
+	}
+
+	commit, err := r.run("git", "rev-parse", "--verify", tracking)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(commit), nil
+}
+
+// MergeCheckpoints merges a commit of another checkpoints branch into the
+// local one. Checkpoint paths are sharded by random ID, so the merge is a
+// union of the two trees; if both sides changed the same file, the local
+// copy wins. It reports whether the local branch moved.
+func (r *Repository) MergeCheckpoints(commit string) (bool, error) {
+	var err error
+	for attempt := 0; attempt < commitRetries; attempt++ {
+		var moved, changed bool
+		changed, moved, err = r.mergeCheckpoints(commit)
+		if err == nil || !moved {
+			return changed, err
+		}
+	}
+	return false, err
+}
+
+// mergeCheckpoints makes one attempt at MergeCheckpoints. moved reports
+// whether the attempt failed because the branch changed concurrently.
+func (r *Repository) mergeCheckpoints(commit string) (changed, moved bool, err error) {
+	ref := "refs/heads/" + CheckpointsBranch
+
+	local := ""
+	if out, err := r.run("git", "rev-parse", "--verify", "--quiet", ref); err == nil {
+		local = strings.TrimSpace(out)
+	}
+
+	switch {
+	case local == "":
+		// First sync: adopt the remote branch as is
+		if _, err := r.run("git", "update-ref", ref, commit, ""); err != nil {
+			return false, true, fmt.Errorf("failed to create %s: %w", CheckpointsBranch, err)
+		}
+		return true, false, nil
+	case local == commit || r.isAncestor(commit, local):
+		return false, false, nil
+	case r.isAncestor(local, commit):
+		if _, err := r.run("git", "update-ref", "-m", "sync checkpoints: fast-forward", ref, commit, local); err != nil {
+			return false, true, fmt.Errorf("failed to update %s: %w", CheckpointsBranch, err)
+		}
+		return true, false, nil
+	}
+
+	if !r.related(local, commit) && (r.purged(local) || r.purged(commit)) {
+		return false, false, fmt.Errorf("%w: refusing to merge unrelated checkpoints history back in; "+
+			"replace the remote's branch with 'open-entire redact --purge-history'", ErrPurgedHistory)
+	}
+
+	tree, err := r.unionTree(local, commit)
+	if err != nil {
+		return false, false, err
+	}
+
+	msg := "sync checkpoints: merge remote checkpoints"
+	merge, err := r.run("git", "commit-tree", tree, "-p", local, "-p", commit, "-m", msg)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to commit merge: %w", err)
+	}
+	if _, err := r.run("git", "update-ref", "-m", msg, ref, strings.TrimSpace(merge), local); err != nil {
+		return false, true, fmt.Errorf("failed to update %s: %w", CheckpointsBranch, err)
+	}
+	return true, false, nil
+}
+
+// unionTree writes a tree holding every file of both commits, preferring
+// the first commit's copy when a path exists in both.
+func (r *Repository) unionTree(ours, theirs string) (string, error) {
+	oursFiles, err := r.treeEntries(ours)
+	if err != nil {
+		return "", err
+	}
+	theirsFiles, err := r.treeEntries(theirs)
+	if err != nil {
+		return "", err
+	}
+
+	for path, entry := range theirsFiles {
+		if own, ok := oursFiles[path]; ok {
+			if own != entry {
+				slog.Warn("checkpoint file differs on both sides, keeping local copy", "path", path)
+			}
+			continue
+		}
+		oursFiles[path] = entry
+	}
+
+	paths := make([]string, 0, len(oursFiles))
+	for path := range oursFiles {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var indexInfo strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&indexInfo, "%s\t%s\n", oursFiles[path], path)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "open-entire-index-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmpDir, "index")}
+
+	if _, err := r.runWith([]byte(indexInfo.String()), env, "git", "update-index", "--index-info"); err != nil {
+		return "", fmt.Errorf("failed to build merged index: %w", err)
+	}
+	tree, err := r.runWith(nil, env, "git", "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("failed to write merged tree: %w", err)
+	}
+	return strings.TrimSpace(tree), nil
+}
+
+// treeEntries maps each file path in a commit to its "<mode> <sha>" entry.
+func (r *Repository) treeEntries(commit string) (map[string]string, error) {
+	out, err := r.run("git", "ls-tree", "-r", "--full-tree", commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tree of %s: %w", commit, err)
+	}
+
+	entries := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		// <mode> SP <type> SP <sha> TAB <path>
+		meta, path, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 {
+			continue
+		}
+		entries[path] = fields[0] + " " + fields[2]
+	}
+	return entries, nil
+}
+
+// related reports whether two commits share any history.
+func (r *Repository) related(a, b string) bool {
+	_, err := r.run("git", "merge-base", a, b)
+	return err == nil
+}
+
+// purged reports whether a commit's history starts at a purge.
+func (r *Repository) purged(commit string) bool {
+	out, err := r.run("git", "log", "--max-parents=0", "--format=%B", commit)
+	return err == nil && strings.Contains(out, PurgedTrailer+":")
+}
+
+func (r *Repository) isAncestor(ancestor, commit string) bool {
+	_, err := r.run("git", "merge-base", "--is-ancestor", ancestor, commit)
+	return err == nil
+}
+
+// PushCheckpoints pushes the checkpoints branch to a remote. If the remote
+// has checkpoints the local branch lacks, they are fetched and merged first
+// and the push is retried.
+func (r *Repository) PushCheckpoints(remote string) error {
+	branchRef := "refs/heads/" + CheckpointsBranch
+
+	var err error
+	for attempt := 0; attempt < commitRetries; attempt++ {
+		if _, err = r.SyncCheckpoints(remote); err != nil {
+			return err
+		}
+		if _, err = r.run("git", "push", "--quiet", "--no-verify", remote, branchRef+":"+branchRef); err == nil {
+			return nil
+		}
+		slog.Debug("checkpoint push rejected, retrying", "remote", remote, "error", err)
+	}
+	return fmt.Errorf("failed to push checkpoints to %s: %w", remote, err)
+}
+
+// SyncCheckpoints fetches a remote's checkpoints and merges them into the
+// local branch. It reports whether the local branch moved.
+func (r *Repository) SyncCheckpoints(remote string) (bool, error) {
+	commit, err := r.FetchCheckpoints(remote)
+	if err != nil || commit == "" {
+		return false, err
+	}
+	changed, err := r.MergeCheckpoints(commit)
+	if errors.Is(err, ErrPurgedHistory) {
+		// Don't let the tracking ref keep purged history reachable
+		if _, derr := r.run("git", "update-ref", "-d", RemoteCheckpointsRef(remote)); derr != nil {
+			slog.Debug("failed to drop tracking ref", "remote", remote, "error", derr)
+		}
+	}
+	return changed, err
+}
+
+// PurgeCheckpointsHistory squashes the checkpoints branch into a single
+// root commit marked with PurgedTrailer and drops the refs tracking remote
+// checkpoints, so no local ref reaches the earlier commits.
+func (r *Repository) PurgeCheckpointsHistory(message string) error {
+	if err := r.SquashBranch(CheckpointsBranch, message+"\n\n"+PurgedTrailer+": true"); err != nil {
+		return err
+	}
+	out, err := r.run("git", "for-each-ref", "--format=%(refname)", "refs/remotes/")
+	if err != nil {
+		return err
+	}
+	for _, ref := range strings.Fields(out) {
+		if strings.HasSuffix(ref, "/"+CheckpointsBranch) {
+			if _, err := r.run("git", "update-ref", "-d", ref); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", ref, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ReplaceRemoteCheckpoints force-pushes the local checkpoints branch over a
+// remote's, as long as the remote's still points at expect, the commit last
+// fetched from it. It is how a purge reaches the remote.
+func (r *Repository) ReplaceRemoteCheckpoints(remote, expect string) error {
+	branchRef := "refs/heads/" + CheckpointsBranch
+	lease := "--force-with-lease=" + branchRef + ":" + expect
+	if _, err := r.run("git", "push", "--quiet", "--no-verify", lease, remote, branchRef+":"+branchRef); err != nil {
+		return fmt.Errorf("failed to replace checkpoints on %s: %w", remote, err)
+	}
+	_, err := r.FetchCheckpoints(remote)
+	return err
+}
+
+// HasRemote reports whether a remote is configured.
+func (r *Repository) HasRemote(name string) bool {
+	_, err := r.run("git", "remote", "get-url", name)
+	return err == nil
+}
@@ -0,0 +1,167 @@
+package git
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cloneTestRepo clones a repository into a new temp dir.
+func cloneTestRepo(t *testing.T, url string) *Repository {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "clone")
+	cmd := exec.Command("git", "clone", "-q", url, dir)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git clone: %s", out)
+
+	repo, err := Open(dir)
+	require.NoError(t, err)
+	return repo
+}
+
+// initBareRemote creates a bare repository and adds it as origin of repo.
+func initBareRemote(t *testing.T, repo *Repository) string {
+	t.Helper()
+	bare := filepath.Join(t.TempDir(), "remote.git")
+	gitCmd(t, repo.Dir, "init", "-q", "--bare", bare)
+	gitCmd(t, repo.Dir, "remote", "add", "origin", bare)
+	gitCmd(t, repo.Dir, "push", "-q", "origin", "main")
+	return bare
+}
+
+func TestSyncCheckpointsMergesTeammates(t *testing.T) {
+	alice := initTestRepo(t)
+	bare := initBareRemote(t, alice)
+	require.NoError(t, alice.EnsureCheckpointsBranch())
+	require.NoError(t, alice.CommitOnBranch(CheckpointsBranch, "checkpoint a1", map[string][]byte{
+		"a1/0000000001/metadata.json": []byte(`{"id":"a10000000001"}`),
+	}))
+	require.NoError(t, alice.PushCheckpoints("origin"))
+
+	// Bob clones and picks up Alice's checkpoints
+	bob := cloneTestRepo(t, bare)
+	assert.False(t, bob.HasCheckpointsBranch())
+	changed, err := bob.SyncCheckpoints("origin")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	data, err := bob.ReadFileFromBranch(CheckpointsBranch, "a1/0000000001/metadata.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"a10000000001"}`, string(data))
+
+	// Both add checkpoints concurrently
+	require.NoError(t, alice.CommitOnBranch(CheckpointsBranch, "checkpoint a2", map[string][]byte{
+		"a2/0000000002/metadata.json": []byte(`{"id":"a20000000002"}`),
+	}))
+	require.NoError(t, bob.CommitOnBranch(CheckpointsBranch, "checkpoint b1", map[string][]byte{
+		"b1/0000000001/metadata.json": []byte(`{"id":"b10000000001"}`),
+	}))
+	require.NoError(t, alice.PushCheckpoints("origin"))
+
+	// Bob's push is not a fast-forward, so it merges first
+	require.NoError(t, bob.PushCheckpoints("origin"))
+	files, err := bob.ListFilesOnBranch(CheckpointsBranch, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"a1/0000000001/metadata.json",
+		"a2/0000000002/metadata.json",
+		"b1/0000000001/metadata.json",
+	}, files)
+	assert.Equal(t,
+		gitCmd(t, bob.Dir, "rev-parse", CheckpointsBranch),
+		gitCmd(t, bob.Dir, "--git-dir", bare, "rev-parse", CheckpointsBranch))
+
+	// Alice syncs and ends up with the same tree
+	changed, err = alice.SyncCheckpoints("origin")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t,
+		gitCmd(t, bob.Dir, "rev-parse", CheckpointsBranch+"^{tree}"),
+		gitCmd(t, alice.Dir, "rev-parse", CheckpointsBranch+"^{tree}"))
+
+	// Nothing new the second time
+	changed, err = alice.SyncCheckpoints("origin")
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestSyncCheckpointsUnrelatedHistories(t *testing.T) {
+	alice := initTestRepo(t)
+	bare := initBareRemote(t, alice)
+	require.NoError(t, alice.EnsureCheckpointsBranch())
+	require.NoError(t, alice.CommitOnBranch(CheckpointsBranch, "checkpoint a1", map[string][]byte{
+		"a1/0000000001/metadata.json": []byte("a"),
+	}))
+	require.NoError(t, alice.PushCheckpoints("origin"))
+
+	// Bob created his own branch before syncing
+	bob := cloneTestRepo(t, bare)
+	require.NoError(t, bob.EnsureCheckpointsBranch())
+	require.NoError(t, bob.CommitOnBranch(CheckpointsBranch, "checkpoint b1", map[string][]byte{
+		"b1/0000000001/metadata.json": []byte("b"),
+	}))
+
+	changed, err := bob.SyncCheckpoints("origin")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	files, err := bob.ListFilesOnBranch(CheckpointsBranch, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a1/0000000001/metadata.json", "b1/0000000001/metadata.json"}, files)
+}
+
+func TestSyncCheckpointsRemoteWithoutBranch(t *testing.T) {
+	repo := initTestRepo(t)
+	initBareRemote(t, repo)
+
+	changed, err := repo.SyncCheckpoints("origin")
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestRemoteCheckpointsRef(t *testing.T) {
+	assert.Equal(t, "refs/remotes/origin/entire/checkpoints/v1", RemoteCheckpointsRef("origin"))
+	assert.Equal(t, "refs/remotes/git-github.com-acme-repo.git/entire/checkpoints/v1", RemoteCheckpointsRef("git@github.com:acme/repo.git"))
+	assert.Equal(t, "refs/remotes/--remote.git/entire/checkpoints/v1", RemoteCheckpointsRef("../remote.git"))
+}
+
+func TestSyncCheckpointsAfterPurge(t *testing.T) {
+	alice := initTestRepo(t)
+	bare := initBareRemote(t, alice)
+	require.NoError(t, alice.EnsureCheckpointsBranch())
+	require.NoError(t, alice.CommitOnBranch(CheckpointsBranch, "checkpoint a1", map[string][]byte{
+		"a1/0000000001/metadata.json": []byte("secret"),
+	}))
+	require.NoError(t, alice.PushCheckpoints("origin"))
+	old, err := alice.BranchHead(CheckpointsBranch)
+	require.NoError(t, err)
+	bob := cloneTestRepo(t, bare)
+	_, err = bob.SyncCheckpoints("origin")
+	require.NoError(t, err)
+
+	require.NoError(t, alice.CommitOnBranch(CheckpointsBranch, "redact a1", map[string][]byte{
+		"a1/0000000001/metadata.json": []byte("[REDACTED]"),
+	}))
+	require.NoError(t, alice.PurgeCheckpointsHistory("squash"))
+
+	// The remote still has the old history, which is never merged back in
+	_, err = alice.SyncCheckpoints("origin")
+	assert.ErrorIs(t, err, ErrPurgedHistory)
+	assert.ErrorIs(t, alice.PushCheckpoints("origin"), ErrPurgedHistory)
+	assert.NotContains(t, gitCmd(t, alice.Dir, "rev-list", "--all"), old)
+
+	expect := gitCmd(t, bare, "rev-parse", "refs/heads/"+CheckpointsBranch)
+	require.NoError(t, alice.ReplaceRemoteCheckpoints("origin", expect))
+	changed, err := alice.SyncCheckpoints("origin")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.NotContains(t, gitCmd(t, bare, "rev-list", "--all"), old)
+
+	// A teammate still on the old history cannot bring it back
+	_, err = bob.SyncCheckpoints("origin")
+	assert.ErrorIs(t, err, ErrPurgedHistory)
+	data, err := bob.ReadFileFromBranch(CheckpointsBranch, "a1/0000000001/metadata.json")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(data))
+}
@@ -2,6 +2,8 @@ package git
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -10,23 +12,13 @@ const (
 	TrailerAttribution = "Entire-Attribution"
 )
 
-// AddTrailer appends an Entire trailer to the last commit message.
-func (r *Repository) AddTrailer(key, value string) error {
-	msg, err := r.LastCommitMessage()
-	if err != nil {
-		return err
-	}
-
-	// Check if trailer already exists
-	if strings.Contains(msg, key+":") {
-		return nil
-	}
-
-	trailer := fmt.Sprintf("%s: %s", key, value)
-
-	// Amend the commit with the trailer
-	newMsg := msg + "\n\n" + trailer
-	_, err = r.run("git", "commit", "--amend", "-m", newMsg)
+// AddMessageTrailer adds a trailer to a commit message file, as the
+// commit-msg hooks see it, with git interpret-trailers: it joins the
+// message's trailer block, or starts one, and is not repeated if the same
+// trailer is already there.
+func (r *Repository) AddMessageTrailer(path, key, value string) error {
+	_, err := r.run("git", "interpret-trailers", "--in-place", "--if-exists", "addIfDifferent",
+		"--trailer", key+": "+value, path)
 	return err
 }
 
@@ -36,12 +28,108 @@ func FormatAttributionTrailer(percent float64, agentLines, totalLines int) strin
 }
 
 // ParseCheckpointTrailer extracts a checkpoint ID from a commit message.
+// A commit that combines others may carry several; the first is returned.
 func ParseCheckpointTrailer(message string) string {
+	if ids := CheckpointTrailers(message); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// CheckpointTrailers returns the checkpoint IDs in a message's trailer
+// block: its last paragraph, when that is not the subject and every line
+// in it is a "Key: value" trailer or the continuation of one. Comment
+// lines and anything below a scissors line are ignored, as git does.
+func CheckpointTrailers(message string) []string {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(line, "# ") && strings.Contains(line, ">8") {
+			break
+		}
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	start := len(lines)
+	for start > 0 && lines[start-1] != "" {
+		start--
+	}
+	// The subject paragraph never holds trailers
+	if start == 0 || start == len(lines) {
+		return nil
+	}
+
+	var ids []string
+	for i, line := range lines[start:] {
+		if i > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			continue
+		}
+		// git counts the line cherry-pick -x adds as part of the block
+		if strings.HasPrefix(line, "(cherry picked from commit ") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || !isTrailerKey(key) {
+			return nil
+		}
+		if strings.EqualFold(strings.TrimSpace(key), TrailerCheckpoint) {
+			if id := strings.TrimSpace(value); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// MentionedCheckpoints returns every checkpoint trailer line anywhere in a
+// message, trailer block or not, in order and without repeats. Messages
+// that combine commits, such as squashes, hold their trailers in the body.
+func MentionedCheckpoints(message string) []string {
+	var ids []string
+	seen := make(map[string]bool)
 	for _, line := range strings.Split(message, "\n") {
 		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, TrailerCheckpoint+":") {
-			return strings.TrimSpace(strings.TrimPrefix(line, TrailerCheckpoint+":"))
+		if rest, ok := strings.CutPrefix(line, TrailerCheckpoint+":"); ok {
+			if id := strings.TrimSpace(rest); id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
 		}
 	}
-	return ""
+	return ids
+}
+
+func isTrailerKey(key string) bool {
+	key = strings.TrimRight(key, " ")
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if !(c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Rewriting reports whether a rebase, cherry-pick or revert is in progress,
+// whose commits copy existing ones rather than record new work.
+func (r *Repository) Rewriting() bool {
+	gitDir, err := r.run("git", "rev-parse", "--git-dir")
+	if err != nil {
+		return false
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(r.Dir, gitDir)
+	}
+	for _, name := range []string{"rebase-merge", "rebase-apply", "CHERRY_PICK_HEAD", "REVERT_HEAD"} {
+		if _, err := os.Stat(filepath.Join(gitDir, name)); err == nil {
+			return true
+		}
+	}
+	return false
 }
@@ -1,9 +1,12 @@
 package git
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFormatAttributionTrailer(t *testing.T) {
@@ -28,3 +31,43 @@ func TestParseCheckpointTrailerNotFound(t *testing.T) {
 	id := ParseCheckpointTrailer(msg)
 	assert.Equal(t, "", id)
 }
+
+func TestCheckpointTrailers(t *testing.T) {
+	msg := "fix: thing\n\nEntire-Checkpoint: aabbccddeeff\n\nMore text.\n\n" +
+		"Signed-off-by: A <a@b>\nEntire-Checkpoint: 112233445566\n  continued\n" +
+		"# Please enter the commit message\n"
+	assert.Equal(t, []string{"112233445566"}, CheckpointTrailers(msg))
+	assert.Equal(t, []string{"aabbccddeeff", "112233445566"}, MentionedCheckpoints(msg))
+
+	// The subject is never a trailer, nor is a paragraph of prose
+	assert.Empty(t, CheckpointTrailers("Entire-Checkpoint: aabbccddeeff"))
+	assert.Empty(t, CheckpointTrailers("fix\n\nEntire-Checkpoint: aabbccddeeff\nsee above"))
+
+	// Nothing below the scissors line counts
+	scissors := "fix\n\nEntire-Checkpoint: aabbccddeeff\n" +
+		"# ------------------------ >8 ------------------------\n\nEntire-Checkpoint: 112233445566\n"
+	assert.Equal(t, []string{"aabbccddeeff"}, CheckpointTrailers(scissors))
+
+	// cherry-pick -x notes the source commit inside the block
+	picked := "fix\n\nEntire-Checkpoint: aabbccddeeff\n(cherry picked from commit 0123456789abcdef)\n"
+	assert.Equal(t, []string{"aabbccddeeff"}, CheckpointTrailers(picked))
+}
+
+func TestAddMessageTrailer(t *testing.T) {
+	repo := initTestRepo(t)
+	path := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	require.NoError(t, os.WriteFile(path, []byte("fix bug\n\nSigned-off-by: A <a@b>\n"), 0o644))
+
+	require.NoError(t, repo.AddMessageTrailer(path, TrailerCheckpoint, "aabbccddeeff"))
+	require.NoError(t, repo.AddMessageTrailer(path, TrailerCheckpoint, "aabbccddeeff"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "fix bug\n\nSigned-off-by: A <a@b>\nEntire-Checkpoint: aabbccddeeff\n", string(data))
+}
+
+func TestRewriting(t *testing.T) {
+	repo := initTestRepo(t)
+	assert.False(t, repo.Rewriting())
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, ".git", "CHERRY_PICK_HEAD"), []byte("x\n"), 0o644))
+	assert.True(t, repo.Rewriting())
+}
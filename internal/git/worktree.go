@@ -0,0 +1,375 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RecoveryRefPrefix is where snapshots of uncommitted work are kept before
+// an operation that would discard them.
+const RecoveryRefPrefix = "refs/entire/recovery/"
+
+// SnapshotRefPrefix is where the snapshots condensed into a checkpoint are
+// kept reachable, under the checkpoint's ID.
+const SnapshotRefPrefix = "refs/entire/snapshots/"
+
+// excludeState keeps Open-Entire's own state directory out of snapshots and
+// restores, so rewinding never rolls back session tracking.
+const excludeState = ":(top,exclude).open-entire"
+
+// WorktreeStatus lists the uncommitted changes in the working tree.
+type WorktreeStatus struct {
+	// Changed are tracked paths that differ from HEAD, staged or not.
+	Changed []string
+	// Untracked are new files that are not ignored.
+	Untracked []string
+}
+
+// Clean reports whether there is nothing uncommitted.
+func (s *WorktreeStatus) Clean() bool {
+	return len(s.Changed) == 0 && len(s.Untracked) == 0
+}
+
+// Status returns the uncommitted changes in the working tree.
+func (r *Repository) Status() (*WorktreeStatus, error) {
+	out, err := r.run("git", "status", "--porcelain", "-z", "--untracked-files=all", "--", ":/", excludeState)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status: %w", err)
+	}
+
+	status := &WorktreeStatus{}
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		code, path := entry[:2], entry[3:]
+		if code == "??" {
+			status.Untracked = append(status.Untracked, path)
+			continue
+		}
+		status.Changed = append(status.Changed, path)
+		// Renames and copies are followed by their source path
+		if code[0] == 'R' || code[0] == 'C' {
+			i++
+			if i < len(entries) && entries[i] != "" {
+				status.Changed = append(status.Changed, entries[i])
+			}
+		}
+	}
+	return status, nil
+}
+
+// SnapshotWorktree commits the working tree as it is, untracked files
+// included, and returns the commit. It stages into a temporary copy of the
+// index, so the user's index, HEAD and branches are left alone; no ref
+// points at the commit until the caller creates one.
+func (r *Repository) SnapshotWorktree(message string, parents ...string) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "open-entire-index-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	tmpIndex := filepath.Join(tmpDir, "index")
+
+	// Starting from the real index keeps its stat cache, so unchanged files
+	// are not hashed again
+	indexPath, err := r.run("git", "rev-parse", "--git-path", "index")
+	if err != nil {
+		return "", err
+	}
+	indexPath = strings.TrimSpace(indexPath)
+	if !filepath.IsAbs(indexPath) {
+		indexPath = filepath.Join(r.Dir, indexPath)
+	}
+	if data, err := os.ReadFile(indexPath); err == nil {
+		if err := os.WriteFile(tmpIndex, data, 0o644); err != nil {
+			return "", err
+		}
+	}
+
+	env := []string{"GIT_INDEX_FILE=" + tmpIndex}
+	if _, err := r.runWith(nil, env, "git", "add", "-A", "--", ":/", excludeState); err != nil {
+		return "", fmt.Errorf("failed to stage working tree: %w", err)
+	}
+	tree, err := r.runWith(nil, env, "git", "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("failed to write tree: %w", err)
+	}
+
+	args := []string{"commit-tree", strings.TrimSpace(tree), "-m", message}
+	for _, p := range parents {
+		args = append(args, "-p", p)
+	}
+	commit, err := r.run("git", args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to commit snapshot: %w", err)
+	}
+	return strings.TrimSpace(commit), nil
+}
+
+// SnapshotToBranch snapshots the working tree as a new commit on a shadow
+// branch, starting the branch at HEAD if it does not exist yet. Nothing is
+// committed when the tree matches the branch tip, in which case the
+// returned commit is empty.
+func (r *Repository) SnapshotToBranch(branch, message string) (string, error) {
+	ref := "refs/heads/" + branch
+	parent := ""
+	if out, err := r.run("git", "rev-parse", "--verify", "--quiet", ref); err == nil {
+		parent = strings.TrimSpace(out)
+	}
+	base := parent
+	if base == "" {
+		head, err := r.HeadCommitHash()
+		if err != nil {
+			return "", err
+		}
+		base = head
+	}
+
+	commit, err := r.SnapshotWorktree(message, base)
+	if err != nil {
+		return "", err
+	}
+	trees, err := r.run("git", "rev-parse", commit+"^{tree}", base+"^{tree}")
+	if err != nil {
+		return "", err
+	}
+	if t := strings.Fields(trees); len(t) == 2 && t[0] == t[1] {
+		return "", nil
+	}
+
+	// Compare-and-swap, like CommitOnBranch; an empty parent means the
+	// branch must not exist yet
+	if _, err := r.run("git", "update-ref", "-m", message, ref, commit, parent); err != nil {
+		return "", fmt.Errorf("failed to update %s: %w", branch, err)
+	}
+	return commit, nil
+}
+
+// SnapshotCommit is one snapshot on a shadow branch.
+type SnapshotCommit struct {
+	Hash string
+	Time time.Time
+	// Files are the paths changed since the previous snapshot.
+	Files []string
+}
+
+// SnapshotCommits returns the snapshots on a shadow branch that are not
+// part of HEAD's history, oldest first.
+func (r *Repository) SnapshotCommits(branch string) ([]SnapshotCommit, error) {
+	out, err := r.run("git", "-c", "core.quotePath=false", "log", "--first-parent", "--reverse",
+		"--format=%x01%H %cI", "--name-only", "refs/heads/"+branch, "--not", "HEAD", "--")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []SnapshotCommit
+	for _, entry := range strings.Split(out, "\x01") {
+		lines := strings.Split(strings.TrimSpace(entry), "\n")
+		fields := strings.Fields(lines[0])
+		if len(fields) != 2 {
+			continue
+		}
+		c := SnapshotCommit{Hash: fields[0]}
+		c.Time, _ = time.Parse(time.RFC3339, fields[1])
+		for _, line := range lines[1:] {
+			if line = strings.TrimSpace(line); line != "" {
+				c.Files = append(c.Files, line)
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// SetRef points a ref at a commit, creating it if needed.
+func (r *Repository) SetRef(ref, commit string) error {
+	if _, err := r.run("git", "update-ref", ref, commit); err != nil {
+		return fmt.Errorf("failed to update %s: %w", ref, err)
+	}
+	return nil
+}
+
+// SaveRecovery snapshots the uncommitted work onto a new recovery ref and
+// returns the ref.
+func (r *Repository) SaveRecovery(message string) (string, error) {
+	head, err := r.HeadCommitHash()
+	if err != nil {
+		return "", err
+	}
+	commit, err := r.SnapshotWorktree(message, head)
+	if err != nil {
+		return "", err
+	}
+
+	// An empty old value never overwrites an earlier recovery
+	stamp := time.Now().UTC().Format("20060102-150405")
+	ref := RecoveryRefPrefix + stamp
+	for n := 2; ; n++ {
+		_, err := r.run("git", "update-ref", "-m", message, ref, commit, "")
+		if err == nil {
+			return ref, nil
+		}
+		if n > 10 {
+			return "", fmt.Errorf("failed to create recovery ref: %w", err)
+		}
+		ref = fmt.Sprintf("%s%s-%d", RecoveryRefPrefix, stamp, n)
+	}
+}
+
+// RecoveryRefs lists the recovery refs, oldest first.
+func (r *Repository) RecoveryRefs() ([]string, error) {
+	out, err := r.run("git", "for-each-ref", "--sort=creatordate", "--format=%(refname)", RecoveryRefPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// RestorePreview describes what restoring the working tree to a commit
+// would change.
+type RestorePreview struct {
+	// DiffStat summarises the changes from the working tree to the commit.
+	DiffStat string
+	// Overwritten are uncommitted changes the restore would replace.
+	Overwritten []string
+	Status      *WorktreeStatus
+}
+
+// PreviewRestore reports what restoring the working tree to a commit would
+// change, without changing anything.
+func (r *Repository) PreviewRestore(commit string) (*RestorePreview, error) {
+	status, err := r.Status()
+	if err != nil {
+		return nil, err
+	}
+	stat, err := r.run("git", "diff", "--stat", "-R", commit, "--", ":/", excludeState)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff against %s: %w", commit, err)
+	}
+	preview := &RestorePreview{DiffStat: strings.TrimRight(stat, "\n"), Status: status}
+	if status.Clean() {
+		return preview, nil
+	}
+
+	differs, err := r.pathSet("diff", "-z", "--name-only", "--no-renames", commit, "--", ":/", excludeState)
+	if err != nil {
+		return nil, err
+	}
+	inCommit, err := r.pathSet("ls-tree", "-z", "-r", "--name-only", "--full-tree", commit)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range status.Changed {
+		if differs[p] {
+			preview.Overwritten = append(preview.Overwritten, p)
+		}
+	}
+	for _, p := range status.Untracked {
+		if inCommit[p] {
+			preview.Overwritten = append(preview.Overwritten, p)
+		}
+	}
+	sort.Strings(preview.Overwritten)
+	return preview, nil
+}
+
+// pathSet runs a git command that lists NUL-separated paths.
+func (r *Repository) pathSet(args ...string) (map[string]bool, error) {
+	out, err := r.run("git", args...)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool)
+	for _, line := range strings.Split(out, "\x00") {
+		if line != "" {
+			set[line] = true
+		}
+	}
+	return set, nil
+}
+
+// RestoreFiles makes the working tree and index match a commit, leaving
+// HEAD and the current branch where they are.
+func (r *Repository) RestoreFiles(commit string) error {
+	if _, err := r.run("git", "restore", "--source="+commit, "--staged", "--worktree", "--", ":/", excludeState); err != nil {
+		return fmt.Errorf("failed to restore files from %s: %w", commit, err)
+	}
+	return nil
+}
+
+// RestoreSnapshot makes the working tree match a snapshot from
+// SnapshotWorktree, leaving HEAD and the current branch where they are.
+// The index is restored only for paths it already tracks: a snapshot also
+// holds the files that were untracked when it was taken, and those are
+// written back untracked rather than staged.
+func (r *Repository) RestoreSnapshot(commit string) error {
+	tracked, err := r.indexPaths()
+	if err != nil {
+		return err
+	}
+	if err := r.RestoreFiles(commit); err != nil {
+		return err
+	}
+	restored, err := r.indexPaths()
+	if err != nil {
+		return err
+	}
+
+	var unstage strings.Builder
+	for path := range restored {
+		if !tracked[path] {
+			unstage.WriteString(path + "\x00")
+		}
+	}
+	if unstage.Len() == 0 {
+		return nil
+	}
+	if _, err := r.runWith([]byte(unstage.String()), nil, "git", "update-index", "-z", "--force-remove", "--stdin"); err != nil {
+		return fmt.Errorf("failed to unstage untracked files of %s: %w", commit, err)
+	}
+	return nil
+}
+
+// indexPaths returns the paths in the index, relative to the top of the
+// working tree.
+func (r *Repository) indexPaths() (map[string]bool, error) {
+	out, err := r.run("git", "ls-files", "-z", "--full-name", "--", ":/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the index: %w", err)
+	}
+	paths := make(map[string]bool)
+	for _, path := range strings.Split(out, "\x00") {
+		if path != "" {
+			paths[path] = true
+		}
+	}
+	return paths, nil
+}
+
+// SwitchNewBranch creates a branch at a commit and switches to it,
+// discarding uncommitted changes.
+func (r *Repository) SwitchNewBranch(name, commit string) error {
+	if _, err := r.run("git", "check-ref-format", "--branch", name); err != nil {
+		return fmt.Errorf("invalid branch name %q", name)
+	}
+	if _, err := r.run("git", "switch", "--force", "--no-guess", "-c", name, commit); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// ResetHard moves the current branch to a commit, discarding uncommitted
+// changes.
+func (r *Repository) ResetHard(commit string) error {
+	if _, err := r.run("git", "reset", "--hard", "--quiet", commit); err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", commit, err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotWorktreeLeavesIndexAlone(t *testing.T) {
+	repo := initTestRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("changed\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "new.txt"), []byte("new\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo.Dir, ".open-entire"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, ".open-entire", "state.json"), []byte("{}"), 0o644))
+
+	head := gitCmd(t, repo.Dir, "rev-parse", "HEAD")
+	statusBefore := gitCmd(t, repo.Dir, "status", "--porcelain")
+
+	commit, err := repo.SnapshotWorktree("snapshot", head)
+	require.NoError(t, err)
+
+	assert.Equal(t, head, gitCmd(t, repo.Dir, "rev-parse", "HEAD"))
+	assert.Equal(t, statusBefore, gitCmd(t, repo.Dir, "status", "--porcelain"))
+	assert.Equal(t, head, gitCmd(t, repo.Dir, "rev-parse", commit+"^"))
+	assert.Equal(t, "changed", gitCmd(t, repo.Dir, "show", commit+":README.md"))
+	assert.Equal(t, "new", gitCmd(t, repo.Dir, "show", commit+":new.txt"))
+	assert.Equal(t, "README.md\nnew.txt", gitCmd(t, repo.Dir, "ls-tree", "-r", "--name-only", commit))
+}
+
+func TestPreviewAndRestoreFiles(t *testing.T) {
+	repo := initTestRepo(t)
+	target := gitCmd(t, repo.Dir, "rev-parse", "HEAD")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("second\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "other.txt"), []byte("other\n"), 0o644))
+	gitCmd(t, repo.Dir, "add", "-A")
+	gitCmd(t, repo.Dir, "commit", "-q", "-m", "second")
+	head := gitCmd(t, repo.Dir, "rev-parse", "HEAD")
+
+	// Dirty one file the rewind changes and one it does not
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("dirty\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "scratch.txt"), []byte("scratch\n"), 0o644))
+
+	preview, err := repo.PreviewRestore(target)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md"}, preview.Overwritten)
+	assert.Equal(t, []string{"README.md"}, preview.Status.Changed)
+	assert.Equal(t, []string{"scratch.txt"}, preview.Status.Untracked)
+	assert.Contains(t, preview.DiffStat, "README.md")
+	assert.Contains(t, preview.DiffStat, "other.txt")
+
+	ref, err := repo.SaveRecovery("before rewind")
+	require.NoError(t, err)
+	refs, err := repo.RecoveryRefs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{ref}, refs)
+
+	require.NoError(t, repo.RestoreFiles(target))
+	assert.Equal(t, head, gitCmd(t, repo.Dir, "rev-parse", "HEAD"))
+	data, err := os.ReadFile(filepath.Join(repo.Dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(data))
+	assert.NoFileExists(t, filepath.Join(repo.Dir, "other.txt"))
+	assert.FileExists(t, filepath.Join(repo.Dir, "scratch.txt"))
+
+	// The recovery ref holds the dirty state
+	assert.Equal(t, "dirty", gitCmd(t, repo.Dir, "show", ref+":README.md"))
+	assert.Equal(t, "scratch", gitCmd(t, repo.Dir, "show", ref+":scratch.txt"))
+}
+
+func TestRestoreSnapshotLeavesUntrackedFilesUnstaged(t *testing.T) {
+	repo := initTestRepo(t)
+	head := gitCmd(t, repo.Dir, "rev-parse", "HEAD")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("turn\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "new.txt"), []byte("new\n"), 0o644))
+	snap, err := repo.SnapshotWorktree("snapshot", head)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(filepath.Join(repo.Dir, "new.txt")))
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("later\n"), 0o644))
+
+	require.NoError(t, repo.RestoreSnapshot(snap))
+	assert.Equal(t, head, gitCmd(t, repo.Dir, "rev-parse", "HEAD"))
+	data, err := os.ReadFile(filepath.Join(repo.Dir, "new.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", string(data))
+	// Tracked files are restored in the index too; untracked ones stay so
+	assert.Equal(t, "M  README.md\n?? new.txt", gitCmd(t, repo.Dir, "status", "--porcelain"))
+}
+
+func TestSnapshotToBranch(t *testing.T) {
+	repo := initTestRepo(t)
+	branch := ShadowBranchName("sess/1", "abcd1234")
+	assert.Equal(t, "entire/sess-1-abcd1234", branch)
+
+	// Nothing to snapshot while the tree matches HEAD
+	commit, err := repo.SnapshotToBranch(branch, "turn")
+	require.NoError(t, err)
+	assert.Empty(t, commit)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "one.txt"), []byte("1\n"), 0o644))
+	first, err := repo.SnapshotToBranch(branch, "turn 1")
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+
+	commit, err = repo.SnapshotToBranch(branch, "turn 2")
+	require.NoError(t, err)
+	assert.Empty(t, commit, "unchanged tree")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("edited\n"), 0o644))
+	second, err := repo.SnapshotToBranch(branch, "turn 3")
+	require.NoError(t, err)
+
+	commits, err := repo.SnapshotCommits(branch)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, first, commits[0].Hash)
+	assert.Equal(t, []string{"one.txt"}, commits[0].Files)
+	assert.Equal(t, second, commits[1].Hash)
+	assert.Equal(t, []string{"README.md"}, commits[1].Files)
+	assert.False(t, commits[0].Time.IsZero())
+
+	// The user's index and status are untouched
+	assert.Equal(t, "M README.md\n?? one.txt", gitCmd(t, repo.Dir, "status", "--porcelain"))
+
+	// Once the work is committed, the snapshots are still listed
+	gitCmd(t, repo.Dir, "add", "-A")
+	gitCmd(t, repo.Dir, "commit", "-q", "-m", "work")
+	commits, err = repo.SnapshotCommits(branch)
+	require.NoError(t, err)
+	assert.Len(t, commits, 2)
+}
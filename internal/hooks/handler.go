@@ -1,11 +1,19 @@
 package hooks
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"strings"
 
+	"github.com/yibudak/open-entire/internal/agent"
+	"github.com/yibudak/open-entire/internal/checkpoint"
 	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/session"
 	"github.com/yibudak/open-entire/internal/strategy"
 )
 
@@ -25,8 +33,134 @@ func NewHandler(repoDir string, cfg *config.Config, strat strategy.Strategy) *Ha
 	}
 }
 
-// HandlePostCommit handles the post-commit hook event.
-func (h *Handler) HandlePostCommit(ctx context.Context) error {
+// HandlePrepareCommitMsg handles the prepare-commit-msg hook event. args
+// are the hook arguments: the message file, and the message's source and
+// commit when git gives them.
+//
+// A commit that records new agent work gets a checkpoint ID reserved and
+// its Entire-Checkpoint trailer added. A message that already names
+// checkpoints, as amends, -c/-C reuses, squashes and rebased commits do,
+// keeps them, gathered into its trailer block, and gets no new one. Merges
+// and rewrites such as rebase, cherry-pick and revert never reserve one;
+// agent work from before them goes to the next commit.
+//
+// Under auto-commit every agent response is checkpointed as it ends, so a
+// commit between turns has no pending work and gets no trailer; its work is
+// found through the auto checkpoints. Only a commit made during a turn, as
+// when the agent commits, gets one.
+func (h *Handler) HandlePrepareCommitMsg(ctx context.Context, args []string) error {
+	if !h.cfg.Enabled {
+		slog.Debug("entire is disabled, skipping prepare-commit-msg")
+		return nil
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("prepare-commit-msg: missing message file")
+	}
+	msgFile := args[0]
+	var source string
+	if len(args) > 1 {
+		source = args[1]
+	}
+
+	repo, err := git.Open(h.repoDir)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(msgFile)
+	if err != nil {
+		return err
+	}
+	message := string(data)
+
+	if mentioned := git.MentionedCheckpoints(message); len(mentioned) > 0 {
+		return addCheckpointTrailers(repo, msgFile, message, mentioned)
+	}
+	if source == "merge" || source == "squash" || repo.Rewriting() {
+		slog.Debug("commit copies other commits, not reserving a checkpoint", "source", source)
+		return nil
+	}
+	if !repo.HasCheckpointsBranch() || !strategy.HasPendingSessions(repo) {
+		return nil
+	}
+
+	reservations, err := checkpoint.LoadReservations(h.repoDir)
+	if err != nil {
+		return err
+	}
+	id, err := reservations.Reserve()
+	if err != nil {
+		return fmt.Errorf("failed to reserve checkpoint: %w", err)
+	}
+	slog.Debug("reserved checkpoint", "checkpoint", id)
+	return repo.AddMessageTrailer(msgFile, git.TrailerCheckpoint, id)
+}
+
+// HandleCommitMsg handles the commit-msg hook event, once the message has
+// been edited. A reserved checkpoint still named in the message is put back
+// in the trailer block if editing moved it out; one deleted from the
+// message is left to expire, and its work goes to the next commit.
+func (h *Handler) HandleCommitMsg(ctx context.Context, args []string) error {
+	if !h.cfg.Enabled {
+		return nil
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("commit-msg: missing message file")
+	}
+	msgFile := args[0]
+
+	data, err := os.ReadFile(msgFile)
+	if err != nil {
+		return err
+	}
+	message := string(data)
+	reservations, err := checkpoint.LoadReservations(h.repoDir)
+	if err != nil {
+		return err
+	}
+	var reserved []string
+	for _, id := range git.MentionedCheckpoints(message) {
+		if reservations.Reserved(id) {
+			reserved = append(reserved, id)
+		}
+	}
+	if len(reserved) == 0 {
+		return nil
+	}
+
+	repo, err := git.Open(h.repoDir)
+	if err != nil {
+		return err
+	}
+	return addCheckpointTrailers(repo, msgFile, message, reserved)
+}
+
+// addCheckpointTrailers adds the checkpoints a message names outside its
+// trailer block to it.
+func addCheckpointTrailers(repo *git.Repository, msgFile, message string, ids []string) error {
+	present := make(map[string]bool)
+	for _, id := range git.CheckpointTrailers(message) {
+		present[id] = true
+	}
+	for _, id := range ids {
+		if present[id] {
+			continue
+		}
+		if err := repo.AddMessageTrailer(msgFile, git.TrailerCheckpoint, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandlePostCommit handles the post-commit hook event. args may give the
+// commit, since the hook runs in the background and HEAD can move on.
+//
+// The checkpoint is created under the ID reserved in the commit's trailer,
+// with the commit's real hash. Commits whose trailers only name existing
+// checkpoints get none. Commits without a trailer get one only when the
+// prepare-commit-msg hook is not installed, as with hooks from older
+// versions, and then without a trailer.
+func (h *Handler) HandlePostCommit(ctx context.Context, args []string) error {
 	if !h.cfg.Enabled {
 		slog.Debug("entire is disabled, skipping post-commit")
 		return nil
@@ -36,6 +170,46 @@ func (h *Handler) HandlePostCommit(ctx context.Context) error {
 		RepoDir: h.repoDir,
 	}
 
+	repo, err := git.Open(h.repoDir)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 && args[0] != "" {
+		event.CommitHash = args[0]
+	} else {
+		event.CommitHash, _ = repo.HeadCommitHash()
+	}
+	event.Message, _ = repo.CommitMessage(event.CommitHash)
+	event.Branch, _ = repo.CurrentBranch()
+
+	ids := git.CheckpointTrailers(event.Message)
+	reservations, err := checkpoint.LoadReservations(h.repoDir)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		claimed, err := reservations.Claim(id)
+		if err != nil {
+			return err
+		}
+		if claimed {
+			event.CheckpointID = id
+			break
+		}
+	}
+
+	switch {
+	case event.CheckpointID != "":
+	case len(ids) > 0:
+		slog.Debug("commit names existing checkpoints, skipping", "checkpoints", ids)
+		return nil
+	case hookInstalled(h.repoDir, "prepare-commit-msg"):
+		slog.Debug("no checkpoint reserved for commit, skipping")
+		return nil
+	default:
+		slog.Info("checkpoint has no commit trailer; run 'open-entire enable' to install the prepare-commit-msg hook")
+	}
+
 	if err := h.strategy.OnCommit(ctx, event); err != nil {
 		return fmt.Errorf("strategy post-commit failed: %w", err)
 	}
@@ -43,8 +217,99 @@ func (h *Handler) HandlePostCommit(ctx context.Context) error {
 	return nil
 }
 
+// HandleAgentHook handles a session event reported by an agent's own hooks.
+// Sessions are tracked from the exact ID, transcript path and process the
+// agent gives. A prompt makes the session active; the end of a turn makes
+// it idle and is passed to the strategy.
+func (h *Handler) HandleAgentHook(ctx context.Context, agentName string, event *agent.HookEvent) error {
+	if !h.cfg.Enabled {
+		slog.Debug("entire is disabled, skipping agent hook", "event", event.Name)
+		return nil
+	}
+
+	sessions, err := session.NewStore(h.repoDir)
+	if err != nil {
+		return err
+	}
+	if event.Name == agent.HookSessionEnd {
+		return sessions.EndSession(event.SessionID)
+	}
+
+	if err := sessions.StartSession(event.SessionID, agentName); err != nil {
+		return err
+	}
+	if event.TranscriptPath != "" {
+		if err := sessions.SetTranscriptPath(event.SessionID, event.TranscriptPath); err != nil {
+			return err
+		}
+	}
+	// The hook runs as a child of the agent, maybe through a shell
+	if p, err := session.AgentProcess(os.Getppid()); err == nil {
+		if err := sessions.SetProcess(event.SessionID, p); err != nil {
+			return err
+		}
+	} else {
+		slog.Debug("agent process unknown", "error", err)
+	}
+	switch event.Name {
+	case agent.HookSessionStart:
+		// A new or resumed session waits for its first prompt
+		return sessions.IdleSession(event.SessionID)
+	case agent.HookStop:
+		if err := sessions.IdleSession(event.SessionID); err != nil {
+			return err
+		}
+		return h.strategy.OnAgentResponse(ctx, &strategy.AgentResponseEvent{
+			RepoDir:        h.repoDir,
+			SessionID:      event.SessionID,
+			AgentName:      agentName,
+			TranscriptPath: event.TranscriptPath,
+		})
+	}
+	return nil
+}
+
+// HandlePostRewrite handles the post-rewrite hook event. args give what
+// rewrote the commits, amend or rebase, and stdin lists them as old and new
+// hash pairs. Checkpoints of rewritten commits are pointed at their
+// replacements, so either hash finds them.
+func (h *Handler) HandlePostRewrite(ctx context.Context, args []string, stdin io.Reader) error {
+	if !h.cfg.Enabled || stdin == nil {
+		return nil
+	}
+
+	rewrites, err := ParseRewrites(stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read post-rewrite commits: %w", err)
+	}
+	if len(rewrites) == 0 {
+		return nil
+	}
+
+	repo, err := git.Open(h.repoDir)
+	if err != nil {
+		return err
+	}
+	if !repo.HasCheckpointsBranch() {
+		return nil
+	}
+	changed, err := checkpoint.NewStore(repo).RecordRewrites(rewrites)
+	if err != nil {
+		return err
+	}
+	if changed > 0 {
+		var cause string
+		if len(args) > 0 {
+			cause = args[0]
+		}
+		slog.Info("recorded rewritten commits", "by", cause, "checkpoints", changed)
+	}
+	return nil
+}
+
 // HandlePrePush handles the pre-push hook event.
-func (h *Handler) HandlePrePush(ctx context.Context) error {
+// args are the hook arguments (remote name and URL) and stdin carries the ref list.
+func (h *Handler) HandlePrePush(ctx context.Context, args []string, stdin io.Reader) error {
 	if !h.cfg.Enabled {
 		return nil
 	}
@@ -52,6 +317,66 @@ func (h *Handler) HandlePrePush(ctx context.Context) error {
 	event := &strategy.PushEvent{
 		RepoDir: h.repoDir,
 	}
+	if len(args) > 0 {
+		event.Remote = args[0]
+	}
+	if len(args) > 1 {
+		event.URL = args[1]
+	}
+
+	if stdin != nil {
+		refs, err := ParsePushRefs(stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read pre-push refs: %w", err)
+		}
+		event.Refs = refs
+	}
+	if len(event.Refs) > 0 {
+		event.Branch = strings.TrimPrefix(event.Refs[0].LocalRef, "refs/heads/")
+	}
 
 	return h.strategy.OnPush(ctx, event)
 }
+
+// ParsePushRefs parses the pre-push ref list.
+// Each line has the form: <local ref> <local sha> <remote ref> <remote sha>
+func ParsePushRefs(r io.Reader) ([]strategy.PushRef, error) {
+	var refs []strategy.PushRef
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 {
+			continue
+		}
+		refs = append(refs, strategy.PushRef{
+			LocalRef:  fields[0],
+			LocalSHA:  fields[1],
+			RemoteRef: fields[2],
+			RemoteSHA: fields[3],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// ParseRewrites parses the post-rewrite commit list.
+// Each line has the form: <old sha> <new sha> [<extra>]
+func ParseRewrites(r io.Reader) ([]checkpoint.Rewrite, error) {
+	var rewrites []checkpoint.Rewrite
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		rewrites = append(rewrites, checkpoint.Rewrite{Old: fields[0], New: fields[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rewrites, nil
+}
@@ -0,0 +1,244 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/internal/agent"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/session"
+	"github.com/yibudak/open-entire/internal/strategy"
+)
+
+type recordingStrategy struct {
+	response *strategy.AgentResponseEvent
+	commit   *strategy.CommitEvent
+	push     *strategy.PushEvent
+}
+
+func (s *recordingStrategy) Name() string { return "recording" }
+
+func (s *recordingStrategy) OnAgentResponse(ctx context.Context, event *strategy.AgentResponseEvent) error {
+	s.response = event
+	return nil
+}
+
+func (s *recordingStrategy) OnCommit(ctx context.Context, event *strategy.CommitEvent) error {
+	s.commit = event
+	return nil
+}
+
+func (s *recordingStrategy) OnPush(ctx context.Context, event *strategy.PushEvent) error {
+	s.push = event
+	return nil
+}
+
+func TestParsePushRefs(t *testing.T) {
+	input := `refs/heads/main 1111111111111111111111111111111111111111 refs/heads/main 2222222222222222222222222222222222222222
+
+garbage line
+refs/heads/feat 3333333333333333333333333333333333333333 refs/heads/feat 0000000000000000000000000000000000000000
+`
+	refs, err := ParsePushRefs(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, refs, 2)
+	assert.Equal(t, "refs/heads/main", refs[0].LocalRef)
+	assert.Equal(t, "2222222222222222222222222222222222222222", refs[0].RemoteSHA)
+	assert.Equal(t, "refs/heads/feat", refs[1].RemoteRef)
+}
+
+func TestParseRewrites(t *testing.T) {
+	input := `1111111111111111111111111111111111111111 2222222222222222222222222222222222222222
+
+garbage
+3333333333333333333333333333333333333333 4444444444444444444444444444444444444444 extra
+`
+	rewrites, err := ParseRewrites(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, rewrites, 2)
+	assert.Equal(t, "1111111111111111111111111111111111111111", rewrites[0].Old)
+	assert.Equal(t, "4444444444444444444444444444444444444444", rewrites[1].New)
+}
+
+func TestHandlePrePush(t *testing.T) {
+	cfg := config.DefaultConfig()
+	strat := &recordingStrategy{}
+	h := NewHandler(t.TempDir(), &cfg, strat)
+
+	stdin := strings.NewReader("refs/heads/main aaaa refs/heads/main bbbb\n")
+	require.NoError(t, h.HandlePrePush(context.Background(), []string{"origin", "git@example.com:repo.git"}, stdin))
+
+	require.NotNil(t, strat.push)
+	assert.Equal(t, "origin", strat.push.Remote)
+	assert.Equal(t, "git@example.com:repo.git", strat.push.URL)
+	assert.Equal(t, "main", strat.push.Branch)
+	assert.Len(t, strat.push.Refs, 1)
+}
+
+func TestHandlePrePushDisabled(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Enabled = false
+	strat := &recordingStrategy{}
+	h := NewHandler(t.TempDir(), &cfg, strat)
+
+	require.NoError(t, h.HandlePrePush(context.Background(), []string{"origin"}, strings.NewReader("")))
+	assert.Nil(t, strat.push)
+}
+
+func TestHandleAgentHook(t *testing.T) {
+	repoDir := t.TempDir()
+	cfg := config.DefaultConfig()
+	strat := &recordingStrategy{}
+	h := NewHandler(repoDir, &cfg, strat)
+	ctx := context.Background()
+	transcript := "/home/dev/.claude/projects/x/sess-1.jsonl"
+
+	start := &agent.HookEvent{Name: agent.HookSessionStart, SessionID: "sess-1", TranscriptPath: transcript}
+	require.NoError(t, h.HandleAgentHook(ctx, "claude-code", start))
+	assert.Nil(t, strat.response)
+
+	sessions, err := session.NewStore(repoDir)
+	require.NoError(t, err)
+	s, found := sessions.GetSession("sess-1")
+	require.True(t, found)
+	assert.Equal(t, "claude-code", s.AgentName)
+	assert.Equal(t, transcript, s.TranscriptPath)
+
+	stop := &agent.HookEvent{Name: agent.HookStop, SessionID: "sess-1", TranscriptPath: transcript}
+	require.NoError(t, h.HandleAgentHook(ctx, "claude-code", stop))
+	require.NotNil(t, strat.response)
+	assert.Equal(t, "sess-1", strat.response.SessionID)
+	assert.Equal(t, transcript, strat.response.TranscriptPath)
+
+	end := &agent.HookEvent{Name: agent.HookSessionEnd, SessionID: "sess-1"}
+	require.NoError(t, h.HandleAgentHook(ctx, "claude-code", end))
+	sessions, err = session.NewStore(repoDir)
+	require.NoError(t, err)
+	assert.Empty(t, sessions.ActiveSessions())
+}
+
+// initGitRepo creates a repository with one commit and no agent sessions.
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "tester")
+	t.Setenv("GIT_AUTHOR_EMAIL", "tester@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "tester")
+	t.Setenv("GIT_COMMITTER_EMAIL", "tester@example.com")
+
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q", "-b", "main")
+	gitCmd(t, dir, "commit", "-q", "--allow-empty", "-m", "initial")
+	return dir
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "ENTIRE_ENABLED=false")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
+func writeMessage(t *testing.T, dir, message string) string {
+	t.Helper()
+	path := filepath.Join(dir, "COMMIT_EDITMSG")
+	require.NoError(t, os.WriteFile(path, []byte(message), 0o644))
+	return path
+}
+
+func readMessage(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestHandlePrepareCommitMsg(t *testing.T) {
+	dir := initGitRepo(t)
+	cfg := config.DefaultConfig()
+	h := NewHandler(dir, &cfg, &recordingStrategy{})
+	ctx := context.Background()
+
+	// Nothing pending: the message is left alone
+	path := writeMessage(t, dir, "fix bug\n")
+	require.NoError(t, h.HandlePrepareCommitMsg(ctx, []string{path, "message"}))
+	assert.Equal(t, "fix bug\n", readMessage(t, path))
+
+	// An amend keeps its checkpoint
+	amend := "fix bug\n\nEntire-Checkpoint: aabbccddeeff\n"
+	path = writeMessage(t, dir, amend)
+	require.NoError(t, h.HandlePrepareCommitMsg(ctx, []string{path, "commit", "HEAD"}))
+	assert.Equal(t, amend, readMessage(t, path))
+
+	// A squash gathers the checkpoints of the commits it combines
+	squash := "Squashed commit of the following:\n\ncommit 1111\n\n    one\n\n    Entire-Checkpoint: aabbccddeeff\n\n" +
+		"commit 2222\n\n    two\n\n    Entire-Checkpoint: 112233445566\n"
+	path = writeMessage(t, dir, squash)
+	require.NoError(t, h.HandlePrepareCommitMsg(ctx, []string{path, "squash"}))
+	assert.Equal(t, []string{"aabbccddeeff", "112233445566"}, git.CheckpointTrailers(readMessage(t, path)))
+}
+
+func TestHandleCommitMsg(t *testing.T) {
+	dir := initGitRepo(t)
+	cfg := config.DefaultConfig()
+	h := NewHandler(dir, &cfg, &recordingStrategy{})
+
+	reservations, err := checkpoint.LoadReservations(dir)
+	require.NoError(t, err)
+	id, err := reservations.Reserve()
+	require.NoError(t, err)
+
+	// Editing left the reserved trailer above a new paragraph
+	path := writeMessage(t, dir, "fix bug\n\nEntire-Checkpoint: "+id+"\n\nMore detail.\n")
+	require.NoError(t, h.HandleCommitMsg(context.Background(), []string{path}))
+	assert.Equal(t, []string{id}, git.CheckpointTrailers(readMessage(t, path)))
+}
+
+func TestHandlePostCommit(t *testing.T) {
+	dir := initGitRepo(t)
+	cfg := config.DefaultConfig()
+	strat := &recordingStrategy{}
+	h := NewHandler(dir, &cfg, strat)
+	ctx := context.Background()
+	require.NoError(t, Install(dir, false))
+
+	reservations, err := checkpoint.LoadReservations(dir)
+	require.NoError(t, err)
+	id, err := reservations.Reserve()
+	require.NoError(t, err)
+
+	gitCmd(t, dir, "commit", "-q", "--allow-empty", "-m", "work\n\nEntire-Checkpoint: "+id)
+	head := gitCmd(t, dir, "rev-parse", "HEAD")
+	require.NoError(t, h.HandlePostCommit(ctx, []string{head}))
+	require.NotNil(t, strat.commit)
+	assert.Equal(t, id, strat.commit.CheckpointID)
+	assert.Equal(t, head, strat.commit.CommitHash)
+
+	// Amending copies the trailer of a checkpoint that already exists
+	strat.commit = nil
+	gitCmd(t, dir, "commit", "-q", "--amend", "--allow-empty", "-m", "work, amended\n\nEntire-Checkpoint: "+id)
+	require.NoError(t, h.HandlePostCommit(ctx, nil))
+	assert.Nil(t, strat.commit)
+
+	// Nothing was reserved for a commit without a trailer
+	gitCmd(t, dir, "commit", "-q", "--allow-empty", "-m", "human work")
+	require.NoError(t, h.HandlePostCommit(ctx, nil))
+	assert.Nil(t, strat.commit)
+
+	// Hooks from before prepare-commit-msg was installed still checkpoint
+	require.NoError(t, os.Remove(filepath.Join(dir, ".git", "hooks", "prepare-commit-msg")))
+	require.NoError(t, h.HandlePostCommit(ctx, nil))
+	require.NotNil(t, strat.commit)
+	assert.Empty(t, strat.commit.CheckpointID)
+}
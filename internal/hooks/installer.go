@@ -8,6 +8,9 @@ import (
 
 const entireMarker = "# managed by open-entire"
 
+// hookNames are the Git hooks Open-Entire installs.
+var hookNames = []string{"prepare-commit-msg", "commit-msg", "post-commit", "post-rewrite", "pre-push"}
+
 // Install installs Open-Entire git hooks into the repository.
 func Install(repoDir string, force bool) error {
 	hooksDir := filepath.Join(repoDir, ".git", "hooks")
@@ -16,8 +19,11 @@ func Install(repoDir string, force bool) error {
 	}
 
 	hookFiles := map[string]string{
-		"post-commit": postCommitScript,
-		"pre-push":    prePushScript,
+		"prepare-commit-msg": prepareCommitMsgScript,
+		"commit-msg":         commitMsgScript,
+		"post-commit":        postCommitScript,
+		"post-rewrite":       postRewriteScript,
+		"pre-push":           prePushScript,
 	}
 
 	for name, script := range hookFiles {
@@ -43,7 +49,7 @@ func Install(repoDir string, force bool) error {
 func Remove(repoDir string) error {
 	hooksDir := filepath.Join(repoDir, ".git", "hooks")
 
-	for _, name := range []string{"post-commit", "pre-push"} {
+	for _, name := range hookNames {
 		path := filepath.Join(hooksDir, name)
 		data, err := os.ReadFile(path)
 		if err != nil {
@@ -61,7 +67,12 @@ func Remove(repoDir string) error {
 
 // IsInstalled checks if Open-Entire hooks are installed.
 func IsInstalled(repoDir string) bool {
-	path := filepath.Join(repoDir, ".git", "hooks", "post-commit")
+	return hookInstalled(repoDir, "post-commit")
+}
+
+// hookInstalled checks if one of the Open-Entire hooks is installed.
+func hookInstalled(repoDir, name string) bool {
+	path := filepath.Join(repoDir, ".git", "hooks", name)
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return false
@@ -29,11 +29,16 @@ func TestInstallAndRemove(t *testing.T) {
 	data, err := os.ReadFile(postCommit)
 	require.NoError(t, err)
 	assert.Contains(t, string(data), "managed by open-entire")
+	for _, name := range []string{"prepare-commit-msg", "commit-msg", "post-rewrite", "pre-push"} {
+		assert.True(t, hookInstalled(dir, name), name)
+	}
 
 	// Remove
 	err = Remove(dir)
 	require.NoError(t, err)
 	assert.False(t, IsInstalled(dir))
+	assert.False(t, hookInstalled(dir, "prepare-commit-msg"))
+	assert.False(t, hookInstalled(dir, "post-rewrite"))
 }
 
 func TestInstallDoesNotOverwriteExistingHook(t *testing.T) {
@@ -25,8 +25,62 @@ if [ -z "$ENTIRE_BIN" ]; then
     exit 0
 fi
 
-# Run in background to not block commit
-"$ENTIRE_BIN" _hook post-commit &
+# Run in background to not block commit, on the commit just made
+"$ENTIRE_BIN" _hook post-commit "$(git rev-parse HEAD)" </dev/null >/dev/null 2>&1 &
+`
+
+const prepareCommitMsgScript = `#!/bin/sh
+# managed by open-entire
+# Prepare-commit-msg hook: reserve a checkpoint and add its trailer
+
+# Skip if entire is disabled
+if [ "$ENTIRE_ENABLED" = "false" ] || [ "$ENTIRE_ENABLED" = "0" ]; then
+    exit 0
+fi
+
+ENTIRE_BIN=$(command -v open-entire 2>/dev/null)
+if [ -z "$ENTIRE_BIN" ]; then
+    for p in /usr/local/bin/open-entire "$HOME/go/bin/open-entire" "$HOME/.local/bin/open-entire"; do
+        if [ -x "$p" ]; then
+            ENTIRE_BIN="$p"
+            break
+        fi
+    done
+fi
+
+if [ -z "$ENTIRE_BIN" ]; then
+    exit 0
+fi
+
+"$ENTIRE_BIN" _hook prepare-commit-msg "$@" </dev/null
+exit 0
+`
+
+const commitMsgScript = `#!/bin/sh
+# managed by open-entire
+# Commit-msg hook: keep the reserved checkpoint trailer in the trailer block
+
+# Skip if entire is disabled
+if [ "$ENTIRE_ENABLED" = "false" ] || [ "$ENTIRE_ENABLED" = "0" ]; then
+    exit 0
+fi
+
+ENTIRE_BIN=$(command -v open-entire 2>/dev/null)
+if [ -z "$ENTIRE_BIN" ]; then
+    for p in /usr/local/bin/open-entire "$HOME/go/bin/open-entire" "$HOME/.local/bin/open-entire"; do
+        if [ -x "$p" ]; then
+            ENTIRE_BIN="$p"
+            break
+        fi
+    done
+fi
+
+if [ -z "$ENTIRE_BIN" ]; then
+    exit 0
+fi
+
+"$ENTIRE_BIN" _hook commit-msg "$@" </dev/null
+exit 0
 `
 
 const prePushScript = `#!/bin/sh
@@ -52,5 +106,32 @@ if [ -z "$ENTIRE_BIN" ]; then
     exit 0
 fi
 
-"$ENTIRE_BIN" _hook pre-push
+"$ENTIRE_BIN" _hook pre-push "$@"
+`
+
+const postRewriteScript = `#!/bin/sh
+# managed by open-entire
+# Post-rewrite hook: follow amended and rebased commits
+
+# Skip if entire is disabled
+if [ "$ENTIRE_ENABLED" = "false" ] || [ "$ENTIRE_ENABLED" = "0" ]; then
+    exit 0
+fi
+
+ENTIRE_BIN=$(command -v open-entire 2>/dev/null)
+if [ -z "$ENTIRE_BIN" ]; then
+    for p in /usr/local/bin/open-entire "$HOME/go/bin/open-entire" "$HOME/.local/bin/open-entire"; do
+        if [ -x "$p" ]; then
+            ENTIRE_BIN="$p"
+            break
+        fi
+    done
+fi
+
+if [ -z "$ENTIRE_BIN" ]; then
+    exit 0
+fi
+
+# stdin lists the rewritten commits as "<old> <new>" lines
+"$ENTIRE_BIN" _hook post-rewrite "$@" >/dev/null 2>&1
 `
@@ -0,0 +1,37 @@
+// Package lockfile serializes read-modify-write cycles on the state files
+// that hooks running in separate processes share.
+package lockfile
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Timeout bounds how long Acquire waits for a lock, and how old a lock must
+// be to be taken as left behind by a crashed process.
+const Timeout = 5 * time.Second
+
+// Acquire takes an exclusive lock by creating path, waiting for another
+// holder to remove it. The returned function releases it.
+func Acquire(path string) (func(), error) {
+	deadline := time.Now().Add(Timeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > Timeout {
+			os.Remove(path) // Left by a process that died holding it
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
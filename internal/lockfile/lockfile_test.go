@@ -0,0 +1,46 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json.lock")
+
+	release, err := Acquire(path)
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+
+	acquired := make(chan struct{})
+	go func() {
+		again, err := Acquire(path)
+		assert.NoError(t, err)
+		close(acquired)
+		again()
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	<-acquired
+}
+
+func TestAcquireStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json.lock")
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+	old := time.Now().Add(-2 * Timeout)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	release, err := Acquire(path)
+	require.NoError(t, err)
+	release()
+	assert.NoFileExists(t, path)
+}
@@ -0,0 +1,125 @@
+// Package pricing prices the tokens agents use.
+package pricing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// builtin are the list prices of the models agents commonly run, in USD
+// per million tokens, keyed by model name prefix.
+var builtin = map[string]config.ModelPrice{
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+	"claude-opus-4":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+	"claude-haiku-4-5":  {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+	"claude-3-5-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+	"claude-3-5-haiku":  {Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+	"claude-3-opus":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheWrite: 0.30, CacheRead: 0.03},
+	"gemini-2.5-pro":    {Input: 1.25, Output: 10, CacheRead: 0.31},
+	"gemini-2.5-flash":  {Input: 0.30, Output: 2.50, CacheRead: 0.075},
+	"gpt-5":             {Input: 1.25, Output: 10, CacheRead: 0.125},
+	"gpt-5-mini":        {Input: 0.25, Output: 2, CacheRead: 0.025},
+	"gpt-5-nano":        {Input: 0.05, Output: 0.40, CacheRead: 0.005},
+	"gpt-4.1":           {Input: 2, Output: 8, CacheRead: 0.50},
+	"gpt-4.1-mini":      {Input: 0.40, Output: 1.60, CacheRead: 0.10},
+	"gpt-4o":            {Input: 2.50, Output: 10, CacheRead: 1.25},
+	"gpt-4o-mini":       {Input: 0.15, Output: 0.60, CacheRead: 0.075},
+	"o3":                {Input: 2, Output: 8, CacheRead: 0.50},
+	"o4-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.275},
+	"codex-mini":        {Input: 1.50, Output: 6, CacheRead: 0.375},
+}
+
+// Table maps model name prefixes to prices.
+type Table map[string]config.ModelPrice
+
+// New returns the built-in prices with the configured ones on top.
+func New(overrides map[string]config.ModelPrice) Table {
+	t := make(Table, len(builtin)+len(overrides))
+	for model, p := range builtin {
+		t[model] = p
+	}
+	for model, p := range overrides {
+		t[model] = p
+	}
+	return t
+}
+
+// Lookup returns the price of a model. Model names carry a release date
+// or other suffix, so the longest prefix in the table wins.
+func (t Table) Lookup(model string) (config.ModelPrice, bool) {
+	best := ""
+	for prefix := range t {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return config.ModelPrice{}, false
+	}
+	return t[best], true
+}
+
+// Cost returns what the tokens cost at a price.
+func Cost(p config.ModelPrice, u types.TokenUsage) float64 {
+	return (float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheCreation)*p.CacheWrite +
+		float64(u.CacheReads)*p.CacheRead) / 1e6
+}
+
+// PriceSession fills in the cost of each response of a session, its
+// subagents included, and totals it on the session. A cost the agent
+// reported itself is kept; responses from models without a price cost
+// nothing.
+func (t Table) PriceSession(s *types.SessionData) {
+	var total float64
+	for i := range s.Responses {
+		r := &s.Responses[i]
+		if r.TokenUsage.CostUSD == 0 {
+			if p, ok := t.Lookup(r.Model); ok {
+				r.TokenUsage.CostUSD = Cost(p, r.TokenUsage)
+			}
+		}
+		total += r.TokenUsage.CostUSD
+	}
+	for i := range s.NestedSessions {
+		t.PriceSession(&s.NestedSessions[i])
+		total += s.NestedSessions[i].TokenUsage.CostUSD
+	}
+	s.TokenUsage.CostUSD = total
+}
+
+// Models lists the models that answered in a session or its subagents, in
+// the order they were first used.
+func Models(s *types.SessionData) []string {
+	var models []string
+	seen := make(map[string]bool)
+	var walk func(s *types.SessionData)
+	walk = func(s *types.SessionData) {
+		for _, r := range s.Responses {
+			if r.Model != "" && !seen[r.Model] {
+				seen[r.Model] = true
+				models = append(models, r.Model)
+			}
+		}
+		for i := range s.NestedSessions {
+			walk(&s.NestedSessions[i])
+		}
+	}
+	walk(s)
+	return models
+}
+
+// Format renders a cost in dollars, keeping small amounts visible.
+func Format(usd float64) string {
+	if usd > 0 && usd < 0.01 {
+		return fmt.Sprintf("$%.4f", usd)
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
@@ -0,0 +1,76 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func TestLookup(t *testing.T) {
+	table := New(map[string]config.ModelPrice{
+		"claude-sonnet-4-5": {Input: 1},
+		"local-llm":         {Input: 0.1},
+	})
+
+	p, ok := table.Lookup("claude-opus-4-5-20251101")
+	assert.True(t, ok)
+	assert.Equal(t, 5.0, p.Input)
+	p, ok = table.Lookup("claude-opus-4-1-20250805")
+	assert.True(t, ok)
+	assert.Equal(t, 15.0, p.Input)
+
+	// Configured prices win over the built-in ones
+	p, _ = table.Lookup("claude-sonnet-4-5-20250929")
+	assert.Equal(t, 1.0, p.Input)
+	p, _ = table.Lookup("claude-sonnet-4-20250514")
+	assert.Equal(t, 3.0, p.Input)
+	_, ok = table.Lookup("local-llm-7b")
+	assert.True(t, ok)
+
+	// Codex models
+	p, _ = table.Lookup("gpt-5-codex")
+	assert.Equal(t, 1.25, p.Input)
+	p, _ = table.Lookup("gpt-5-mini-2025-08-07")
+	assert.Equal(t, 0.25, p.Input)
+
+	_, ok = table.Lookup("unknown")
+	assert.False(t, ok)
+	_, ok = table.Lookup("")
+	assert.False(t, ok)
+}
+
+func TestCost(t *testing.T) {
+	p := config.ModelPrice{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30}
+	u := types.TokenUsage{InputTokens: 1_000_000, OutputTokens: 100_000, CacheCreation: 200_000, CacheReads: 1_000_000}
+	assert.InDelta(t, 3+1.5+0.75+0.30, Cost(p, u), 1e-9)
+}
+
+func TestPriceSession(t *testing.T) {
+	s := &types.SessionData{
+		Responses: []types.Response{
+			{Model: "claude-sonnet-4-5", TokenUsage: types.TokenUsage{InputTokens: 1_000_000}},
+			// Reported by the agent
+			{Model: "claude-sonnet-4-5", TokenUsage: types.TokenUsage{InputTokens: 1_000_000, CostUSD: 0.5}},
+			{Model: "unknown", TokenUsage: types.TokenUsage{InputTokens: 1_000_000}},
+		},
+		NestedSessions: []types.SessionData{{
+			Responses: []types.Response{{Model: "claude-haiku-4-5", TokenUsage: types.TokenUsage{OutputTokens: 1_000_000}}},
+		}},
+	}
+	New(nil).PriceSession(s)
+
+	assert.InDelta(t, 3.0, s.Responses[0].TokenUsage.CostUSD, 1e-9)
+	assert.InDelta(t, 0.5, s.Responses[1].TokenUsage.CostUSD, 1e-9)
+	assert.Zero(t, s.Responses[2].TokenUsage.CostUSD)
+	assert.InDelta(t, 5.0, s.NestedSessions[0].TokenUsage.CostUSD, 1e-9)
+	assert.InDelta(t, 8.5, s.TokenUsage.CostUSD, 1e-9)
+	assert.Equal(t, []string{"claude-sonnet-4-5", "unknown", "claude-haiku-4-5"}, Models(s))
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "$0.00", Format(0))
+	assert.Equal(t, "$0.0042", Format(0.0042))
+	assert.Equal(t, "$1.50", Format(1.5))
+}
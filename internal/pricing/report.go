@@ -0,0 +1,105 @@
+package pricing
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// GroupBy is what a cost report totals by.
+type GroupBy string
+
+const (
+	ByAuthor GroupBy = "author"
+	ByBranch GroupBy = "branch"
+	ByAgent  GroupBy = "agent"
+)
+
+// ParseGroupBy validates a grouping given by name.
+func ParseGroupBy(name string) (GroupBy, error) {
+	switch by := GroupBy(name); by {
+	case ByAuthor, ByBranch, ByAgent:
+		return by, nil
+	}
+	return "", fmt.Errorf("unknown grouping %q (use author, branch or agent)", name)
+}
+
+// Key returns the group a session of a checkpoint falls in.
+func (by GroupBy) Key(cp *types.CheckpointMetadata, s types.SessionSummary) string {
+	var key string
+	switch by {
+	case ByAuthor:
+		key = cp.Author
+	case ByBranch:
+		key = cp.Branch
+	case ByAgent:
+		key = s.AgentName
+	}
+	if key == "" {
+		return "(unknown)"
+	}
+	return key
+}
+
+// Group is what the sessions in one group cost.
+type Group struct {
+	Key         string           `json:"key"`
+	Checkpoints int              `json:"checkpoints"`
+	Sessions    int              `json:"sessions"`
+	TokenUsage  types.TokenUsage `json:"token_usage"`
+	// Unpriced counts sessions that used tokens without a cost, usually
+	// because their model has no price.
+	Unpriced int `json:"unpriced,omitempty"`
+}
+
+// Report totals the cost of the checkpoints created on or after since,
+// most expensive group first. A zero since includes every checkpoint.
+func Report(checkpoints []*types.CheckpointMetadata, by GroupBy, since time.Time) []Group {
+	groups := make(map[string]*Group)
+	for _, cp := range checkpoints {
+		if cp.CreatedAt.Before(since) {
+			continue
+		}
+		counted := make(map[string]bool)
+		for _, s := range cp.Sessions {
+			key := by.Key(cp, s)
+			g := groups[key]
+			if g == nil {
+				g = &Group{Key: key}
+				groups[key] = g
+			}
+			if !counted[key] {
+				counted[key] = true
+				g.Checkpoints++
+			}
+			g.Sessions++
+			addUsage(&g.TokenUsage, s.TokenUsage)
+			if s.TokenUsage.CostUSD == 0 && s.TokenUsage.InputTokens+s.TokenUsage.OutputTokens > 0 {
+				g.Unpriced++
+			}
+		}
+	}
+
+	report := make([]Group, 0, len(groups))
+	for _, g := range groups {
+		report = append(report, *g)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].TokenUsage.CostUSD != report[j].TokenUsage.CostUSD {
+			return report[i].TokenUsage.CostUSD > report[j].TokenUsage.CostUSD
+		}
+		return report[i].Key < report[j].Key
+	})
+	return report
+}
+
+func addUsage(dst *types.TokenUsage, u types.TokenUsage) {
+	dst.InputTokens += u.InputTokens
+	dst.OutputTokens += u.OutputTokens
+	dst.CacheCreation += u.CacheCreation
+	dst.CacheReads += u.CacheReads
+	dst.APICalls += u.APICalls
+	dst.CostUSD += u.CostUSD
+}
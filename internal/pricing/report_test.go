@@ -0,0 +1,46 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func TestReport(t *testing.T) {
+	now := time.Now()
+	session := func(agent string, cost float64) types.SessionSummary {
+		return types.SessionSummary{AgentName: agent, TokenUsage: types.TokenUsage{InputTokens: 100, CostUSD: cost}}
+	}
+	checkpoints := []*types.CheckpointMetadata{
+		{ID: "a", Author: "Ada", Branch: "main", CreatedAt: now,
+			Sessions: []types.SessionSummary{session("claude-code", 1), session("claude-code", 0.5), session("aider", 0)}},
+		{ID: "b", Author: "Bob", Branch: "main", CreatedAt: now,
+			Sessions: []types.SessionSummary{session("claude-code", 2)}},
+		{ID: "old", Author: "Ada", Branch: "main", CreatedAt: now.Add(-48 * time.Hour),
+			Sessions: []types.SessionSummary{session("claude-code", 100)}},
+	}
+
+	byAgent := Report(checkpoints, ByAgent, now.Add(-time.Hour))
+	require.Len(t, byAgent, 2)
+	assert.Equal(t, "claude-code", byAgent[0].Key)
+	assert.Equal(t, 2, byAgent[0].Checkpoints)
+	assert.Equal(t, 3, byAgent[0].Sessions)
+	assert.InDelta(t, 3.5, byAgent[0].TokenUsage.CostUSD, 1e-9)
+	assert.Equal(t, "aider", byAgent[1].Key)
+	assert.Equal(t, 1, byAgent[1].Unpriced)
+
+	byAuthor := Report(checkpoints, ByAuthor, time.Time{})
+	require.Len(t, byAuthor, 2)
+	assert.Equal(t, "Ada", byAuthor[0].Key)
+	assert.Equal(t, 2, byAuthor[0].Checkpoints)
+	assert.InDelta(t, 101.5, byAuthor[0].TokenUsage.CostUSD, 1e-9)
+
+	_, err := ParseGroupBy("team")
+	assert.Error(t, err)
+	by, err := ParseGroupBy("branch")
+	require.NoError(t, err)
+	assert.Equal(t, ByBranch, by)
+}
@@ -0,0 +1,224 @@
+// Package redact scrubs secrets and credentials from session transcripts
+// before they are written to the checkpoints branch.
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+
+	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// DefaultMinEntropy is the Shannon entropy, in bits per character, above
+// which a long token is treated as a secret.
+const DefaultMinEntropy = 4.5
+
+// minEntropyLength is the shortest token checked for entropy. Shorter
+// tokens cannot carry enough entropy to tell keys from words.
+const minEntropyLength = 32
+
+// Rule is a named pattern for one kind of secret. If the pattern has a
+// capture group, only the first group is redacted.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// builtinRules are the detectors that always run.
+var builtinRules = []Rule{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[^"]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|private).{0,20}?[=:]\s*\\?["']?([A-Za-z0-9/+]{40})\b`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+}
+
+// entropyCandidate matches tokens that look like encoded keys.
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_=-]{32,}`)
+
+// Redactor replaces secrets with [REDACTED:<rule>] markers.
+type Redactor struct {
+	rules      []Rule
+	allow      []*regexp.Regexp
+	minEntropy float64
+}
+
+// New creates a redactor with the built-in detectors plus the rules and
+// allow-list from the config.
+func New(opts config.RedactionOptions) (*Redactor, error) {
+	r := &Redactor{
+		rules:      append([]Rule(nil), builtinRules...),
+		minEntropy: opts.MinEntropy,
+	}
+	if r.minEntropy == 0 {
+		r.minEntropy = DefaultMinEntropy
+	}
+
+	for _, rule := range opts.Rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rule %q: %w", rule.Name, err)
+		}
+		name := rule.Name
+		if name == "" {
+			name = "custom"
+		}
+		r.rules = append(r.rules, Rule{Name: name, Pattern: re})
+	}
+
+	for _, pattern := range opts.Allow {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction allow pattern %q: %w", pattern, err)
+		}
+		r.allow = append(r.allow, re)
+	}
+
+	return r, nil
+}
+
+// span is a byte range to redact.
+type span struct {
+	start, end int
+	rule       string
+}
+
+// Redact returns data with every secret replaced, and the number of
+// secrets found per rule. Replacements never contain quotes or
+// backslashes, so JSON stays valid.
+func (r *Redactor) Redact(data []byte) ([]byte, map[string]int) {
+	var spans []span
+	for _, rule := range r.rules {
+		for _, m := range rule.Pattern.FindAllSubmatchIndex(data, -1) {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			spans = append(spans, span{start, end, rule.Name})
+		}
+	}
+	if r.minEntropy > 0 {
+		for _, m := range entropyCandidate.FindAllIndex(data, -1) {
+			if isHighEntropy(data[m[0]:m[1]], r.minEntropy) {
+				spans = append(spans, span{m[0], m[1], "high-entropy"})
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return data, nil
+	}
+
+	// Earlier spans win; among spans starting together, the longest
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	out := make([]byte, 0, len(data))
+	counts := make(map[string]int)
+	pos := 0
+	for _, s := range spans {
+		if s.start < pos {
+			continue // Overlaps a secret already redacted
+		}
+		// Never split a JSON escape such as \n from its backslash
+		if escaped(data, s.start) {
+			s.start++
+		}
+		if s.start >= s.end || r.allowed(data[s.start:s.end]) {
+			continue
+		}
+		out = append(out, data[pos:s.start]...)
+		out = append(out, "[REDACTED:"+s.rule+"]"...)
+		pos = s.end
+		counts[s.rule]++
+	}
+	if len(counts) == 0 {
+		return data, nil
+	}
+	out = append(out, data[pos:]...)
+	return out, counts
+}
+
+func (r *Redactor) allowed(secret []byte) bool {
+	for _, re := range r.allow {
+		if re.Match(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// escaped reports whether the byte at i is escaped by an odd run of
+// backslashes before it.
+func escaped(data []byte, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && data[j] == '\\'; j-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// isHighEntropy reports whether a token looks random: it mixes letters
+// and digits and its Shannon entropy reaches the threshold.
+func isHighEntropy(token []byte, threshold float64) bool {
+	if len(token) < minEntropyLength {
+		return false
+	}
+	var letters, digits bool
+	for _, c := range token {
+		switch {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			letters = true
+		}
+	}
+	if !letters || !digits {
+		return false
+	}
+	return Entropy(token) >= threshold
+}
+
+// Entropy returns the Shannon entropy of data in bits per byte.
+func Entropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	var freq [256]int
+	for _, c := range data {
+		freq[c]++
+	}
+	var h float64
+	n := float64(len(data))
+	for _, f := range freq {
+		if f == 0 {
+			continue
+		}
+		p := float64(f) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// AddToReport adds per-rule counts for one file to a report.
+func AddToReport(report *types.RedactionReport, file string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	if report.ByRule == nil {
+		report.ByRule = make(map[string]int)
+	}
+	n := 0
+	for rule, c := range counts {
+		report.ByRule[rule] += c
+		n += c
+	}
+	report.Total += n
+	report.Files = append(report.Files, types.RedactedFile{Path: file, Count: n})
+}
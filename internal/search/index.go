@@ -0,0 +1,313 @@
+// Package search keeps a local full-text index of checkpoint transcripts.
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yibudak/open-entire/internal/agent"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// indexVersion changes whenever the on-disk format does; an index of
+// another version is rebuilt from scratch.
+const indexVersion = 2
+
+// Doc is one searchable piece of a checkpoint: a prompt, a response, a
+// tool call or the commit message.
+type Doc struct {
+	CheckpointID string    `json:"checkpoint_id"`
+	CommitHash   string    `json:"commit_hash,omitempty"`
+	Session      int       `json:"session"` // -1 for the commit message
+	Subagent     string    `json:"subagent,omitempty"`
+	Turn         int       `json:"turn"`
+	Kind         string    `json:"kind"` // prompt, response, tool or commit
+	Tool         string    `json:"tool,omitempty"`
+	Agent        string    `json:"agent,omitempty"`
+	Branch       string    `json:"branch,omitempty"`
+	Author       string    `json:"author,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	Text         string    `json:"text,omitempty"`
+}
+
+// Index is an inverted index over the checkpoints branch, stored under
+// .open-entire/index/. Head is the checkpoints commit it reflects.
+type Index struct {
+	Version  int              `json:"version"`
+	Head     string           `json:"head"`
+	NextID   int              `json:"next_id"`
+	Docs     map[int]*Doc     `json:"docs"`
+	Postings map[string][]int `json:"postings"`
+
+	path string
+}
+
+// Dir returns the directory the index is kept in.
+func Dir(repoDir string) string {
+	return filepath.Join(repoDir, ".open-entire", "index")
+}
+
+// Open loads the repository's index, or returns an empty one if there is
+// none yet or it is from another version.
+func Open(repoDir string) (*Index, error) {
+	idx := &Index{path: filepath.Join(Dir(repoDir), "index.json")}
+
+	data, err := os.ReadFile(idx.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, idx); err != nil {
+			slog.Warn("search index is corrupt, rebuilding", "path", idx.path, "error", err)
+			*idx = Index{path: idx.path}
+		}
+	}
+	if idx.Version != indexVersion {
+		idx.reset()
+	}
+	return idx, nil
+}
+
+// Load opens the repository's index and brings it up to date, saving it if
+// it changed.
+func Load(repoDir string, repo *git.Repository) (*Index, error) {
+	idx, err := Open(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := idx.Update(repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update search index: %w", err)
+	}
+	if changed {
+		if err := idx.Save(); err != nil {
+			slog.Warn("failed to save search index", "error", err)
+		}
+	}
+	return idx, nil
+}
+
+func (idx *Index) reset() {
+	idx.Version = indexVersion
+	idx.Head = ""
+	idx.NextID = 0
+	idx.Docs = make(map[int]*Doc)
+	idx.Postings = make(map[string][]int)
+}
+
+// Save writes the index atomically.
+func (idx *Index) Save() error {
+	dir := filepath.Dir(idx.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// The index is a local cache and never belongs in a commit
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		if err := os.WriteFile(ignore, []byte("*\n"), 0o644); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, idx.path)
+}
+
+// Update brings the index up to date with the checkpoints branch,
+// re-indexing only checkpoints whose files changed since the last update.
+// It reports whether anything changed.
+func (idx *Index) Update(repo *git.Repository) (bool, error) {
+	if !repo.HasCheckpointsBranch() {
+		return false, nil
+	}
+	head, err := repo.BranchHead(git.CheckpointsBranch)
+	if err != nil {
+		return false, err
+	}
+	if head == idx.Head {
+		return false, nil
+	}
+
+	var files []string
+	if idx.Head != "" {
+		// The old head may be gone, e.g. after redact --purge-history
+		files, err = repo.ChangedFilesBetween(idx.Head, head)
+		if err != nil {
+			slog.Debug("rebuilding search index", "reason", err)
+			idx.reset()
+		}
+	}
+	if idx.Head == "" {
+		if files, err = repo.ListFilesOnBranch(head, ""); err != nil {
+			return false, err
+		}
+	}
+
+	ids := checkpointIDs(files)
+	for _, id := range ids {
+		idx.remove(id)
+		if err := idx.addCheckpoint(repo, head, id); err != nil {
+			slog.Warn("failed to index checkpoint", "id", id, "error", err)
+		}
+	}
+	idx.Head = head
+	slog.Debug("search index updated", "checkpoints", len(ids), "head", head)
+	return true, nil
+}
+
+// checkpointIDs returns the checkpoints that own the given branch paths.
+func checkpointIDs(files []string) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, f := range files {
+		parts := strings.SplitN(f, "/", 3)
+		if len(parts) < 3 || len(parts[0]) != 2 {
+			continue
+		}
+		id := parts[0] + parts[1]
+		if len(id) != 12 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// addCheckpoint indexes a checkpoint as of the given checkpoints commit.
+// A checkpoint that no longer exists there is skipped.
+func (idx *Index) addCheckpoint(repo *git.Repository, rev, id string) error {
+	data, err := repo.ReadFileFromBranch(rev, checkpoint.MetadataPath(id))
+	if err != nil {
+		return nil
+	}
+	var meta types.CheckpointMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return fmt.Errorf("invalid metadata: %w", err)
+	}
+
+	base := Doc{
+		CheckpointID: meta.ID,
+		CommitHash:   meta.CommitHash,
+		Branch:       meta.Branch,
+		Author:       meta.Author,
+		CreatedAt:    meta.CreatedAt,
+	}
+	if base.CheckpointID == "" {
+		base.CheckpointID = id
+	}
+
+	if msg := strings.TrimSpace(meta.Message); msg != "" {
+		d := base
+		d.Session, d.Kind, d.Text = -1, "commit", msg
+		idx.add(&d)
+	}
+
+	for _, s := range meta.Sessions {
+		for _, e := range sessionEntries(repo, rev, id, s) {
+			d := base
+			d.Session = s.Index
+			d.Agent = s.AgentName
+			d.Subagent, d.Turn, d.Kind, d.Tool, d.Text = e.Subagent, e.Turn, e.Kind, e.Tool, e.Text
+			idx.add(&d)
+		}
+	}
+	return nil
+}
+
+// sessionEntries reads a stored session's prompts, responses and tool calls.
+// They come from the full transcript, parsed by the agent, so tool inputs
+// are indexed whole; sessions of agents without a transcript parser fall
+// back to context.md, which names only what each tool call acted on.
+func sessionEntries(repo *git.Repository, rev, id string, s types.SessionSummary) []checkpoint.ContextEntry {
+	files := checkpoint.SessionFiles(id, s.Index)
+	if transcript, err := repo.ReadFileFromBranch(rev, files["full"]); err == nil && len(transcript) > 0 {
+		data, err := agent.ParseStoredTranscript(s.AgentName, transcript)
+		if err == nil {
+			return checkpoint.SessionEntries(data)
+		}
+		slog.Debug("indexing context.md instead of transcript", "id", id, "session", s.Index, "error", err)
+	}
+	md, err := repo.ReadFileFromBranch(rev, files["context"])
+	if err != nil {
+		return nil
+	}
+	return checkpoint.ParseContext(md)
+}
+
+func (idx *Index) add(d *Doc) {
+	id := idx.NextID
+	idx.NextID++
+	idx.Docs[id] = d
+
+	terms := tokenize(d.Text)
+	if d.Tool != "" {
+		terms = append(terms, tokenize(d.Tool)...)
+	}
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			idx.Postings[term] = append(idx.Postings[term], id)
+		}
+	}
+}
+
+// remove drops every document of a checkpoint.
+func (idx *Index) remove(checkpointID string) {
+	removed := make(map[int]bool)
+	for id, d := range idx.Docs {
+		if d.CheckpointID == checkpointID {
+			removed[id] = true
+			delete(idx.Docs, id)
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	for term, ids := range idx.Postings {
+		kept := ids[:0]
+		for _, id := range ids {
+			if !removed[id] {
+				kept = append(kept, id)
+			}
+		}
+		if len(kept) == 0 {
+			delete(idx.Postings, term)
+		} else {
+			idx.Postings[term] = kept
+		}
+	}
+}
+
+// tokenize splits text into lowercase words of two or more letters or
+// digits.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := words[:0]
+	for _, w := range words {
+		if len([]rune(w)) >= 2 {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,224 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultLimit is how many results a search returns when no limit is set.
+const defaultLimit = 20
+
+// snippetContext is how many bytes of text a snippet shows around a match.
+const snippetContext = 80
+
+// Query is a search and its filters. A query wrapped in double quotes must
+// match as a phrase; otherwise every word must appear.
+type Query struct {
+	Text   string
+	Agent  string
+	Branch string
+	Author string // case-insensitive substring
+	Tool   string
+	Since  time.Time // inclusive
+	Until  time.Time // exclusive
+	Limit  int
+}
+
+// Result is a matching document with a snippet of the text around the match.
+type Result struct {
+	Doc
+	Snippet string `json:"snippet"`
+	Score   int    `json:"score"`
+}
+
+// Search returns the documents matching the query, best first.
+func (idx *Index) Search(q Query) []Result {
+	text := strings.TrimSpace(q.Text)
+	phrase := ""
+	if len(text) > 1 && strings.HasPrefix(text, `"`) && strings.HasSuffix(text, `"`) {
+		phrase = strings.ToLower(strings.Trim(text, `"`))
+	}
+	terms := unique(tokenize(text))
+
+	var results []Result
+	for _, id := range idx.candidates(terms) {
+		d := idx.Docs[id]
+		if !q.matches(d) {
+			continue
+		}
+		if phrase != "" && !strings.Contains(strings.ToLower(d.Text), phrase) {
+			continue
+		}
+		r := Result{Doc: *d, Score: score(d.Text, terms)}
+		r.Text = ""
+		r.Snippet = snippet(d.Text, terms)
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		if a.CheckpointID != b.CheckpointID {
+			return a.CheckpointID < b.CheckpointID
+		}
+		if a.Session != b.Session {
+			return a.Session < b.Session
+		}
+		if a.Turn != b.Turn {
+			return a.Turn < b.Turn
+		}
+		return a.Kind < b.Kind
+	})
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// candidates returns the documents holding every term, or all documents
+// when there are no terms.
+func (idx *Index) candidates(terms []string) []int {
+	if len(terms) == 0 {
+		ids := make([]int, 0, len(idx.Docs))
+		for id := range idx.Docs {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	// Intersect starting from the rarest term
+	lists := make([][]int, len(terms))
+	for i, term := range terms {
+		lists[i] = idx.Postings[term]
+		if len(lists[i]) == 0 {
+			return nil
+		}
+	}
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+
+	ids := lists[0]
+	for _, list := range lists[1:] {
+		in := make(map[int]bool, len(list))
+		for _, id := range list {
+			in[id] = true
+		}
+		var kept []int
+		for _, id := range ids {
+			if in[id] {
+				kept = append(kept, id)
+			}
+		}
+		ids = kept
+	}
+	return ids
+}
+
+func (q Query) matches(d *Doc) bool {
+	if q.Agent != "" && !strings.EqualFold(d.Agent, q.Agent) {
+		return false
+	}
+	if q.Branch != "" && d.Branch != q.Branch {
+		return false
+	}
+	if q.Author != "" && !strings.Contains(strings.ToLower(d.Author), strings.ToLower(q.Author)) {
+		return false
+	}
+	if q.Tool != "" && (d.Kind != "tool" || !strings.EqualFold(d.Tool, q.Tool)) {
+		return false
+	}
+	if !q.Since.IsZero() && d.CreatedAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !d.CreatedAt.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// score counts how often the terms occur in the text.
+func score(text string, terms []string) int {
+	want := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		want[t] = true
+	}
+	n := 0
+	for _, w := range tokenize(text) {
+		if want[w] {
+			n++
+		}
+	}
+	return n
+}
+
+// snippet returns the text around the first match of any term, on one line.
+func snippet(text string, terms []string) string {
+	from, to := 0, min(2*snippetContext, len(text))
+	if len(terms) > 0 {
+		quoted := make([]string, len(terms))
+		for i, t := range terms {
+			quoted[i] = regexp.QuoteMeta(t)
+		}
+		re := regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+		if loc := re.FindStringIndex(text); loc != nil {
+			from = max(loc[0]-snippetContext, 0)
+			to = min(loc[1]+snippetContext, len(text))
+		}
+	}
+	for from > 0 && !isRuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !isRuneStart(text[to]) {
+		to++
+	}
+
+	s := strings.Join(strings.Fields(text[from:to]), " ")
+	if from > 0 {
+		s = "…" + s
+	}
+	if to < len(text) {
+		s += "…"
+	}
+	return s
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func unique(terms []string) []string {
+	seen := make(map[string]bool)
+	out := terms[:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// ParseTime parses a date filter, either a day (2006-01-02) or an RFC 3339
+// timestamp.
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD or RFC 3339", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,226 @@
+package search
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "github.com/yibudak/open-entire/internal/agent/claude"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func initTestRepo(t *testing.T) (*git.Repository, string) {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "tester")
+	t.Setenv("GIT_AUTHOR_EMAIL", "tester@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "tester")
+	t.Setenv("GIT_COMMITTER_EMAIL", "tester@example.com")
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	}
+
+	repo, err := git.Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, repo.EnsureCheckpointsBranch())
+	return repo, dir
+}
+
+func createCheckpoint(t *testing.T, repo *git.Repository, id, branch, message string, session *types.SessionData) {
+	t.Helper()
+	meta := checkpoint.NewMetadata(id, "abc123", branch, "Tester <tester@example.com>", message, "manual-commit")
+	meta.Sessions = []types.SessionSummary{{Index: 0, AgentName: session.AgentName, SessionID: session.ID}}
+	bundle := checkpoint.SessionBundle{
+		Metadata: &types.SessionMetadata{AgentName: session.AgentName, SessionID: session.ID},
+		Session:  session,
+	}
+	require.NoError(t, checkpoint.NewStore(repo).Create(meta, []checkpoint.SessionBundle{bundle}))
+}
+
+func redisSession() *types.SessionData {
+	return &types.SessionData{
+		ID:        "sess-1",
+		AgentName: "claude-code",
+		Prompts: []types.Prompt{
+			{Content: "Should we keep Redis for sessions?"},
+			{Content: "OK, drop Redis and store sessions in Postgres"},
+		},
+		Responses: []types.Response{
+			{Content: "Redis only caches sessions; Postgres could hold them."},
+			{Content: "Removed the Redis client and added a sessions table."},
+		},
+		ToolCalls: []types.ToolCall{
+			{Name: "Bash", Input: `{"command":"go mod tidy"}`},
+		},
+	}
+}
+
+func openUpdated(t *testing.T, repo *git.Repository, dir string) *Index {
+	t.Helper()
+	idx, err := Open(dir)
+	require.NoError(t, err)
+	_, err = idx.Update(repo)
+	require.NoError(t, err)
+	require.NoError(t, idx.Save())
+	return idx
+}
+
+func TestSearch(t *testing.T) {
+	repo, dir := initTestRepo(t)
+	createCheckpoint(t, repo, "aaaaaaaaaaaa", "main", "Drop Redis", redisSession())
+	createCheckpoint(t, repo, "bbbbbbbbbbbb", "feature", "Add logging", &types.SessionData{
+		ID:        "sess-2",
+		AgentName: "codex",
+		Prompts:   []types.Prompt{{Content: "Add structured logging"}},
+	})
+
+	idx := openUpdated(t, repo, dir)
+
+	results := idx.Search(Query{Text: "drop redis"})
+	require.Len(t, results, 2)
+	assert.Equal(t, "commit", results[0].Kind, "equal scores put the commit message first")
+	assert.Equal(t, -1, results[0].Session)
+	assert.Equal(t, "prompt", results[1].Kind)
+	assert.Equal(t, "aaaaaaaaaaaa", results[1].CheckpointID)
+	assert.Equal(t, 0, results[1].Session)
+	assert.Equal(t, 2, results[1].Turn)
+	assert.Equal(t, "claude-code", results[1].Agent)
+	assert.Contains(t, results[1].Snippet, "drop Redis")
+	assert.Empty(t, results[1].Text)
+
+	results = idx.Search(Query{Text: `"redis client"`})
+	require.Len(t, results, 1)
+	assert.Equal(t, "response", results[0].Kind)
+
+	assert.Empty(t, idx.Search(Query{Text: "redis", Agent: "codex"}))
+	assert.Len(t, idx.Search(Query{Text: "logging", Branch: "feature"}), 2)
+	assert.Empty(t, idx.Search(Query{Text: "logging", Author: "someone"}))
+	assert.Len(t, idx.Search(Query{Text: "logging", Author: "TESTER"}), 2)
+	assert.Empty(t, idx.Search(Query{Text: "logging", Until: time.Now().Add(-time.Hour)}))
+	assert.Empty(t, idx.Search(Query{Text: "logging", Since: time.Now().Add(time.Hour)}))
+
+	results = idx.Search(Query{Tool: "bash"})
+	require.Len(t, results, 1)
+	assert.Equal(t, "go mod tidy", results[0].Snippet)
+	assert.Equal(t, 2, results[0].Turn)
+}
+
+func TestUpdateIsIncremental(t *testing.T) {
+	repo, dir := initTestRepo(t)
+	createCheckpoint(t, repo, "aaaaaaaaaaaa", "main", "Drop Redis", redisSession())
+	idx := openUpdated(t, repo, dir)
+	docs := len(idx.Docs)
+
+	// Nothing new: no update
+	changed, err := idx.Update(repo)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	createCheckpoint(t, repo, "bbbbbbbbbbbb", "main", "Tune Postgres pool", &types.SessionData{
+		ID:        "sess-2",
+		AgentName: "claude-code",
+		Prompts:   []types.Prompt{{Content: "Raise the pool size"}},
+	})
+
+	// A reopened index picks up only the new checkpoint
+	idx, err = Open(dir)
+	require.NoError(t, err)
+	assert.Len(t, idx.Docs, docs)
+	changed, err = idx.Update(repo)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Len(t, idx.Docs, docs+2)
+	assert.Len(t, idx.Search(Query{Text: "pool"}), 2)
+
+	// Rewriting a checkpoint replaces its documents
+	require.NoError(t, repo.CommitOnBranch(git.CheckpointsBranch, "edit", map[string][]byte{
+		checkpoint.SessionFiles("aaaaaaaaaaaa", 0)["context"]: []byte("# Session\n\n## Turn 1\n\n**Prompt**\n\n> Use Postgres\n"),
+	}))
+	_, err = idx.Update(repo)
+	require.NoError(t, err)
+	assert.Empty(t, idx.Search(Query{Text: "redis client"}))
+	assert.Len(t, idx.Search(Query{Text: "use postgres"}), 1)
+}
+
+func TestSaveIgnoresIndexDir(t *testing.T) {
+	dir := t.TempDir()
+	idx, err := Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, idx.Save())
+
+	data, err := os.ReadFile(filepath.Join(Dir(dir), ".gitignore"))
+	require.NoError(t, err)
+	assert.Equal(t, "*\n", string(data))
+}
+
+func TestSnippet(t *testing.T) {
+	text := strings.Repeat("padding ", 30) + "the Redis   client\nwas removed" + strings.Repeat(" tail", 30)
+	s := snippet(text, []string{"redis"})
+	assert.True(t, strings.HasPrefix(s, "…"))
+	assert.True(t, strings.HasSuffix(s, "…"))
+	assert.Contains(t, s, "the Redis client was removed")
+
+	assert.Equal(t, "short", snippet("short", nil))
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"drop", "redis", "v2", "café"}, tokenize("Drop Redis (v2) a café!"))
+}
+
+func TestParseTime(t *testing.T) {
+	day, err := ParseTime("2025-01-15")
+	require.NoError(t, err)
+	assert.Equal(t, 15, day.Day())
+
+	_, err = ParseTime("2025-01-15T10:00:00Z")
+	require.NoError(t, err)
+
+	_, err = ParseTime("yesterday")
+	assert.Error(t, err)
+}
+
+func TestSearchToolInputs(t *testing.T) {
+	repo, dir := initTestRepo(t)
+
+	// The Edit's new_string is only in the transcript; context.md shows the
+	// file it edited. The response's own heading must not start a turn.
+	transcript := `{"type":"user","timestamp":"2025-01-15T10:00:00Z","message":"Rename the handler"}
+{"type":"assistant","timestamp":"2025-01-15T10:00:05Z","requestId":"req-1","usage":{"input_tokens":10,"output_tokens":5},"message":{"content":[{"type":"text","text":"Plan:\n## Turn 9\nrename it"},{"type":"tool_use","id":"toolu_1","name":"Edit","input":{"file_path":"/work/main.go","old_string":"func handle()","new_string":"func serveWidgets()"}}]}}
+`
+	meta := checkpoint.NewMetadata("cccccccccccc", "abc123", "main", "Tester <tester@example.com>", "Rename", "manual-commit")
+	meta.Sessions = []types.SessionSummary{{Index: 0, AgentName: "claude-code", SessionID: "sess-3"}}
+	bundle := checkpoint.SessionBundle{
+		Metadata:       &types.SessionMetadata{AgentName: "claude-code", SessionID: "sess-3"},
+		Session:        &types.SessionData{ID: "sess-3", AgentName: "claude-code"},
+		FullTranscript: []byte(transcript),
+	}
+	require.NoError(t, checkpoint.NewStore(repo).Create(meta, []checkpoint.SessionBundle{bundle}))
+
+	idx := openUpdated(t, repo, dir)
+
+	results := idx.Search(Query{Text: "servewidgets"})
+	require.Len(t, results, 1)
+	assert.Equal(t, "tool", results[0].Kind)
+	assert.Equal(t, "Edit", results[0].Tool)
+	assert.Equal(t, 1, results[0].Turn)
+	assert.Contains(t, results[0].Snippet, "func serveWidgets()")
+
+	results = idx.Search(Query{Text: "rename it"})
+	require.Len(t, results, 1)
+	assert.Equal(t, "response", results[0].Kind)
+	assert.Equal(t, 1, results[0].Turn)
+}
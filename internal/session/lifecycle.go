@@ -0,0 +1,116 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// transitions lists the phases a session may move to from each phase.
+// A session runs ACTIVE -> IDLE between turns and ENDED when the agent
+// closes it; CONDENSED once its snapshots are in a checkpoint. Resuming a
+// session makes it ACTIVE again from any phase.
+var transitions = map[types.SessionPhase][]types.SessionPhase{
+	types.SessionActive:    {types.SessionIdle, types.SessionEnded},
+	types.SessionIdle:      {types.SessionActive, types.SessionEnded, types.SessionCondensed},
+	types.SessionEnded:     {types.SessionActive, types.SessionCondensed},
+	types.SessionCondensed: {types.SessionActive},
+}
+
+// CanTransition reports whether a session may move from one phase to
+// another. Staying in the same phase is always allowed.
+func CanTransition(from, to types.SessionPhase) bool {
+	if from == to {
+		return true
+	}
+	for _, p := range transitions[from] {
+		if p == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transition moves a session to a phase, recording the activity.
+func (sess *Session) transition(to types.SessionPhase, now time.Time) error {
+	if !CanTransition(sess.Phase, to) {
+		return fmt.Errorf("session %s cannot go from %s to %s", sess.ID, sess.Phase, to)
+	}
+	sess.Phase = to
+	sess.LastActivity = now
+	return nil
+}
+
+// Problem is why doctor flags a session.
+type Problem string
+
+const (
+	// ProblemProcessGone is a live session whose agent process has exited.
+	ProblemProcessGone Problem = "process gone"
+	// ProblemTranscriptStale is a live session with no known process and
+	// no recent activity.
+	ProblemTranscriptStale Problem = "transcript stale"
+	// ProblemNeverCondensed is a finished session whose snapshots were never
+	// condensed into a checkpoint.
+	ProblemNeverCondensed Problem = "never condensed"
+)
+
+// StaleAfter is how long a session without a known agent process may go
+// without activity before it is considered abandoned.
+const StaleAfter = time.Hour
+
+// Diagnosis is a problem found with a session.
+type Diagnosis struct {
+	Session Session
+	Problem Problem
+	Detail  string
+}
+
+// Diagnose checks the tracked sessions for problems. A live session is
+// checked against its agent process when one was recorded, and against
+// its last activity otherwise.
+func (s *Store) Diagnose(now time.Time) []Diagnosis {
+	var found []Diagnosis
+	for _, sess := range s.state.Sessions {
+		finished := sess.Phase == types.SessionEnded
+		switch sess.Phase {
+		case types.SessionActive, types.SessionIdle:
+			alive, known := processAlive(sess.PID, sess.PIDStartTime)
+			if known {
+				if !alive {
+					found = append(found, Diagnosis{sess, ProblemProcessGone,
+						fmt.Sprintf("agent process %d has exited", sess.PID)})
+					finished = true
+				}
+				break
+			}
+			if idle := now.Sub(lastActivity(sess)); idle > StaleAfter {
+				found = append(found, Diagnosis{sess, ProblemTranscriptStale,
+					fmt.Sprintf("no activity for %s", idle.Round(time.Minute))})
+				finished = true
+			}
+		}
+		if finished && sess.ShadowBranch != "" {
+			found = append(found, Diagnosis{sess, ProblemNeverCondensed,
+				fmt.Sprintf("snapshots on %s were never condensed into a checkpoint", sess.ShadowBranch)})
+		}
+	}
+	return found
+}
+
+// lastActivity returns when a session was last heard from, including
+// writes to its transcript.
+func lastActivity(sess Session) time.Time {
+	last := sess.StartedAt
+	if sess.LastActivity.After(last) {
+		last = sess.LastActivity
+	}
+	if sess.TranscriptPath != "" {
+		if info, err := os.Stat(sess.TranscriptPath); err == nil && info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last
+}
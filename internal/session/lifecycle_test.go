@@ -0,0 +1,90 @@
+package session
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, CanTransition(types.SessionActive, types.SessionIdle))
+	assert.True(t, CanTransition(types.SessionIdle, types.SessionActive))
+	assert.True(t, CanTransition(types.SessionIdle, types.SessionEnded))
+	assert.True(t, CanTransition(types.SessionEnded, types.SessionCondensed))
+	assert.True(t, CanTransition(types.SessionCondensed, types.SessionActive))
+	assert.True(t, CanTransition(types.SessionEnded, types.SessionEnded))
+
+	assert.False(t, CanTransition(types.SessionActive, types.SessionCondensed))
+	assert.False(t, CanTransition(types.SessionCondensed, types.SessionIdle))
+	assert.False(t, CanTransition(types.SessionCondensed, types.SessionEnded))
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.StartSession("sess-1", "claude-code"))
+	require.NoError(t, store.IdleSession("sess-1"))
+	s, _ := store.GetSession("sess-1")
+	assert.Equal(t, types.SessionIdle, s.Phase)
+	assert.False(t, s.LastActivity.IsZero())
+
+	// A session mid-turn is not condensed
+	require.NoError(t, store.StartSession("sess-1", "claude-code"))
+	require.NoError(t, store.CondenseSession("sess-1"))
+	s, _ = store.GetSession("sess-1")
+	assert.Equal(t, types.SessionActive, s.Phase)
+	assert.NotNil(t, s.CondensedAt)
+
+	require.NoError(t, store.EndSession("sess-1"))
+	require.NoError(t, store.CondenseSession("sess-1"))
+	s, _ = store.GetSession("sess-1")
+	assert.Equal(t, types.SessionCondensed, s.Phase)
+
+	// Ending a condensed session keeps it condensed
+	require.NoError(t, store.EndSession("sess-1"))
+	s, _ = store.GetSession("sess-1")
+	assert.Equal(t, types.SessionCondensed, s.Phase)
+	assert.NotNil(t, s.EndedAt)
+
+	// Only an active session can go idle
+	assert.Error(t, store.IdleSession("sess-1"))
+	assert.Error(t, store.IdleSession("unknown"))
+}
+
+func TestDiagnose(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no /proc")
+	}
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	now := time.Now()
+
+	self, err := ReadProcess(os.Getpid())
+	require.NoError(t, err)
+	store.state.Sessions = []Session{
+		// Running, however long ago it was last heard from
+		{ID: "alive", Phase: types.SessionIdle, StartedAt: now.Add(-5 * time.Hour), PID: self.PID, PIDStartTime: self.StartTime},
+		// The PID now belongs to a different process
+		{ID: "gone", Phase: types.SessionActive, StartedAt: now, PID: self.PID, PIDStartTime: self.StartTime + 1},
+		{ID: "stale", Phase: types.SessionActive, StartedAt: now.Add(-3 * time.Hour), LastActivity: now.Add(-2 * time.Hour)},
+		{ID: "recent", Phase: types.SessionActive, StartedAt: now.Add(-3 * time.Hour), LastActivity: now.Add(-time.Minute)},
+		{ID: "ended", Phase: types.SessionEnded, StartedAt: now, ShadowBranch: "entire/ended-abcd"},
+		{ID: "condensed", Phase: types.SessionCondensed, StartedAt: now.Add(-5 * time.Hour)},
+	}
+
+	found := make(map[string][]Problem)
+	for _, d := range store.Diagnose(now) {
+		found[d.Session.ID] = append(found[d.Session.ID], d.Problem)
+		assert.NotEmpty(t, d.Detail)
+	}
+	assert.Equal(t, map[string][]Problem{
+		"gone":  {ProblemProcessGone},
+		"stale": {ProblemTranscriptStale},
+		"ended": {ProblemNeverCondensed},
+	}, found)
+}
@@ -0,0 +1,98 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procDir is where process information is read from.
+var procDir = "/proc"
+
+// Process identifies a running process. A PID is reused once its process
+// exits, so the start time is kept with it to tell the two apart.
+type Process struct {
+	PID  int
+	PPID int
+	Name string
+	// StartTime is when the process started, in clock ticks since boot.
+	StartTime uint64
+}
+
+// ReadProcess reads a process from /proc/<pid>/stat.
+func ReadProcess(pid int) (*Process, error) {
+	data, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil, err
+	}
+	return parseStat(string(data))
+}
+
+// parseStat parses the contents of /proc/<pid>/stat. The command name is
+// in parentheses and may itself contain spaces and parentheses, so the
+// fields are counted from the last closing one.
+func parseStat(stat string) (*Process, error) {
+	open := strings.IndexByte(stat, '(')
+	end := strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return nil, fmt.Errorf("malformed process stat")
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(stat[:open]))
+	if err != nil {
+		return nil, fmt.Errorf("malformed process stat: %w", err)
+	}
+
+	// Fields from the third on: state, ppid, ... starttime is the 22nd
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 20 {
+		return nil, fmt.Errorf("malformed process stat: %d fields", len(fields)+2)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed process stat: %w", err)
+	}
+	start, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed process stat: %w", err)
+	}
+	return &Process{PID: pid, PPID: ppid, Name: stat[open+1 : end], StartTime: start}, nil
+}
+
+// shells are the programs an agent may run its hooks through.
+var shells = map[string]bool{"sh": true, "bash": true, "dash": true, "zsh": true, "fish": true}
+
+// AgentProcess returns the process an agent's hook was started from: the
+// nearest process from pid up that is not a shell. It fails where /proc
+// is not available.
+func AgentProcess(pid int) (*Process, error) {
+	for range 8 {
+		p, err := ReadProcess(pid)
+		if err != nil {
+			return nil, err
+		}
+		if !shells[p.Name] || p.PPID <= 1 {
+			return p, nil
+		}
+		pid = p.PPID
+	}
+	return nil, fmt.Errorf("no agent process above %d", pid)
+}
+
+// processAlive reports whether the process that had pid and start time is
+// still running. known is false when that cannot be told, as on systems
+// without /proc.
+func processAlive(pid int, startTime uint64) (alive, known bool) {
+	if pid <= 0 {
+		return false, false
+	}
+	if _, err := os.Stat(filepath.Join(procDir, "self", "stat")); err != nil {
+		return false, false
+	}
+	p, err := ReadProcess(pid)
+	if err != nil {
+		return false, true
+	}
+	return p.StartTime == startTime, true
+}
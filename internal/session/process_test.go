@@ -0,0 +1,41 @@
+package session
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStat(t *testing.T) {
+	stat := "4242 (my (odd) cmd) S 17 4242 4242 0 -1 4194560 100 0 0 0 5 3 0 0 20 0 1 0 987654 1000 100 18446744073709551615\n"
+	p, err := parseStat(stat)
+	require.NoError(t, err)
+	assert.Equal(t, 4242, p.PID)
+	assert.Equal(t, 17, p.PPID)
+	assert.Equal(t, "my (odd) cmd", p.Name)
+	assert.Equal(t, uint64(987654), p.StartTime)
+
+	_, err = parseStat("4242 (short) S 1 2")
+	assert.Error(t, err)
+	_, err = parseStat("garbage")
+	assert.Error(t, err)
+}
+
+func TestProcessAlive(t *testing.T) {
+	self, err := ReadProcess(os.Getpid())
+	if err != nil {
+		t.Skip("no /proc")
+	}
+	alive, known := processAlive(self.PID, self.StartTime)
+	assert.True(t, known)
+	assert.True(t, alive)
+
+	alive, known = processAlive(self.PID, self.StartTime+1)
+	assert.True(t, known)
+	assert.False(t, alive)
+
+	_, known = processAlive(0, 0)
+	assert.False(t, known)
+}
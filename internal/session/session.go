@@ -14,4 +14,19 @@ type Session struct {
 	Phase     types.SessionPhase `json:"phase"`
 	StartedAt time.Time          `json:"started_at"`
 	EndedAt   *time.Time         `json:"ended_at,omitempty"`
+	// LastActivity is when the session last changed phase or took a turn.
+	LastActivity time.Time `json:"last_activity"`
+	// PID and PIDStartTime identify the agent process running the session,
+	// when its hooks reported it. The start time, in clock ticks since
+	// boot, tells the process apart from a later one reusing the PID.
+	PID          int    `json:"pid,omitempty"`
+	PIDStartTime uint64 `json:"pid_start_time,omitempty"`
+	// CondensedAt is when the session's snapshots were last condensed into
+	// a checkpoint.
+	CondensedAt *time.Time `json:"condensed_at,omitempty"`
+	// TranscriptPath is where the agent reported the session's transcript.
+	TranscriptPath string `json:"transcript_path,omitempty"`
+	// ShadowBranch holds the session's per-turn snapshots until they are
+	// condensed into a checkpoint.
+	ShadowBranch string `json:"shadow_branch,omitempty"`
 }
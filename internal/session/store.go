@@ -2,10 +2,12 @@ package session
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/yibudak/open-entire/internal/lockfile"
 	"github.com/yibudak/open-entire/pkg/types"
 )
 
@@ -44,42 +46,158 @@ func (s *Store) ActiveSessions() []Session {
 	return active
 }
 
-// StuckSessions returns sessions that appear stuck (ACTIVE for > 1 hour with no agent process).
-func (s *Store) StuckSessions() []Session {
-	var stuck []Session
-	threshold := time.Now().Add(-1 * time.Hour)
+// LiveSessions returns the sessions that have not ended: those working on a
+// turn and those waiting for the next prompt.
+func (s *Store) LiveSessions() []Session {
+	var live []Session
 	for _, sess := range s.state.Sessions {
-		if sess.Phase == types.SessionActive && sess.StartedAt.Before(threshold) {
-			stuck = append(stuck, sess)
+		if sess.Phase == types.SessionActive || sess.Phase == types.SessionIdle {
+			live = append(live, sess)
 		}
 	}
-	return stuck
+	return live
 }
 
-// StartSession records a new active session.
+// StartSession records a new active session. Starting a session that is
+// already tracked, e.g. when it is resumed, makes it active again.
 func (s *Store) StartSession(id, agentName string) error {
-	sess := Session{
-		ID:        id,
-		AgentName: agentName,
-		RepoDir:   s.repoDir,
-		Phase:     types.SessionActive,
-		StartedAt: time.Now(),
-	}
-	s.state.Sessions = append(s.state.Sessions, sess)
-	return s.save()
+	return s.update(func() error {
+		now := time.Now()
+		if sess := s.find(id); sess != nil {
+			if err := sess.transition(types.SessionActive, now); err != nil {
+				return err
+			}
+			sess.EndedAt = nil
+			return nil
+		}
+		s.state.Sessions = append(s.state.Sessions, Session{
+			ID:           id,
+			AgentName:    agentName,
+			RepoDir:      s.repoDir,
+			Phase:        types.SessionActive,
+			StartedAt:    now,
+			LastActivity: now,
+		})
+		return nil
+	})
 }
 
-// EndSession marks a session as ended.
-func (s *Store) EndSession(id string) error {
-	now := time.Now()
-	for i, sess := range s.state.Sessions {
-		if sess.ID == id {
-			s.state.Sessions[i].Phase = types.SessionEnded
-			s.state.Sessions[i].EndedAt = &now
-			return s.save()
+// IdleSession marks the end of a session's turn.
+func (s *Store) IdleSession(id string) error {
+	return s.update(func() error {
+		sess := s.find(id)
+		if sess == nil {
+			return fmt.Errorf("session %s is not tracked", id)
 		}
+		return sess.transition(types.SessionIdle, time.Now())
+	})
+}
+
+// SetTranscriptPath records where the agent keeps a session's transcript.
+func (s *Store) SetTranscriptPath(id, path string) error {
+	if sess := s.find(id); sess != nil && sess.TranscriptPath == path {
+		return nil
 	}
-	return nil
+	return s.update(func() error {
+		sess := s.find(id)
+		if sess == nil {
+			return fmt.Errorf("session %s is not tracked", id)
+		}
+		sess.TranscriptPath = path
+		return nil
+	})
+}
+
+// SetProcess records the agent process running a session, so its liveness
+// can be checked exactly.
+func (s *Store) SetProcess(id string, p *Process) error {
+	if sess := s.find(id); sess != nil && sess.PID == p.PID && sess.PIDStartTime == p.StartTime {
+		return nil
+	}
+	return s.update(func() error {
+		sess := s.find(id)
+		if sess == nil {
+			return fmt.Errorf("session %s is not tracked", id)
+		}
+		sess.PID, sess.PIDStartTime = p.PID, p.StartTime
+		return nil
+	})
+}
+
+// Sessions returns every tracked session.
+func (s *Store) Sessions() []Session {
+	return s.state.Sessions
+}
+
+// EndSession marks a session as ended. A condensed session stays
+// condensed, since nothing it did is left out of a checkpoint.
+func (s *Store) EndSession(id string) error {
+	return s.update(func() error {
+		sess := s.find(id)
+		if sess == nil {
+			return nil
+		}
+		now := time.Now()
+		if sess.Phase != types.SessionCondensed {
+			if err := sess.transition(types.SessionEnded, now); err != nil {
+				return err
+			}
+		}
+		sess.EndedAt = &now
+		return nil
+	})
+}
+
+// SetShadowBranch records the shadow branch a session's snapshots go to,
+// starting the session if it is not tracked yet. Snapshots are taken at
+// the end of a turn, so the session is left idle.
+func (s *Store) SetShadowBranch(id, agentName, branch string) error {
+	return s.update(func() error {
+		now := time.Now()
+		sess := s.find(id)
+		if sess == nil {
+			s.state.Sessions = append(s.state.Sessions, Session{
+				ID:           id,
+				AgentName:    agentName,
+				RepoDir:      s.repoDir,
+				Phase:        types.SessionIdle,
+				StartedAt:    now,
+				LastActivity: now,
+				ShadowBranch: branch,
+			})
+			return nil
+		}
+
+		sess.ShadowBranch = branch
+		if sess.Phase != types.SessionIdle && sess.Phase != types.SessionActive {
+			if err := sess.transition(types.SessionActive, now); err != nil {
+				return err
+			}
+			sess.EndedAt = nil
+		}
+		return sess.transition(types.SessionIdle, now)
+	})
+}
+
+// CondenseSession marks a session's snapshots as condensed into a
+// checkpoint and forgets its shadow branch. A session in the middle of a
+// turn stays active; the rest of the turn goes to a new shadow branch.
+func (s *Store) CondenseSession(id string) error {
+	return s.update(func() error {
+		sess := s.find(id)
+		if sess == nil {
+			return nil
+		}
+		now := time.Now()
+		if sess.Phase != types.SessionActive {
+			if err := sess.transition(types.SessionCondensed, now); err != nil {
+				return err
+			}
+		}
+		sess.CondensedAt = &now
+		sess.ShadowBranch = ""
+		return nil
+	})
 }
 
 // GetSession returns a session by ID.
@@ -92,22 +210,64 @@ func (s *Store) GetSession(id string) (*Session, bool) {
 	return nil, false
 }
 
+// find returns the stored session with an ID, or nil.
+func (s *Store) find(id string) *Session {
+	for i := range s.state.Sessions {
+		if s.state.Sessions[i].ID == id {
+			return &s.state.Sessions[i]
+		}
+	}
+	return nil
+}
+
 func (s *Store) load() error {
 	data, err := os.ReadFile(s.path)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, &s.state)
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	s.state = state
+	return nil
 }
 
-func (s *Store) save() error {
-	dir := filepath.Dir(s.path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+// update applies a change to the state on disk under a lock, starting from
+// what is there now rather than what was loaded. Agent hooks and the
+// backgrounded post-commit hook update the state from separate processes.
+func (s *Store) update(change func() error) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
 		return err
 	}
+	unlock, err := lockfile.Acquire(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.load(); err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		s.state = State{}
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// save writes the state to a temporary file and renames it into place, so
+// readers never see it half-written.
+func (s *Store) save() error {
 	data, err := json.MarshalIndent(s.state, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(s.path, data, 0o644)
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
 }
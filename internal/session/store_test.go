@@ -1,6 +1,8 @@
 package session
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -55,3 +57,77 @@ func TestSessionStorePersistence(t *testing.T) {
 	assert.True(t, found)
 	assert.Equal(t, "sess-abc", s.ID)
 }
+
+func TestSessionShadowBranch(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewStore(dir)
+	require.NoError(t, err)
+
+	// An untracked session is started by its first snapshot, which ends a
+	// turn
+	require.NoError(t, store.SetShadowBranch("sess-1", "claude-code", "entire/sess-1-abcd"))
+	s, found := store.GetSession("sess-1")
+	require.True(t, found)
+	assert.Equal(t, types.SessionIdle, s.Phase)
+	assert.Equal(t, "entire/sess-1-abcd", s.ShadowBranch)
+
+	require.NoError(t, store.CondenseSession("sess-1"))
+	s, _ = store.GetSession("sess-1")
+	assert.Equal(t, types.SessionCondensed, s.Phase)
+	assert.Empty(t, s.ShadowBranch)
+	assert.NotNil(t, s.CondensedAt)
+
+	// Another turn brings it back
+	require.NoError(t, store.SetShadowBranch("sess-1", "claude-code", "entire/sess-1-abcd"))
+	s, _ = store.GetSession("sess-1")
+	assert.Equal(t, types.SessionIdle, s.Phase)
+	assert.Len(t, store.state.Sessions, 1)
+}
+
+func TestStartSessionResumes(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	assert.Error(t, store.SetTranscriptPath("sess-1", "/tmp/sess-1.jsonl"))
+
+	require.NoError(t, store.StartSession("sess-1", "claude-code"))
+	require.NoError(t, store.SetTranscriptPath("sess-1", "/tmp/sess-1.jsonl"))
+	require.NoError(t, store.StartSession("sess-1", "claude-code"))
+	assert.Len(t, store.Sessions(), 1)
+
+	// A resumed session is active again
+	require.NoError(t, store.EndSession("sess-1"))
+	require.NoError(t, store.StartSession("sess-1", "claude-code"))
+	s, found := store.GetSession("sess-1")
+	require.True(t, found)
+	assert.Equal(t, types.SessionActive, s.Phase)
+	assert.Nil(t, s.EndedAt)
+	assert.Equal(t, "/tmp/sess-1.jsonl", s.TranscriptPath)
+}
+
+func TestSessionStoreConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+
+	// Each hook process loads the state before the others have written
+	const n = 20
+	stores := make([]*Store, n)
+	for i := range stores {
+		store, err := NewStore(dir)
+		require.NoError(t, err)
+		stores[i] = store
+	}
+	var wg sync.WaitGroup
+	for i, store := range stores {
+		wg.Add(1)
+		go func(i int, store *Store) {
+			defer wg.Done()
+			assert.NoError(t, store.StartSession(fmt.Sprintf("sess-%d", i), "claude-code"))
+		}(i, store)
+	}
+	wg.Wait()
+
+	store, err := NewStore(dir)
+	require.NoError(t, err)
+	assert.Len(t, store.Sessions(), n)
+}
@@ -0,0 +1,46 @@
+package stats
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// csvHeader names the columns WriteCSV writes.
+var csvHeader = []string{
+	"period", "group", "checkpoints", "sessions", "prompts", "commits",
+	"input_tokens", "output_tokens", "cache_write_tokens", "cache_read_tokens",
+	"cost_usd", "agent_percent", "prompts_per_commit", "cache_hit_ratio",
+}
+
+// WriteCSV writes a report's rows as CSV, one line per period and group.
+func WriteCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		u := row.TokenUsage
+		record := []string{
+			row.Period,
+			row.Group,
+			strconv.Itoa(row.Checkpoints),
+			strconv.Itoa(row.Sessions),
+			strconv.Itoa(row.Prompts),
+			strconv.Itoa(row.Commits),
+			strconv.Itoa(u.InputTokens),
+			strconv.Itoa(u.OutputTokens),
+			strconv.Itoa(u.CacheCreation),
+			strconv.Itoa(u.CacheReads),
+			strconv.FormatFloat(u.CostUSD, 'f', 4, 64),
+			strconv.FormatFloat(row.AgentPercent, 'f', 1, 64),
+			strconv.FormatFloat(row.PromptsPerCommit, 'f', 2, 64),
+			strconv.FormatFloat(row.CacheHitRatio, 'f', 3, 64),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
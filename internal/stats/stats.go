@@ -0,0 +1,269 @@
+// Package stats aggregates checkpoint metadata into usage reports.
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/pricing"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// Interval is the length of the periods a report is split into.
+type Interval string
+
+const (
+	Day  Interval = "day"
+	Week Interval = "week"
+)
+
+// ParseInterval validates an interval given by name.
+func ParseInterval(name string) (Interval, error) {
+	switch i := Interval(name); i {
+	case Day, Week:
+		return i, nil
+	}
+	return "", fmt.Errorf("unknown interval %q (use day or week)", name)
+}
+
+// Start returns the start of the period t falls in, in t's location.
+// Weeks start on Monday.
+func (i Interval) Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if i == Week {
+		offset := (int(day.Weekday()) + 6) % 7
+		day = day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// Options selects what a report covers.
+type Options struct {
+	Interval Interval
+	// GroupBy splits each period by author, branch or agent; empty keeps
+	// periods whole.
+	GroupBy pricing.GroupBy
+	// Since and Until bound the checkpoints' creation time; zero values
+	// leave that side open.
+	Since, Until time.Time
+	// TopFiles is how many of the files agents wrote most to list.
+	TopFiles int
+}
+
+// Row is the activity of one period and group, or a total of them.
+type Row struct {
+	// Period is the first day of the period, as 2006-01-02. Totals have
+	// none.
+	Period      string           `json:"period,omitempty"`
+	Group       string           `json:"group,omitempty"`
+	Checkpoints int              `json:"checkpoints"`
+	Sessions    int              `json:"sessions"`
+	Prompts     int              `json:"prompts"`
+	Commits     int              `json:"commits"`
+	TokenUsage  types.TokenUsage `json:"token_usage"`
+	// AgentPercent is the mean agent attribution of the checkpoints that
+	// have one.
+	AgentPercent     float64 `json:"agent_percent"`
+	PromptsPerCommit float64 `json:"prompts_per_commit"`
+	// CacheHitRatio is the share of input tokens read from the prompt
+	// cache: CacheReads / (CacheReads + InputTokens).
+	CacheHitRatio float64 `json:"cache_hit_ratio"`
+}
+
+// FileStat is how much agents wrote to one file.
+type FileStat struct {
+	Path        string `json:"path"`
+	AgentLines  int    `json:"agent_lines"`
+	Checkpoints int    `json:"checkpoints"`
+}
+
+// Report is usage over time.
+type Report struct {
+	Interval Interval        `json:"interval"`
+	GroupBy  pricing.GroupBy `json:"group_by,omitempty"`
+	// Rows has one row per period, or per period and group, oldest first.
+	Rows []Row `json:"rows"`
+	// Groups totals each group over all periods, when grouped.
+	Groups   []Row      `json:"groups,omitempty"`
+	Total    Row        `json:"total"`
+	TopFiles []FileStat `json:"top_files"`
+}
+
+// Build aggregates checkpoints into a report.
+func Build(checkpoints []*types.CheckpointMetadata, opts Options) *Report {
+	if opts.Interval == "" {
+		opts.Interval = Day
+	}
+	report := &Report{Interval: opts.Interval, GroupBy: opts.GroupBy}
+
+	rows := make(map[[2]string]*accumulator)
+	groups := make(map[string]*accumulator)
+	total := newAccumulator("", "")
+	files := make(map[string]*FileStat)
+
+	for _, cp := range checkpoints {
+		if cp.CreatedAt.Before(opts.Since) || (!opts.Until.IsZero() && !cp.CreatedAt.Before(opts.Until)) {
+			continue
+		}
+		period := opts.Interval.Start(cp.CreatedAt.Local()).Format("2006-01-02")
+
+		// The sessions of each group; a checkpoint without sessions still
+		// counts, under its author or branch
+		bySession := make(map[string][]types.SessionSummary)
+		var keys []string
+		if opts.GroupBy == "" {
+			keys = []string{""}
+			bySession[""] = cp.Sessions
+		} else {
+			for _, s := range cp.Sessions {
+				key := opts.GroupBy.Key(cp, s)
+				if _, ok := bySession[key]; !ok {
+					keys = append(keys, key)
+				}
+				bySession[key] = append(bySession[key], s)
+			}
+			if len(keys) == 0 && opts.GroupBy != pricing.ByAgent {
+				key := opts.GroupBy.Key(cp, types.SessionSummary{})
+				keys = []string{key}
+			}
+		}
+
+		for _, key := range keys {
+			id := [2]string{period, key}
+			if rows[id] == nil {
+				rows[id] = newAccumulator(period, key)
+			}
+			rows[id].add(cp, bySession[key])
+			if opts.GroupBy != "" {
+				if groups[key] == nil {
+					groups[key] = newAccumulator("", key)
+				}
+				groups[key].add(cp, bySession[key])
+			}
+		}
+		total.add(cp, cp.Sessions)
+
+		if cp.Attribution != nil {
+			for _, f := range cp.Attribution.Files {
+				if f.AgentLines == 0 {
+					continue
+				}
+				fs := files[f.Path]
+				if fs == nil {
+					fs = &FileStat{Path: f.Path}
+					files[f.Path] = fs
+				}
+				fs.AgentLines += f.AgentLines
+				fs.Checkpoints++
+			}
+		}
+	}
+
+	for _, a := range rows {
+		report.Rows = append(report.Rows, a.row())
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Period != report.Rows[j].Period {
+			return report.Rows[i].Period < report.Rows[j].Period
+		}
+		return report.Rows[i].Group < report.Rows[j].Group
+	})
+	for _, a := range groups {
+		report.Groups = append(report.Groups, a.row())
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].Checkpoints != report.Groups[j].Checkpoints {
+			return report.Groups[i].Checkpoints > report.Groups[j].Checkpoints
+		}
+		return report.Groups[i].Group < report.Groups[j].Group
+	})
+	report.Total = total.row()
+
+	report.TopFiles = []FileStat{}
+	for _, f := range files {
+		report.TopFiles = append(report.TopFiles, *f)
+	}
+	sort.Slice(report.TopFiles, func(i, j int) bool {
+		if report.TopFiles[i].AgentLines != report.TopFiles[j].AgentLines {
+			return report.TopFiles[i].AgentLines > report.TopFiles[j].AgentLines
+		}
+		return report.TopFiles[i].Path < report.TopFiles[j].Path
+	})
+	if opts.TopFiles > 0 && len(report.TopFiles) > opts.TopFiles {
+		report.TopFiles = report.TopFiles[:opts.TopFiles]
+	}
+	return report
+}
+
+// accumulator sums the checkpoints of one row.
+type accumulator struct {
+	r          Row
+	commits    map[string]bool
+	percentSum float64
+	percentN   int
+}
+
+func newAccumulator(period, group string) *accumulator {
+	return &accumulator{r: Row{Period: period, Group: group}, commits: make(map[string]bool)}
+}
+
+func (a *accumulator) add(cp *types.CheckpointMetadata, sessions []types.SessionSummary) {
+	a.r.Checkpoints++
+	a.r.Sessions += len(sessions)
+	for _, s := range sessions {
+		a.r.Prompts += s.Prompts
+		u := &a.r.TokenUsage
+		u.InputTokens += s.TokenUsage.InputTokens
+		u.OutputTokens += s.TokenUsage.OutputTokens
+		u.CacheCreation += s.TokenUsage.CacheCreation
+		u.CacheReads += s.TokenUsage.CacheReads
+		u.APICalls += s.TokenUsage.APICalls
+		u.CostUSD += s.TokenUsage.CostUSD
+	}
+	if cp.CommitHash != "" {
+		a.commits[cp.CommitHash] = true
+	}
+	if cp.Attribution != nil && cp.Attribution.TotalLines > 0 {
+		a.percentSum += cp.Attribution.AgentPercent
+		a.percentN++
+	}
+}
+
+func (a *accumulator) row() Row {
+	r := a.r
+	r.Commits = len(a.commits)
+	if a.percentN > 0 {
+		r.AgentPercent = a.percentSum / float64(a.percentN)
+	}
+	if r.Commits > 0 {
+		r.PromptsPerCommit = float64(r.Prompts) / float64(r.Commits)
+	}
+	if read := r.TokenUsage.CacheReads + r.TokenUsage.InputTokens; read > 0 {
+		r.CacheHitRatio = float64(r.TokenUsage.CacheReads) / float64(read)
+	}
+	return r
+}
+
+// Load lists every checkpoint for a report. Checkpoints made before
+// prompts were counted in their metadata have them counted from the
+// stored prompts.
+func Load(store *checkpoint.Store) ([]*types.CheckpointMetadata, error) {
+	checkpoints, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, cp := range checkpoints {
+		for i := range cp.Sessions {
+			s := &cp.Sessions[i]
+			if s.Prompts > 0 {
+				continue
+			}
+			if prompts, err := store.Prompts(cp.ID, s.Index); err == nil {
+				s.Prompts = len(prompts)
+			}
+		}
+	}
+	return checkpoints, nil
+}
@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/internal/pricing"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func testCheckpoints() []*types.CheckpointMetadata {
+	at := func(day int) time.Time { return time.Date(2025, 1, day, 12, 0, 0, 0, time.Local) }
+	usage := types.TokenUsage{InputTokens: 100, OutputTokens: 50, CacheReads: 300, CostUSD: 0.5}
+	return []*types.CheckpointMetadata{
+		// Monday 13th and Tuesday 14th fall in one week
+		{ID: "a", CommitHash: "c1", Author: "Ada", Branch: "main", CreatedAt: at(13),
+			Attribution: &types.Attribution{AgentPercent: 80, AgentLines: 8, TotalLines: 10,
+				Files: []types.FileAttribution{{Path: "main.go", AgentLines: 8, HumanLines: 2}}},
+			Sessions: []types.SessionSummary{
+				{AgentName: "claude-code", Prompts: 3, TokenUsage: usage},
+				{AgentName: "codex", Prompts: 1, TokenUsage: usage},
+			}},
+		{ID: "b", CommitHash: "c2", Author: "Bob", Branch: "feat", CreatedAt: at(14),
+			Attribution: &types.Attribution{AgentPercent: 40, AgentLines: 4, TotalLines: 10,
+				Files: []types.FileAttribution{{Path: "main.go", AgentLines: 2}, {Path: "util.go", AgentLines: 2}, {Path: "README.md", HumanLines: 6}}},
+			Sessions: []types.SessionSummary{{AgentName: "claude-code", Prompts: 2, TokenUsage: usage}}},
+		{ID: "c", CommitHash: "c3", Author: "Ada", Branch: "main", CreatedAt: at(20),
+			Sessions: []types.SessionSummary{{AgentName: "claude-code", Prompts: 1, TokenUsage: usage}}},
+	}
+}
+
+func TestBuildByDay(t *testing.T) {
+	r := Build(testCheckpoints(), Options{Interval: Day})
+	require.Len(t, r.Rows, 3)
+	assert.Equal(t, "2025-01-13", r.Rows[0].Period)
+	assert.Equal(t, 2, r.Rows[0].Sessions)
+	assert.Equal(t, 4, r.Rows[0].Prompts)
+	assert.Equal(t, "2025-01-20", r.Rows[2].Period)
+
+	total := r.Total
+	assert.Equal(t, 3, total.Checkpoints)
+	assert.Equal(t, 3, total.Commits)
+	assert.Equal(t, 7, total.Prompts)
+	assert.InDelta(t, 7.0/3, total.PromptsPerCommit, 1e-9)
+	assert.InDelta(t, 60, total.AgentPercent, 1e-9) // mean of 80 and 40
+	assert.InDelta(t, 0.75, total.CacheHitRatio, 1e-9)
+	assert.InDelta(t, 2.0, total.TokenUsage.CostUSD, 1e-9)
+
+	require.Len(t, r.TopFiles, 2)
+	assert.Equal(t, FileStat{Path: "main.go", AgentLines: 10, Checkpoints: 2}, r.TopFiles[0])
+	assert.Equal(t, "util.go", r.TopFiles[1].Path)
+}
+
+func TestBuildByWeekAndAgent(t *testing.T) {
+	r := Build(testCheckpoints(), Options{Interval: Week, GroupBy: pricing.ByAgent})
+	require.Len(t, r.Rows, 3)
+	assert.Equal(t, "2025-01-13", r.Rows[0].Period)
+	assert.Equal(t, "claude-code", r.Rows[0].Group)
+	assert.Equal(t, 2, r.Rows[0].Checkpoints)
+	assert.Equal(t, 5, r.Rows[0].Prompts)
+	assert.Equal(t, "codex", r.Rows[1].Group)
+	assert.Equal(t, "2025-01-20", r.Rows[2].Period)
+
+	require.Len(t, r.Groups, 2)
+	assert.Equal(t, "claude-code", r.Groups[0].Group)
+	assert.Equal(t, 3, r.Groups[0].Checkpoints)
+}
+
+func TestBuildSinceUntil(t *testing.T) {
+	since := time.Date(2025, 1, 14, 0, 0, 0, 0, time.Local)
+	until := time.Date(2025, 1, 15, 0, 0, 0, 0, time.Local)
+	r := Build(testCheckpoints(), Options{Since: since, Until: until, GroupBy: pricing.ByAuthor})
+	require.Len(t, r.Rows, 1)
+	assert.Equal(t, "Bob", r.Rows[0].Group)
+}
+
+func TestWeekStart(t *testing.T) {
+	sunday := time.Date(2025, 1, 19, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC), Week.Start(sunday))
+	assert.Equal(t, time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC), Day.Start(sunday))
+
+	_, err := ParseInterval("month")
+	assert.Error(t, err)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, Build(testCheckpoints(), Options{Interval: Week})))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, "2025-01-13", records[1][0])
+	assert.Equal(t, "2", records[1][2])
+	assert.Equal(t, "1.5000", records[1][10])
+}
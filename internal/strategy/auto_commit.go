@@ -4,19 +4,22 @@ import (
 	"context"
 	"log/slog"
 
+	"github.com/yibudak/open-entire/internal/agent"
 	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/config"
 	"github.com/yibudak/open-entire/internal/git"
-	"github.com/yibudak/open-entire/pkg/types"
+	"github.com/yibudak/open-entire/internal/pricing"
 )
 
 // AutoCommit creates checkpoints after each AI agent response.
 type AutoCommit struct {
 	repoDir string
+	cfg     *config.Config
 }
 
 // NewAutoCommit creates an auto-commit strategy.
-func NewAutoCommit(repoDir string) *AutoCommit {
-	return &AutoCommit{repoDir: repoDir}
+func NewAutoCommit(repoDir string, cfg *config.Config) *AutoCommit {
+	return &AutoCommit{repoDir: repoDir, cfg: cfg}
 }
 
 func (s *AutoCommit) Name() string { return "auto-commit" }
@@ -33,6 +36,24 @@ func (s *AutoCommit) OnAgentResponse(ctx context.Context, event *AgentResponseEv
 		return nil
 	}
 
+	if err := snapshotTurn(repo, event); err != nil {
+		slog.Warn("failed to snapshot agent turn", "session", event.SessionID, "error", err)
+	}
+
+	a, err := agent.Get(event.AgentName)
+	if err != nil {
+		return err
+	}
+	bundle, advance, err := parseSession(a, event.SessionID, s.repoDir, pricing.New(s.cfg.Pricing))
+	if err != nil {
+		return err
+	}
+	if isEmpty(bundle.Session) {
+		slog.Debug("nothing new in session since last checkpoint", "session", event.SessionID)
+		return nil
+	}
+	bundles := []checkpoint.SessionBundle{bundle}
+
 	id, err := checkpoint.GenerateID()
 	if err != nil {
 		return err
@@ -43,24 +64,29 @@ func (s *AutoCommit) OnAgentResponse(ctx context.Context, event *AgentResponseEv
 	author := repo.Author()
 
 	meta := checkpoint.NewMetadata(id, commitHash, branch, author, "[entire] auto checkpoint", s.Name())
+	meta.Sessions = summarize(bundles)
+	meta.CostUSD = sessionsCost(meta.Sessions)
 
-	store := checkpoint.NewStore(repo)
-	bundle := checkpoint.SessionBundle{
-		Metadata: &types.SessionMetadata{
-			AgentName: event.AgentName,
-			SessionID: event.SessionID,
-		},
+	store, err := newStore(repo, s.cfg)
+	if err != nil {
+		return err
+	}
+	if err := store.Create(meta, bundles); err != nil {
+		return err
+	}
+	if advance != nil {
+		runAdvances([]func() error{advance})
 	}
 
-	return store.Create(meta, []checkpoint.SessionBundle{bundle})
+	summarizeCheckpoint(ctx, s.cfg, store, repo, id)
+	return nil
 }
 
 func (s *AutoCommit) OnCommit(ctx context.Context, event *CommitEvent) error {
 	// Auto-commit also checkpoints on explicit commits
-	return (&ManualCommit{repoDir: s.repoDir}).OnCommit(ctx, event)
+	return NewManualCommit(s.repoDir, s.cfg).OnCommit(ctx, event)
 }
 
 func (s *AutoCommit) OnPush(ctx context.Context, event *PushEvent) error {
-	slog.Debug("auto-commit: push event")
-	return nil
+	return pushCheckpoints(s.repoDir, s.cfg, event)
 }
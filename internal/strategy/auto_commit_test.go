@@ -0,0 +1,61 @@
+package strategy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func TestAutoCommitOnAgentResponse(t *testing.T) {
+	repo := initTestRepo(t)
+	a := &deltaAgent{fakeAgent{name: "fake", sessions: map[string]*types.SessionData{"sess-1": writingSession(repo.Dir)}, done: map[string]bool{}}}
+	useAgent(t, a)
+	s := NewAutoCommit(repo.Dir, testConfig())
+	ctx := context.Background()
+	event := &AgentResponseEvent{RepoDir: repo.Dir, SessionID: "sess-1", AgentName: "fake"}
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+	require.NoError(t, s.OnAgentResponse(ctx, event))
+
+	list := checkpoints(t, repo)
+	require.Len(t, list, 1)
+	meta := list[0]
+	assert.Equal(t, "auto-commit", meta.Strategy)
+	assert.Equal(t, "[entire] auto checkpoint", meta.Message)
+	assert.Equal(t, gitCmd(t, repo.Dir, "rev-parse", "HEAD"), meta.CommitHash)
+	require.Len(t, meta.Sessions, 1)
+	assert.Equal(t, "sess-1", meta.Sessions[0].SessionID)
+	assert.InDelta(t, 4.5, meta.CostUSD, 1e-9)
+	assert.True(t, a.done["sess-1"], "the delta is marked checkpointed")
+
+	worktree, err := repo.WorktreeID()
+	require.NoError(t, err)
+	_, err = repo.BranchHead(git.ShadowBranchName("sess-1", worktree))
+	assert.NoError(t, err, "the turn is snapshotted")
+
+	// A response with nothing new adds no checkpoint
+	require.NoError(t, s.OnAgentResponse(ctx, event))
+	assert.Len(t, checkpoints(t, repo), 1)
+
+	// Nor does a commit after it, the work being checkpointed already
+	gitCmd(t, repo.Dir, "add", "main.go")
+	gitCmd(t, repo.Dir, "commit", "-q", "-m", "add main")
+	require.NoError(t, s.OnCommit(ctx, &CommitEvent{RepoDir: repo.Dir}))
+	assert.Len(t, checkpoints(t, repo), 1)
+}
+
+func TestAutoCommitUnknownAgent(t *testing.T) {
+	repo := initTestRepo(t)
+	useAgent(t, &fakeAgent{name: "fake"})
+	s := NewAutoCommit(repo.Dir, testConfig())
+
+	err := s.OnAgentResponse(context.Background(), &AgentResponseEvent{RepoDir: repo.Dir, SessionID: "sess-1", AgentName: "missing"})
+	assert.Error(t, err)
+	assert.Empty(t, checkpoints(t, repo))
+}
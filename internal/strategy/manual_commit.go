@@ -5,26 +5,37 @@ import (
 	"log/slog"
 
 	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/config"
 	"github.com/yibudak/open-entire/internal/git"
-	"github.com/yibudak/open-entire/pkg/types"
+	"github.com/yibudak/open-entire/internal/pricing"
 )
 
 // ManualCommit creates checkpoints only when the user makes a git commit.
 type ManualCommit struct {
 	repoDir string
+	cfg     *config.Config
 }
 
 // NewManualCommit creates a manual-commit strategy.
-func NewManualCommit(repoDir string) *ManualCommit {
-	return &ManualCommit{repoDir: repoDir}
+func NewManualCommit(repoDir string, cfg *config.Config) *ManualCommit {
+	return &ManualCommit{repoDir: repoDir, cfg: cfg}
 }
 
 func (s *ManualCommit) Name() string { return "manual-commit" }
 
 func (s *ManualCommit) OnAgentResponse(ctx context.Context, event *AgentResponseEvent) error {
-	// Manual strategy does not checkpoint on agent responses
-	slog.Debug("manual-commit: ignoring agent response", "session", event.SessionID)
-	return nil
+	// No checkpoint yet; the turn is snapshotted onto the session's shadow
+	// branch and condensed into the checkpoint of the next commit
+	slog.Debug("manual-commit: snapshotting agent turn", "session", event.SessionID)
+
+	repo, err := git.Open(s.repoDir)
+	if err != nil {
+		return err
+	}
+	if !repo.HasCheckpointsBranch() {
+		return nil
+	}
+	return snapshotTurn(repo, event)
 }
 
 func (s *ManualCommit) OnCommit(ctx context.Context, event *CommitEvent) error {
@@ -40,39 +51,65 @@ func (s *ManualCommit) OnCommit(ctx context.Context, event *CommitEvent) error {
 		return nil
 	}
 
-	id, err := checkpoint.GenerateID()
+	since, err := repo.LastCheckpointTime()
 	if err != nil {
 		return err
 	}
+	bundles, markCheckpointed := collectSessions(s.repoDir, since, pricing.New(s.cfg.Pricing))
+	if len(bundles) == 0 {
+		if event.CheckpointID == "" {
+			slog.Debug("no agent sessions since last checkpoint, skipping")
+			return nil
+		}
+		// The commit already carries the trailer, so it must resolve even
+		// though the sessions were checkpointed since it was reserved
+		slog.Info("no agent sessions left for reserved checkpoint, recording it without sessions", "checkpoint", event.CheckpointID)
+	}
 
-	// Resolve commit info
-	commitHash, _ := repo.HeadCommitHash()
-	branch, _ := repo.CurrentBranch()
-	message, _ := repo.LastCommitMessage()
+	id := event.CheckpointID
+	if id == "" {
+		if id, err = checkpoint.GenerateID(); err != nil {
+			return err
+		}
+	}
+
+	// Resolve commit info, preferring what the hook already knows
+	commitHash, branch, message := event.CommitHash, event.Branch, event.Message
+	if commitHash == "" {
+		commitHash, _ = repo.HeadCommitHash()
+	}
+	if branch == "" {
+		branch, _ = repo.CurrentBranch()
+	}
+	if message == "" {
+		message, _ = repo.LastCommitMessage()
+	}
 	author := repo.Author()
 
 	meta := checkpoint.NewMetadata(id, commitHash, branch, author, message, s.Name())
+	meta.Attribution, meta.Lines = attribute(repo, commitHash, bundles)
+	meta.Sessions = summarize(bundles)
+	meta.CostUSD = sessionsCost(meta.Sessions)
+	snapshots, markCondensed := condenseShadows(repo, id, bundles)
+	meta.Snapshots = snapshots
 
-	store := checkpoint.NewStore(repo)
-
-	// Create checkpoint with empty session bundle (agent parser will enrich)
-	bundle := checkpoint.SessionBundle{
-		Metadata: &types.SessionMetadata{
-			AgentName: "unknown",
-		},
+	store, err := newStore(repo, s.cfg)
+	if err != nil {
+		return err
 	}
-
-	if err := store.Create(meta, []checkpoint.SessionBundle{bundle}); err != nil {
+	if err := store.Create(meta, bundles); err != nil {
 		return err
 	}
+	markCheckpointed()
+	markCondensed()
 
-	// Add trailer to commit
-	_ = repo.AddTrailer(git.TrailerCheckpoint, id)
+	if len(bundles) > 0 {
+		summarizeCheckpoint(ctx, s.cfg, store, repo, id)
+	}
 
 	return nil
 }
 
 func (s *ManualCommit) OnPush(ctx context.Context, event *PushEvent) error {
-	slog.Debug("manual-commit: push event (sync checkpoints)")
-	return nil
+	return pushCheckpoints(s.repoDir, s.cfg, event)
 }
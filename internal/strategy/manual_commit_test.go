@@ -0,0 +1,93 @@
+package strategy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func TestManualCommitOnCommit(t *testing.T) {
+	repo := initTestRepo(t)
+	a := &deltaAgent{fakeAgent{name: "fake", sessions: map[string]*types.SessionData{"sess-1": writingSession(repo.Dir)}, done: map[string]bool{}}}
+	useAgent(t, a)
+	s := NewManualCommit(repo.Dir, testConfig())
+	ctx := context.Background()
+
+	// The agent's turn is snapshotted onto the session's shadow branch
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+	require.NoError(t, s.OnAgentResponse(ctx, &AgentResponseEvent{RepoDir: repo.Dir, SessionID: "sess-1", AgentName: "fake"}))
+	worktree, err := repo.WorktreeID()
+	require.NoError(t, err)
+	shadow := git.ShadowBranchName("sess-1", worktree)
+	_, err = repo.BranchHead(shadow)
+	require.NoError(t, err)
+	assert.Empty(t, checkpoints(t, repo), "no checkpoint before the commit")
+
+	// The commit claims its reserved ID and condenses the shadow branch
+	gitCmd(t, repo.Dir, "add", "main.go")
+	gitCmd(t, repo.Dir, "commit", "-q", "-m", "add main")
+	head := gitCmd(t, repo.Dir, "rev-parse", "HEAD")
+	reservations, err := checkpoint.LoadReservations(repo.Dir)
+	require.NoError(t, err)
+	id, err := reservations.Reserve()
+	require.NoError(t, err)
+	require.NoError(t, s.OnCommit(ctx, &CommitEvent{RepoDir: repo.Dir, CommitHash: head, CheckpointID: id}))
+
+	store := checkpoint.NewStore(repo)
+	meta, err := store.Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, head, meta.CommitHash)
+	assert.Equal(t, "main", meta.Branch)
+	assert.Equal(t, "manual-commit", meta.Strategy)
+	require.Len(t, meta.Sessions, 1)
+	assert.Equal(t, "sess-1", meta.Sessions[0].SessionID)
+	assert.Equal(t, []string{"claude-sonnet-4-5-20250929"}, meta.Sessions[0].Models)
+	assert.InDelta(t, 4.5, meta.CostUSD, 1e-9)
+
+	require.NotNil(t, meta.Attribution)
+	assert.Equal(t, 3, meta.Attribution.AgentLines)
+	assert.Equal(t, 100.0, meta.Attribution.AgentPercent)
+	lines, err := store.Lines(id)
+	require.NoError(t, err)
+	assert.Len(t, lines.Files["main.go"], 3)
+
+	require.Len(t, meta.Snapshots, 1)
+	assert.Equal(t, "sess-1", meta.Snapshots[0].SessionID)
+	assert.Equal(t, []string{"main.go"}, meta.Snapshots[0].Files)
+	_, err = repo.BranchHead(shadow)
+	assert.Error(t, err, "shadow branch is deleted once condensed")
+	kept, err := repo.BranchHead(git.SnapshotRefPrefix + id + "/0")
+	require.NoError(t, err)
+	assert.Equal(t, meta.Snapshots[0].Commit, kept)
+
+	found, err := store.ForCommit(head)
+	require.NoError(t, err)
+	assert.Equal(t, id, found.ID)
+}
+
+func TestManualCommitOnCommitWithoutSessions(t *testing.T) {
+	repo := initTestRepo(t)
+	a := &deltaAgent{fakeAgent{name: "fake", sessions: map[string]*types.SessionData{"sess-1": writingSession(repo.Dir)}, done: map[string]bool{"sess-1": true}}}
+	useAgent(t, a)
+	s := NewManualCommit(repo.Dir, testConfig())
+	ctx := context.Background()
+	head := gitCmd(t, repo.Dir, "rev-parse", "HEAD")
+
+	// Without a reservation there is nothing to record
+	require.NoError(t, s.OnCommit(ctx, &CommitEvent{RepoDir: repo.Dir, CommitHash: head}))
+	assert.Empty(t, checkpoints(t, repo))
+
+	// A reserved ID is in the commit's trailer, so it is recorded regardless
+	require.NoError(t, s.OnCommit(ctx, &CommitEvent{RepoDir: repo.Dir, CommitHash: head, CheckpointID: "aabbccddeeff"}))
+	meta, err := checkpoint.NewStore(repo).Get("aabbccddeeff")
+	require.NoError(t, err)
+	assert.Equal(t, head, meta.CommitHash)
+	assert.Empty(t, meta.Sessions)
+}
@@ -0,0 +1,193 @@
+package strategy
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yibudak/open-entire/internal/agent"
+	"github.com/yibudak/open-entire/internal/attribution"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/pricing"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// collectSessions parses every agent session active since the given time
+// and returns one bundle per session, in DetectActive order.
+// Calling the returned function marks the bundles as checkpointed.
+func collectSessions(repoDir string, since time.Time, prices pricing.Table) ([]checkpoint.SessionBundle, func()) {
+	var bundles []checkpoint.SessionBundle
+	var advances []func() error
+	for _, d := range agent.DetectActive(repoDir, since) {
+		slog.Debug("session active", "agent", d.Agent.Name(), "session", d.SessionID, "reason", d.Reason)
+		bundle, advance, err := parseSession(d.Agent, d.SessionID, repoDir, prices)
+		if err != nil {
+			slog.Warn("failed to parse session", "agent", d.Agent.Name(), "session", d.SessionID, "error", err)
+			continue
+		}
+		if isEmpty(bundle.Session) {
+			slog.Debug("nothing new in session since last checkpoint", "agent", d.Agent.Name(), "session", d.SessionID)
+			continue
+		}
+		bundles = append(bundles, bundle)
+		if advance != nil {
+			advances = append(advances, advance)
+		}
+	}
+	return bundles, func() { runAdvances(advances) }
+}
+
+// HasPendingSessions reports whether any agent session has activity the
+// next commit's checkpoint would hold. Commit hooks call it, so nothing is
+// parsed: agents that track what they checkpointed compare file offsets,
+// and for the rest any activity since the last checkpoint counts.
+func HasPendingSessions(repo *git.Repository) bool {
+	since, err := repo.LastCheckpointTime()
+	if err != nil {
+		return false
+	}
+	for _, d := range agent.DetectActive(repo.Dir, since) {
+		pc, ok := d.Agent.(agent.PendingChecker)
+		if !ok || pc.HasPending(d.SessionID, repo.Dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSession parses and prices a single agent session into a checkpoint
+// bundle. Agents that parse incrementally yield only what is new since the
+// last checkpoint, along with a function that records it as checkpointed.
+func parseSession(a agent.Agent, sessionID, repoDir string, prices pricing.Table) (checkpoint.SessionBundle, func() error, error) {
+	var data *types.SessionData
+	var advance func() error
+	var err error
+	if dp, ok := a.(agent.DeltaParser); ok {
+		data, advance, err = dp.ParseSessionDelta(sessionID, repoDir)
+	} else {
+		data, err = a.ParseSession(sessionID, repoDir)
+	}
+	if err != nil {
+		return checkpoint.SessionBundle{}, nil, err
+	}
+
+	prices.PriceSession(data)
+
+	bundle := checkpoint.SessionBundle{
+		Metadata: &types.SessionMetadata{
+			AgentName:  a.Name(),
+			SessionID:  sessionID,
+			Models:     pricing.Models(data),
+			TokenUsage: data.TokenUsage,
+			StartedAt:  data.StartedAt,
+			EndedAt:    data.EndedAt,
+		},
+		Session: data,
+		Prompts: formatPrompts(data.Prompts),
+	}
+
+	if len(data.Transcript) > 0 || advance != nil {
+		bundle.FullTranscript = data.Transcript
+		bundle.Metadata.TranscriptOffset = data.TranscriptOffset
+	} else if data.TranscriptPath != "" {
+		transcript, err := os.ReadFile(data.TranscriptPath)
+		if err != nil {
+			return checkpoint.SessionBundle{}, nil, err
+		}
+		bundle.FullTranscript = transcript
+	}
+
+	return bundle, advance, nil
+}
+
+// isEmpty reports whether a session has no activity to checkpoint.
+func isEmpty(s *types.SessionData) bool {
+	return len(s.Prompts) == 0 && len(s.Responses) == 0 && len(s.ToolCalls) == 0 && len(s.NestedSessions) == 0
+}
+
+// runAdvances marks parsed session deltas as checkpointed. A failure only
+// means the next checkpoint repeats some of this one, so it is not fatal.
+func runAdvances(advances []func() error) {
+	for _, advance := range advances {
+		if err := advance(); err != nil {
+			slog.Warn("failed to record checkpointed transcript offset", "error", err)
+		}
+	}
+}
+
+// attribute fills in line attribution for the commit, per session and
+// overall, and maps the agent's lines to the prompts that wrote them.
+// Commits an agent made itself are attributed to that agent in full.
+func attribute(repo *git.Repository, commitHash string, bundles []checkpoint.SessionBundle) (*types.Attribution, *types.LineAttribution) {
+	tracker := attribution.NewTracker(repo)
+
+	var all []types.ToolCall
+	var edits []attribution.SessionEdits
+	var agentCommit *types.Attribution
+	var agentLines *types.LineAttribution
+	for i, b := range bundles {
+		if b.Session == nil {
+			continue
+		}
+		if a, err := agent.Get(b.Metadata.AgentName); err == nil {
+			if r, ok := a.(agent.CommitRecognizer); ok && r.IsAgentCommit(repo.Dir, commitHash) {
+				attr := tracker.ForAgentCommit(commitHash)
+				b.Metadata.Attribution = attr
+				agentCommit = &attr
+				agentLines = tracker.LinesForAgentCommit(commitHash, attribution.SessionEdits{
+					Session: i,
+					Prompts: b.Session.Prompts,
+				})
+				continue
+			}
+		}
+		calls := attribution.SessionToolCalls(b.Session)
+		b.Metadata.Attribution = tracker.ForCommit(commitHash, calls)
+		all = append(all, calls...)
+		edits = append(edits, attribution.SessionEdits{Session: i, Prompts: b.Session.Prompts, Calls: calls})
+	}
+
+	if agentCommit != nil {
+		return agentCommit, agentLines
+	}
+	attr := tracker.ForCommit(commitHash, all)
+	return &attr, tracker.LinesForCommit(commitHash, edits)
+}
+
+// summarize builds the checkpoint-level session summaries for the given bundles.
+func summarize(bundles []checkpoint.SessionBundle) []types.SessionSummary {
+	summaries := make([]types.SessionSummary, len(bundles))
+	for i, b := range bundles {
+		summaries[i] = types.SessionSummary{
+			Index:      i,
+			AgentName:  b.Metadata.AgentName,
+			SessionID:  b.Metadata.SessionID,
+			Models:     b.Metadata.Models,
+			Prompts:    len(b.Session.Prompts),
+			TokenUsage: b.Metadata.TokenUsage,
+		}
+	}
+	return summaries
+}
+
+// sessionsCost totals what a checkpoint's sessions cost.
+func sessionsCost(sessions []types.SessionSummary) float64 {
+	var total float64
+	for _, s := range sessions {
+		total += s.TokenUsage.CostUSD
+	}
+	return total
+}
+
+func formatPrompts(prompts []types.Prompt) []byte {
+	if len(prompts) == 0 {
+		return nil
+	}
+	parts := make([]string, len(prompts))
+	for i, p := range prompts {
+		parts[i] = strings.TrimSpace(p.Content)
+	}
+	return []byte(strings.Join(parts, checkpoint.PromptSeparator) + "\n")
+}
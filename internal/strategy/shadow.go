@@ -0,0 +1,112 @@
+package strategy
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/session"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// snapshotTurn snapshots the working tree onto the session's shadow branch
+// at the end of an agent turn. HEAD, the index and the user's branches are
+// not touched.
+func snapshotTurn(repo *git.Repository, event *AgentResponseEvent) error {
+	worktree, err := repo.WorktreeID()
+	if err != nil {
+		return err
+	}
+	branch := git.ShadowBranchName(event.SessionID, worktree)
+	msg := fmt.Sprintf("snapshot after %s turn\n\nSession: %s\n", event.AgentName, event.SessionID)
+	commit, err := repo.SnapshotToBranch(branch, msg)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot working tree: %w", err)
+	}
+	if commit == "" {
+		slog.Debug("working tree unchanged since last snapshot", "session", event.SessionID)
+	} else {
+		slog.Debug("snapshot taken", "session", event.SessionID, "branch", branch, "commit", commit)
+	}
+
+	sessions, err := session.NewStore(repo.Dir)
+	if err != nil {
+		return err
+	}
+	return sessions.SetShadowBranch(event.SessionID, event.AgentName, branch)
+}
+
+// condenseShadows gathers the snapshots on the shadow branches of the
+// sessions being checkpointed, oldest first. Calling the returned function
+// once the checkpoint is stored keeps the snapshots reachable under
+// refs/entire/snapshots/<id>/, deletes the shadow branches and marks the
+// sessions condensed.
+func condenseShadows(repo *git.Repository, id string, bundles []checkpoint.SessionBundle) ([]types.Snapshot, func()) {
+	worktree, err := repo.WorktreeID()
+	if err != nil {
+		slog.Warn("failed to identify worktree", "error", err)
+		return nil, func() {}
+	}
+
+	type shadow struct {
+		branch, sessionID, ref, tip string
+	}
+	var shadows []shadow
+	var snapshots []types.Snapshot
+	for i, b := range bundles {
+		branch := git.ShadowBranchName(b.Metadata.SessionID, worktree)
+		if _, err := repo.BranchHead(branch); err != nil {
+			continue
+		}
+		commits, err := repo.SnapshotCommits(branch)
+		if err != nil {
+			slog.Warn("failed to read shadow branch", "branch", branch, "error", err)
+			continue
+		}
+		sh := shadow{branch: branch, sessionID: b.Metadata.SessionID}
+		if len(commits) > 0 {
+			sh.ref = fmt.Sprintf("%s%s/%d", git.SnapshotRefPrefix, id, i)
+			sh.tip = commits[len(commits)-1].Hash
+		}
+		shadows = append(shadows, sh)
+		for _, c := range commits {
+			snapshots = append(snapshots, types.Snapshot{
+				Commit:    c.Hash,
+				AgentName: b.Metadata.AgentName,
+				SessionID: b.Metadata.SessionID,
+				CreatedAt: c.Time,
+				Files:     c.Files,
+			})
+		}
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+
+	finish := func() {
+		sessions, err := session.NewStore(repo.Dir)
+		if err != nil {
+			slog.Warn("failed to open session state", "error", err)
+		}
+		for _, sh := range shadows {
+			if sh.ref != "" {
+				if err := repo.SetRef(sh.ref, sh.tip); err != nil {
+					// Keep the branch rather than lose the snapshots
+					slog.Warn("failed to keep snapshots", "branch", sh.branch, "error", err)
+					continue
+				}
+			}
+			if err := repo.DeleteBranch(sh.branch); err != nil {
+				slog.Warn("failed to delete shadow branch", "branch", sh.branch, "error", err)
+			}
+			if sessions != nil {
+				if err := sessions.CondenseSession(sh.sessionID); err != nil {
+					slog.Warn("failed to mark session condensed", "session", sh.sessionID, "error", err)
+				}
+			}
+		}
+	}
+	return snapshots, finish
+}
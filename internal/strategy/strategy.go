@@ -3,6 +3,15 @@ package strategy
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/redact"
+	"github.com/yibudak/open-entire/internal/summary"
 )
 
 // Strategy defines how and when checkpoints are created.
@@ -18,6 +27,9 @@ type AgentResponseEvent struct {
 	RepoDir   string
 	SessionID string
 	AgentName string
+	// TranscriptPath is where the agent reported the transcript, when it
+	// fired the event through its own hooks.
+	TranscriptPath string
 }
 
 // CommitEvent is fired on git commit.
@@ -26,23 +38,114 @@ type CommitEvent struct {
 	CommitHash string
 	Message    string
 	Branch     string
+	// CheckpointID was reserved for the commit and is already in its
+	// trailer. Without one, the checkpoint gets a new ID and the commit no
+	// trailer.
+	CheckpointID string
 }
 
 // PushEvent is fired on git push.
 type PushEvent struct {
 	RepoDir string
 	Remote  string
+	URL     string
 	Branch  string
+	Refs    []PushRef
+}
+
+// PushRef is one line of the ref list git passes to the pre-push hook.
+type PushRef struct {
+	LocalRef  string
+	LocalSHA  string
+	RemoteRef string
+	RemoteSHA string
 }
 
 // New creates a strategy by name.
-func New(name string, repoDir string) (Strategy, error) {
+func New(name string, repoDir string, cfg *config.Config) (Strategy, error) {
 	switch name {
 	case "manual-commit":
-		return NewManualCommit(repoDir), nil
+		return NewManualCommit(repoDir, cfg), nil
 	case "auto-commit":
-		return NewAutoCommit(repoDir), nil
+		return NewAutoCommit(repoDir, cfg), nil
 	default:
 		return nil, fmt.Errorf("unknown strategy: %s", name)
 	}
 }
+
+// newStore opens the checkpoint store, scrubbing secrets as configured.
+func newStore(repo *git.Repository, cfg *config.Config) (*checkpoint.Store, error) {
+	store := checkpoint.NewStore(repo)
+	if cfg.Redaction.Enabled {
+		r, err := redact.New(cfg.Redaction)
+		if err != nil {
+			return nil, err
+		}
+		store.SetRedactor(r)
+	}
+	return store, nil
+}
+
+// summarizeCheckpoint summarizes a newly stored checkpoint when enabled.
+// It reads the checkpoint back, so only redacted data reaches the
+// summarizer. Backends other than local run in a detached process, since
+// the hooks that create checkpoints block the agent or the commit until
+// they return. A failure leaves the checkpoint without a summary.
+func summarizeCheckpoint(ctx context.Context, cfg *config.Config, store *checkpoint.Store, repo *git.Repository, id string) {
+	opts := cfg.StrategyOptions.Summarize
+	if !opts.Enabled {
+		return
+	}
+	if opts.Backend != "" && opts.Backend != "local" {
+		if err := summarizeInBackground(repo.Dir, id); err != nil {
+			slog.Warn("failed to start summarizer", "id", id, "error", err)
+		}
+		return
+	}
+	s, err := summary.New(opts)
+	if err == nil {
+		_, err = summary.Checkpoint(ctx, s, store, repo, id)
+	}
+	if err != nil {
+		slog.Warn("failed to summarize checkpoint", "id", id, "error", err)
+	}
+}
+
+// summarizeInBackground runs 'open-entire _summarize' for a checkpoint
+// without waiting for it.
+func summarizeInBackground(repoDir, id string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, "_summarize", id)
+	cmd.Dir = repoDir
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// pushCheckpoints pushes the checkpoints branch to the remote being pushed
+// to, merging in checkpoints teammates pushed first.
+func pushCheckpoints(repoDir string, cfg *config.Config, event *PushEvent) error {
+	if !cfg.StrategyOptions.PushCheckpoints || event.Remote == "" {
+		return nil
+	}
+	for _, ref := range event.Refs {
+		if ref.LocalRef == "refs/heads/"+git.CheckpointsBranch {
+			return nil // Already being pushed
+		}
+	}
+
+	repo, err := git.Open(repoDir)
+	if err != nil {
+		return err
+	}
+	if !repo.HasCheckpointsBranch() {
+		return nil
+	}
+
+	slog.Info("pushing checkpoints", "remote", event.Remote)
+	return repo.PushCheckpoints(event.Remote)
+}
@@ -0,0 +1,214 @@
+package strategy
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/internal/agent"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/internal/pricing"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// fakeAgent serves sessions from memory, every one active just now.
+type fakeAgent struct {
+	name     string
+	sessions map[string]*types.SessionData
+	done     map[string]bool
+}
+
+func (a *fakeAgent) Name() string { return a.name }
+
+func (a *fakeAgent) Detect(repoDir string) (string, error) { return "", nil }
+
+func (a *fakeAgent) SessionsSince(repoDir string, since time.Time) ([]types.SessionActivity, error) {
+	var found []types.SessionActivity
+	for id := range a.sessions {
+		found = append(found, types.SessionActivity{SessionID: id, LastActivity: time.Now(), Reason: "fake"})
+	}
+	return found, nil
+}
+
+func (a *fakeAgent) ParseSession(sessionID string, repoDir string) (*types.SessionData, error) {
+	s, ok := a.sessions[sessionID]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (a *fakeAgent) SessionPaths(repoDir string) types.AgentPaths { return types.AgentPaths{} }
+
+// deltaAgent is a fakeAgent that parses incrementally, returning each
+// session until it is advanced and nothing new after.
+type deltaAgent struct{ fakeAgent }
+
+func (a *deltaAgent) ParseSessionDelta(sessionID string, repoDir string) (*types.SessionData, func() error, error) {
+	if a.done[sessionID] {
+		return &types.SessionData{ID: sessionID}, func() error { return nil }, nil
+	}
+	s, err := a.ParseSession(sessionID, repoDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.Transcript = []byte(`{"type":"user","message":"delta"}` + "\n")
+	s.TranscriptOffset = 42
+	advance := func() error {
+		a.done[sessionID] = true
+		return nil
+	}
+	return s, advance, nil
+}
+
+// useAgent registers a under the name "fake", replacing the fake agent of
+// an earlier test. No other agent is registered in this package's tests.
+func useAgent(t *testing.T, a agent.Agent) {
+	t.Helper()
+	require.Equal(t, "fake", a.Name())
+	agent.Register(a)
+}
+
+// initTestRepo creates a repository with a checkpoints branch.
+func initTestRepo(t *testing.T) *git.Repository {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "tester")
+	t.Setenv("GIT_AUTHOR_EMAIL", "tester@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "tester")
+	t.Setenv("GIT_COMMITTER_EMAIL", "tester@example.com")
+
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\n"), 0o644))
+	gitCmd(t, dir, "add", "README.md")
+	gitCmd(t, dir, "commit", "-q", "-m", "initial")
+
+	repo, err := git.Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, repo.EnsureCheckpointsBranch())
+	return repo
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
+// testConfig is the default configuration without summaries.
+func testConfig() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.StrategyOptions.Summarize.Enabled = false
+	return &cfg
+}
+
+// writingSession is a session whose agent wrote main.go in the repository.
+func writingSession(repoDir string) *types.SessionData {
+	at := time.Date(2025, 9, 20, 10, 0, 0, 0, time.UTC)
+	return &types.SessionData{
+		ID:        "sess-1",
+		StartedAt: at,
+		Prompts:   []types.Prompt{{Content: "  write main.go  ", Timestamp: at}},
+		Responses: []types.Response{{
+			Content:    "done",
+			Timestamp:  at.Add(time.Second),
+			Model:      "claude-sonnet-4-5-20250929",
+			TokenUsage: types.TokenUsage{InputTokens: 1_000_000, OutputTokens: 100_000},
+		}},
+		ToolCalls: []types.ToolCall{{
+			Name:      "Write",
+			Input:     `{"file_path":"` + filepath.Join(repoDir, "main.go") + `","content":"package main\n\nfunc main() {}\n"}`,
+			Timestamp: at.Add(time.Second),
+		}},
+	}
+}
+
+func TestParseSession(t *testing.T) {
+	dir := t.TempDir()
+	transcript := filepath.Join(dir, "sess-1.jsonl")
+	require.NoError(t, os.WriteFile(transcript, []byte("{}\n"), 0o644))
+	data := writingSession(dir)
+	data.TranscriptPath = transcript
+	a := &fakeAgent{name: "fake", sessions: map[string]*types.SessionData{"sess-1": data}}
+
+	bundle, advance, err := parseSession(a, "sess-1", dir, pricing.New(nil))
+	require.NoError(t, err)
+	assert.Nil(t, advance)
+	assert.Equal(t, "fake", bundle.Metadata.AgentName)
+	assert.Equal(t, []string{"claude-sonnet-4-5-20250929"}, bundle.Metadata.Models)
+	// $3 per million input and $15 per million output tokens
+	assert.InDelta(t, 4.5, bundle.Metadata.TokenUsage.CostUSD, 1e-9)
+	assert.Equal(t, "write main.go\n", string(bundle.Prompts))
+	assert.Equal(t, "{}\n", string(bundle.FullTranscript))
+	assert.Zero(t, bundle.Metadata.TranscriptOffset)
+
+	_, _, err = parseSession(a, "missing", dir, nil)
+	assert.Error(t, err)
+}
+
+func TestParseSessionDelta(t *testing.T) {
+	dir := t.TempDir()
+	a := &deltaAgent{fakeAgent{name: "fake", sessions: map[string]*types.SessionData{"sess-1": writingSession(dir)}, done: map[string]bool{}}}
+
+	bundle, advance, err := parseSession(a, "sess-1", dir, nil)
+	require.NoError(t, err)
+	require.NotNil(t, advance)
+	assert.Equal(t, `{"type":"user","message":"delta"}`+"\n", string(bundle.FullTranscript))
+	assert.Equal(t, int64(42), bundle.Metadata.TranscriptOffset)
+	assert.Zero(t, bundle.Metadata.TokenUsage.CostUSD, "no prices, no cost")
+	require.NoError(t, advance())
+
+	bundle, _, err = parseSession(a, "sess-1", dir, nil)
+	require.NoError(t, err)
+	assert.True(t, isEmpty(bundle.Session))
+}
+
+func TestCollectSessions(t *testing.T) {
+	dir := t.TempDir()
+	a := &deltaAgent{fakeAgent{name: "fake", sessions: map[string]*types.SessionData{
+		"sess-1": writingSession(dir),
+		"idle":   {ID: "idle"},
+	}, done: map[string]bool{}}}
+	useAgent(t, a)
+
+	bundles, markCheckpointed := collectSessions(dir, time.Time{}, pricing.New(nil))
+	require.Len(t, bundles, 1, "sessions with nothing new are left out")
+	assert.Equal(t, "sess-1", bundles[0].Metadata.SessionID)
+
+	// Until marked, the same work is collected again
+	bundles, _ = collectSessions(dir, time.Time{}, nil)
+	assert.Len(t, bundles, 1)
+	markCheckpointed()
+	bundles, _ = collectSessions(dir, time.Time{}, nil)
+	assert.Empty(t, bundles)
+}
+
+func TestHasPendingSessions(t *testing.T) {
+	repo := initTestRepo(t)
+	a := &fakeAgent{name: "fake", sessions: map[string]*types.SessionData{}}
+	useAgent(t, a)
+	assert.False(t, HasPendingSessions(repo))
+
+	// Agents that cannot tell count any activity as pending
+	a.sessions["sess-1"] = writingSession(repo.Dir)
+	assert.True(t, HasPendingSessions(repo))
+}
+
+// checkpoints lists the checkpoints stored in the repository.
+func checkpoints(t *testing.T, repo *git.Repository) []*types.CheckpointMetadata {
+	t.Helper()
+	list, err := checkpoint.NewStore(repo).List()
+	require.NoError(t, err)
+	return list
+}
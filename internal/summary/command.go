@@ -0,0 +1,44 @@
+package summary
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// Command summarizes by piping the prompt and transcript to an executable,
+// such as `claude -p`, and reading the summary from its output.
+type Command struct {
+	Args    []string
+	Timeout time.Duration
+}
+
+func (c *Command) Name() string { return "command" }
+
+func (c *Command) Summarize(ctx context.Context, in *Input) (*types.Summary, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, c.Args[0], c.Args[1:]...)
+	cmd.Stdin = strings.NewReader(prompt(in))
+	// An agent run as the summarizer fires its own hooks; they must not
+	// record it as a session or summarize again
+	cmd.Env = append(os.Environ(), "ENTIRE_ENABLED=false")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", c.Args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return parseReply(stdout.String(), in)
+}
@@ -0,0 +1,101 @@
+package summary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// defaultAPIKeyEnv holds the API key when the config names no variable.
+const defaultAPIKeyEnv = "OPENAI_API_KEY"
+
+// HTTP summarizes with an OpenAI-compatible chat completions API.
+type HTTP struct {
+	URL    string
+	Model  string
+	APIKey string
+	Client *http.Client
+}
+
+// NewHTTP creates an HTTP summarizer for the API at baseURL, reading the
+// key from the named environment variable.
+func NewHTTP(baseURL, model, apiKeyEnv string, timeout time.Duration) *HTTP {
+	if apiKeyEnv == "" {
+		apiKeyEnv = defaultAPIKeyEnv
+	}
+	return &HTTP{
+		URL:    strings.TrimRight(baseURL, "/") + "/chat/completions",
+		Model:  model,
+		APIKey: os.Getenv(apiKeyEnv),
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (h *HTTP) Name() string { return "http" }
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model,omitempty"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (h *HTTP) Summarize(ctx context.Context, in *Input) (*types.Summary, error) {
+	body, err := json.Marshal(chatRequest{
+		Model:    h.Model,
+		Messages: []chatMessage{{Role: "user", Content: prompt(in)}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.APIKey)
+	}
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s: %s", h.URL, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var chat chatResponse
+	if err := json.Unmarshal(data, &chat); err != nil {
+		return nil, fmt.Errorf("invalid response from %s: %w", h.URL, err)
+	}
+	if len(chat.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response from %s", h.URL)
+	}
+	return parseReply(chat.Choices[0].Message.Content, in)
+}
@@ -0,0 +1,73 @@
+package summary
+
+import (
+	"context"
+	"strings"
+
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+const (
+	maxIntentLen  = 200
+	maxOutcomeLen = 400
+)
+
+// Local builds a summary from the transcript itself, without a model: the
+// first prompt is the intent and the last response the outcome. It is
+// deterministic and never leaves the machine.
+type Local struct{}
+
+func (l *Local) Name() string { return "local" }
+
+func (l *Local) Summarize(ctx context.Context, in *Input) (*types.Summary, error) {
+	return extract(in), nil
+}
+
+func extract(in *Input) *types.Summary {
+	s := &types.Summary{
+		Intent:  "No prompt recorded.",
+		Outcome: "No response recorded.",
+		Files:   in.Files,
+	}
+	if len(in.Prompts) > 0 {
+		s.Intent = truncate(firstParagraph(in.Prompts[0]), maxIntentLen)
+	}
+	if r := lastResponse(in.Transcript); r != "" {
+		s.Outcome = truncate(firstParagraph(r), maxOutcomeLen)
+	}
+	return s
+}
+
+// lastResponse returns the text of the last response in a context.md.
+func lastResponse(transcript string) string {
+	label := checkpoint.ResponseLabel + "\n"
+	i := strings.LastIndex(transcript, "\n"+label)
+	if i < 0 {
+		return ""
+	}
+	text := transcript[i+1+len(label):]
+
+	// The response runs until the next bold label or heading
+	for _, end := range []string{"\n**", "\n#"} {
+		if j := strings.Index(text, end); j >= 0 {
+			text = text[:j]
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+func firstParagraph(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.Index(text, "\n\n"); i >= 0 {
+		text = text[:i]
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func truncate(text string, n int) string {
+	if r := []rune(text); len(r) > n {
+		return strings.TrimSpace(string(r[:n-1])) + "…"
+	}
+	return text
+}
@@ -0,0 +1,177 @@
+// Package summary writes short summaries of checkpointed sessions.
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/internal/git"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// defaultTimeout bounds a summary request when the config sets none.
+const defaultTimeout = 2 * time.Minute
+
+// maxTranscriptLen caps how much of the transcript is sent to a model. Longer
+// transcripts keep their end, where the outcome is.
+const maxTranscriptLen = 100_000
+
+// Input is what a summarizer works from. Everything in it comes from the
+// stored checkpoint, so it has already been through redaction.
+type Input struct {
+	// Transcript is the context.md of each session, concatenated.
+	Transcript string
+	// Prompts are the user prompts across all sessions, in order.
+	Prompts []string
+	// Files are the paths the checkpoint's commit changed.
+	Files []string
+}
+
+// Summarizer turns a checkpoint's sessions into a summary.
+type Summarizer interface {
+	Name() string
+	Summarize(ctx context.Context, in *Input) (*types.Summary, error)
+}
+
+// New creates the summarizer selected by the config.
+func New(opts config.SummarizeOptions) (Summarizer, error) {
+	timeout := defaultTimeout
+	if opts.TimeoutSeconds > 0 {
+		timeout = time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+
+	switch opts.Backend {
+	case "", "local":
+		return &Local{}, nil
+	case "command":
+		if len(opts.Command) == 0 {
+			return nil, fmt.Errorf("summarize backend %q needs a command", opts.Backend)
+		}
+		return &Command{Args: opts.Command, Timeout: timeout}, nil
+	case "http":
+		if opts.URL == "" {
+			return nil, fmt.Errorf("summarize backend %q needs a url", opts.Backend)
+		}
+		return NewHTTP(opts.URL, opts.Model, opts.APIKeyEnv, timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown summarize backend: %s", opts.Backend)
+	}
+}
+
+// Checkpoint summarizes a stored checkpoint and saves the result with it.
+func Checkpoint(ctx context.Context, s Summarizer, store *checkpoint.Store, repo *git.Repository, id string) (*types.Summary, error) {
+	in, err := LoadInput(store, repo, id)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := s.Summarize(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s summarizer failed: %w", s.Name(), err)
+	}
+	summary.Generator = s.Name()
+	if err := store.SaveSummary(id, summary, Render(summary)); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// LoadInput reads a checkpoint's sessions back from the checkpoints branch.
+// Changed files are listed only for checkpoints made on commit, since the
+// commit of an auto checkpoint is just where HEAD was at the time.
+func LoadInput(store *checkpoint.Store, repo *git.Repository, id string) (*Input, error) {
+	meta, err := store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	in := &Input{}
+	var transcripts []string
+	for _, s := range meta.Sessions {
+		if t, err := store.FormattedTranscript(id, s.Index); err == nil {
+			transcripts = append(transcripts, strings.TrimSpace(t))
+		}
+		if prompts, err := store.Prompts(id, s.Index); err == nil {
+			in.Prompts = append(in.Prompts, prompts...)
+		}
+	}
+	in.Transcript = strings.Join(transcripts, "\n\n")
+
+	if meta.Attribution != nil && meta.CommitHash != "" {
+		if files, err := repo.DiffFiles(meta.CommitHash); err == nil {
+			in.Files = files
+		}
+	}
+	return in, nil
+}
+
+// Render formats a summary as the Markdown stored in summary.md.
+func Render(s *types.Summary) []byte {
+	var b strings.Builder
+	b.WriteString("# Summary\n\n")
+	fmt.Fprintf(&b, "**Intent:** %s\n\n", s.Intent)
+	fmt.Fprintf(&b, "**Outcome:** %s\n", s.Outcome)
+	if len(s.Files) > 0 {
+		b.WriteString("\n**Files:**\n\n")
+		for _, f := range s.Files {
+			fmt.Fprintf(&b, "- `%s`\n", f)
+		}
+	}
+	if s.Generator != "" {
+		fmt.Fprintf(&b, "\n_Generated by the %s summarizer._\n", s.Generator)
+	}
+	return []byte(b.String())
+}
+
+// prompt is the instruction sent to model backends, followed by the input.
+func prompt(in *Input) string {
+	var b strings.Builder
+	b.WriteString("Summarize the AI coding session below for someone reading the project's history.\n")
+	b.WriteString("Reply with only a JSON object of this form:\n")
+	b.WriteString(`{"intent": "one sentence: what the developer wanted", "outcome": "one to three sentences: what was done and how it ended", "files": ["paths that were changed"]}`)
+	b.WriteString("\n\n")
+
+	if len(in.Files) > 0 {
+		b.WriteString("Files changed in the commit:\n")
+		for _, f := range in.Files {
+			fmt.Fprintf(&b, "- %s\n", f)
+		}
+		b.WriteString("\n")
+	}
+
+	transcript := in.Transcript
+	if len(transcript) > maxTranscriptLen {
+		transcript = "[earlier transcript omitted]\n" + transcript[len(transcript)-maxTranscriptLen:]
+	}
+	b.WriteString("Transcript:\n\n")
+	b.WriteString(transcript)
+	b.WriteString("\n")
+	return b.String()
+}
+
+// parseReply reads a model's reply. A JSON object is used as is; anything
+// else is kept as the outcome, with the intent and files filled in locally.
+func parseReply(reply string, in *Input) (*types.Summary, error) {
+	reply = strings.TrimSpace(reply)
+	if reply == "" {
+		return nil, fmt.Errorf("empty reply")
+	}
+
+	var s types.Summary
+	if start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}"); start >= 0 && end > start {
+		if err := json.Unmarshal([]byte(reply[start:end+1]), &s); err == nil && (s.Intent != "" || s.Outcome != "") {
+			if len(s.Files) == 0 {
+				s.Files = in.Files
+			}
+			return &s, nil
+		}
+	}
+
+	local := extract(in)
+	local.Outcome = reply
+	return local, nil
+}
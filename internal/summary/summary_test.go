@@ -0,0 +1,155 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/config"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+func testInput() *Input {
+	session := &types.SessionData{
+		ID: "sess-1",
+		Prompts: []types.Prompt{
+			{Content: "Add retries to the HTTP client.\n\nUse exponential backoff."},
+			{Content: "Also cover it with a test"},
+		},
+		Responses: []types.Response{
+			{Content: "I'll look at the client first."},
+			{Content: "Added a retry loop with backoff and a test.\n\nAll tests pass."},
+		},
+	}
+	return &Input{
+		Transcript: string(checkpoint.RenderContext(session)),
+		Prompts:    []string{session.Prompts[0].Content, session.Prompts[1].Content},
+		Files:      []string{"client.go", "client_test.go"},
+	}
+}
+
+func TestLocalSummarize(t *testing.T) {
+	s, err := (&Local{}).Summarize(context.Background(), testInput())
+	require.NoError(t, err)
+
+	assert.Equal(t, "Add retries to the HTTP client.", s.Intent)
+	assert.Equal(t, "Added a retry loop with backoff and a test.", s.Outcome)
+	assert.Equal(t, []string{"client.go", "client_test.go"}, s.Files)
+}
+
+func TestLocalSummarizeEmpty(t *testing.T) {
+	s, err := (&Local{}).Summarize(context.Background(), &Input{})
+	require.NoError(t, err)
+	assert.Equal(t, "No prompt recorded.", s.Intent)
+	assert.Equal(t, "No response recorded.", s.Outcome)
+}
+
+func TestParseReply(t *testing.T) {
+	in := testInput()
+
+	s, err := parseReply("Here you go:\n```json\n{\"intent\": \"Add retries\", \"outcome\": \"Done\"}\n```", in)
+	require.NoError(t, err)
+	assert.Equal(t, "Add retries", s.Intent)
+	assert.Equal(t, "Done", s.Outcome)
+	assert.Equal(t, in.Files, s.Files, "files fall back to the commit's")
+
+	s, err = parseReply("The client now retries failed requests.", in)
+	require.NoError(t, err)
+	assert.Equal(t, "Add retries to the HTTP client.", s.Intent)
+	assert.Equal(t, "The client now retries failed requests.", s.Outcome)
+
+	_, err = parseReply("  \n", in)
+	assert.Error(t, err)
+}
+
+func TestCommandSummarize(t *testing.T) {
+	c := &Command{
+		Args:    []string{"sh", "-c", `grep -q "client.go" && echo '{"intent":"Add retries","outcome":"Retries added","files":["client.go"]}'`},
+		Timeout: 10 * time.Second,
+	}
+	s, err := c.Summarize(context.Background(), testInput())
+	require.NoError(t, err)
+	assert.Equal(t, &types.Summary{Intent: "Add retries", Outcome: "Retries added", Files: []string{"client.go"}}, s)
+
+	c.Args = []string{"sh", "-c", "echo boom >&2; exit 3"}
+	_, err = c.Summarize(context.Background(), testInput())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+}
+
+func TestCommandSummarizeDisablesHooks(t *testing.T) {
+	t.Setenv("ENTIRE_ENABLED", "true")
+	c := &Command{
+		Args:    []string{"sh", "-c", `cat >/dev/null; echo "{\"intent\":\"$ENTIRE_ENABLED\"}"`},
+		Timeout: 10 * time.Second,
+	}
+	s, err := c.Summarize(context.Background(), testInput())
+	require.NoError(t, err)
+	assert.Equal(t, "false", s.Intent)
+}
+
+func TestHTTPSummarize(t *testing.T) {
+	var got chatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+
+		reply := `{"intent":"Add retries","outcome":"Retries added with backoff"}`
+		resp := map[string]any{"choices": []any{map[string]any{"message": map[string]string{"role": "assistant", "content": reply}}}}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	t.Setenv("TEST_SUMMARY_KEY", "test-key")
+	s, err := New(config.SummarizeOptions{Backend: "http", URL: srv.URL + "/v1/", Model: "small-model", APIKeyEnv: "TEST_SUMMARY_KEY"})
+	require.NoError(t, err)
+
+	summary, err := s.Summarize(context.Background(), testInput())
+	require.NoError(t, err)
+	assert.Equal(t, "Add retries", summary.Intent)
+	assert.Equal(t, "Retries added with backoff", summary.Outcome)
+
+	assert.Equal(t, "small-model", got.Model)
+	require.Len(t, got.Messages, 1)
+	assert.Contains(t, got.Messages[0].Content, "Add retries to the HTTP client.")
+}
+
+func TestHTTPSummarizeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	_, err := NewHTTP(srv.URL, "", "", time.Second).Summarize(context.Background(), testInput())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rate limited")
+}
+
+func TestNew(t *testing.T) {
+	s, err := New(config.SummarizeOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "local", s.Name())
+
+	_, err = New(config.SummarizeOptions{Backend: "command"})
+	assert.Error(t, err)
+	_, err = New(config.SummarizeOptions{Backend: "http"})
+	assert.Error(t, err)
+	_, err = New(config.SummarizeOptions{Backend: "magic"})
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	md := string(Render(&types.Summary{Intent: "Add retries", Outcome: "Done", Files: []string{"client.go"}, Generator: "local"}))
+	assert.True(t, strings.HasPrefix(md, "# Summary\n"))
+	assert.Contains(t, md, "**Intent:** Add retries")
+	assert.Contains(t, md, "- `client.go`")
+	assert.Contains(t, md, "local summarizer")
+}
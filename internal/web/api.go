@@ -3,9 +3,15 @@ package web
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/pricing"
+	"github.com/yibudak/open-entire/internal/search"
+	"github.com/yibudak/open-entire/internal/stats"
 )
 
 func (s *Server) apiListCheckpoints(w http.ResponseWriter, r *http.Request) {
@@ -60,8 +66,8 @@ func (s *Server) apiGetSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transcript, _ := store.FormattedTranscript(id, idx)
-	rawTranscript, _ := store.RawTranscript(id, idx)
+	transcript, _ := store.FormattedTranscript(cp.ID, idx)
+	rawTranscript, _ := store.RawTranscript(cp.ID, idx)
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"session":    cp.Sessions[idx],
@@ -70,6 +76,98 @@ func (s *Server) apiGetSession(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// searchResult is a search match with a link to where it was found.
+type searchResult struct {
+	search.Result
+	URL string `json:"url"`
+}
+
+func (s *Server) apiSearch(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := search.Query{
+		Text:   params.Get("q"),
+		Agent:  params.Get("agent"),
+		Branch: params.Get("branch"),
+		Author: params.Get("author"),
+		Tool:   params.Get("tool"),
+	}
+	q.Limit, _ = strconv.Atoi(params.Get("limit"))
+	for _, f := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &q.Since}, {"until", &q.Until}} {
+		if v := params.Get(f.name); v != "" {
+			t, err := search.ParseTime(v)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			*f.dst = t
+		}
+	}
+	if strings.TrimSpace(q.Text) == "" && q.Tool == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing query"})
+		return
+	}
+
+	idx, err := search.Load(s.repoDir, s.repo)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	results := []searchResult{}
+	for _, res := range idx.Search(q) {
+		url := "/checkpoints/" + res.CheckpointID
+		if res.Session >= 0 {
+			url += "/sessions/" + strconv.Itoa(res.Session)
+		}
+		results = append(results, searchResult{Result: res, URL: url})
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (s *Server) apiCost(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	by := pricing.ByAgent
+	if v := params.Get("by"); v != "" {
+		var err error
+		if by, err = pricing.ParseGroupBy(v); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	var since time.Time
+	if v := params.Get("since"); v != "" {
+		var err error
+		if since, err = search.ParseTime(v); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	checkpoints, err := checkpoint.NewStore(s.repo).List()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, pricing.Report(checkpoints, by, since))
+}
+
+func (s *Server) apiStats(w http.ResponseWriter, r *http.Request) {
+	opts, err := statsOptions(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	checkpoints, err := stats.Load(checkpoint.NewStore(s.repo))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, stats.Build(checkpoints, opts))
+}
+
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,74 @@
+package web
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+)
+
+// bar is one bar of a chart.
+type bar struct {
+	Label string
+	Value float64
+}
+
+// Chart geometry, in SVG user units.
+const (
+	chartWidth  = 720
+	chartHeight = 180
+	chartTop    = 16
+	chartBottom = 28
+	chartLeft   = 8
+	chartRight  = 8
+)
+
+// barChart renders bars as an inline SVG chart, labelled with format. A
+// fixed max scales the bars against it instead of the largest value, which
+// keeps percentages on a 0–100 scale.
+func barChart(bars []bar, max float64, format func(float64) string) template.HTML {
+	if len(bars) == 0 {
+		return ""
+	}
+	if max == 0 {
+		for _, b := range bars {
+			if b.Value > max {
+				max = b.Value
+			}
+		}
+	}
+
+	plotHeight := float64(chartHeight - chartTop - chartBottom)
+	slot := float64(chartWidth-chartLeft-chartRight) / float64(len(bars))
+	width := min(slot*0.7, 48)
+	// Periods are labelled only as often as they fit.
+	every := 1
+	if slot < 72 {
+		every = int(72/slot) + 1
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg class="chart" viewBox="0 0 %d %d" role="img">`, chartWidth, chartHeight)
+	fmt.Fprintf(&sb, `<line class="axis" x1="%d" y1="%d" x2="%d" y2="%d"/>`,
+		chartLeft, chartHeight-chartBottom, chartWidth-chartRight, chartHeight-chartBottom)
+	for i, b := range bars {
+		h := 0.0
+		if max > 0 {
+			h = b.Value / max * plotHeight
+		}
+		x := chartLeft + float64(i)*slot + (slot-width)/2
+		y := float64(chartHeight-chartBottom) - h
+		label := html.EscapeString(b.Label)
+		value := html.EscapeString(format(b.Value))
+		fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f"><title>%s: %s</title></rect>`,
+			x, y, width, h, label, value)
+		if len(bars) <= 16 {
+			fmt.Fprintf(&sb, `<text class="value" x="%.1f" y="%.1f">%s</text>`, x+width/2, y-4, value)
+		}
+		if i%every == 0 {
+			fmt.Fprintf(&sb, `<text class="label" x="%.1f" y="%d">%s</text>`, x+width/2, chartHeight-10, label)
+		}
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
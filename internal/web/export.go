@@ -0,0 +1,117 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/yibudak/open-entire/internal/pricing"
+	"github.com/yibudak/open-entire/pkg/types"
+)
+
+// ExportCheckpoint is a checkpoint to include in a standalone HTML export.
+type ExportCheckpoint struct {
+	Checkpoint *types.CheckpointMetadata
+	Diff       string
+	// Transcripts holds each session's context.md, by session index.
+	Transcripts []string
+}
+
+// exportFuncs link to sections of the same page, since an export has no
+// server behind it.
+var exportFuncs = template.FuncMap{
+	"checkpointURL": func(id string) string {
+		return "#checkpoint-" + id
+	},
+	"sessionURL": func(id string, idx int) string {
+		return fmt.Sprintf("#checkpoint-%s-session-%d", id, idx)
+	},
+	// An export has no blame view to link files to.
+	"blameURL": func(path string) string {
+		return ""
+	},
+	"usd": pricing.Format,
+}
+
+type exportSection struct {
+	ID         string
+	Checkpoint template.HTML
+	Sessions   []template.HTML
+}
+
+// RenderExport writes checkpoints as one self-contained HTML page, using
+// the viewer's own checkpoint and session templates with the stylesheet
+// and script inlined.
+func RenderExport(w io.Writer, title string, checkpoints []ExportCheckpoint) error {
+	cpTmpl, err := parseExportTemplate("checkpoint_detail.html")
+	if err != nil {
+		return err
+	}
+	sessTmpl, err := parseExportTemplate("session_detail.html")
+	if err != nil {
+		return err
+	}
+
+	sections := make([]exportSection, 0, len(checkpoints))
+	for _, cp := range checkpoints {
+		section := exportSection{ID: cp.Checkpoint.ID}
+		section.Checkpoint, err = executeContent(cpTmpl, map[string]interface{}{
+			"Checkpoint": cp.Checkpoint,
+			"Diff":       cp.Diff,
+		})
+		if err != nil {
+			return err
+		}
+		for i, transcript := range cp.Transcripts {
+			html, err := executeContent(sessTmpl, map[string]interface{}{
+				"Checkpoint":   cp.Checkpoint,
+				"SessionIndex": i,
+				"Transcript":   transcript,
+			})
+			if err != nil {
+				return err
+			}
+			section.Sessions = append(section.Sessions, html)
+		}
+		sections = append(sections, section)
+	}
+
+	css, err := staticFS.ReadFile("static/style.css")
+	if err != nil {
+		return err
+	}
+	js, err := staticFS.ReadFile("static/app.js")
+	if err != nil {
+		return err
+	}
+
+	page, err := template.ParseFS(templatesFS, "templates/export.html")
+	if err != nil {
+		return fmt.Errorf("failed to parse export template: %w", err)
+	}
+	return page.ExecuteTemplate(w, "export", map[string]interface{}{
+		"Title":      title,
+		"ExportedAt": time.Now(),
+		"Sections":   sections,
+		"CSS":        template.CSS(css),
+		"JS":         template.JS(js),
+	})
+}
+
+func parseExportTemplate(name string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(exportFuncs).ParseFS(templatesFS, "templates/"+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+func executeContent(tmpl *template.Template, data interface{}) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "content", data); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
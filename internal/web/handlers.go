@@ -5,9 +5,14 @@ import (
 	"html/template"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/yibudak/open-entire/internal/blame"
 	"github.com/yibudak/open-entire/internal/checkpoint"
+	"github.com/yibudak/open-entire/internal/pricing"
+	"github.com/yibudak/open-entire/internal/search"
+	"github.com/yibudak/open-entire/internal/stats"
 )
 
 func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
@@ -18,10 +23,17 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		checkpoints = nil
 	}
 
+	var totalCost float64
+	for _, cp := range checkpoints {
+		totalCost += cp.CostUSD
+	}
+
 	data := map[string]interface{}{
 		"Title":       "Entire — Dashboard",
 		"RepoDir":     s.repoDir,
 		"Checkpoints": checkpoints,
+		"TotalCost":   totalCost,
+		"CostByAgent": pricing.Report(checkpoints, pricing.ByAgent, time.Time{}),
 	}
 
 	s.renderTemplate(w, "dashboard.html", data)
@@ -52,6 +64,11 @@ func (s *Server) handleCheckpointDetail(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Checkpoint not found", http.StatusNotFound)
 		return
 	}
+	if cp.ID != id {
+		// Looked up by commit hash
+		http.Redirect(w, r, "/checkpoints/"+cp.ID, http.StatusFound)
+		return
+	}
 
 	// Get diff if commit hash exists
 	var diff string
@@ -84,7 +101,7 @@ func (s *Server) handleSessionDetail(w http.ResponseWriter, r *http.Request) {
 		sessionIdx = parseIdxInt(idx)
 	}
 
-	transcript, _ := store.FormattedTranscript(id, sessionIdx)
+	transcript, _ := store.FormattedTranscript(cp.ID, sessionIdx)
 
 	data := map[string]interface{}{
 		"Title":        "Entire — Session",
@@ -96,8 +113,123 @@ func (s *Server) handleSessionDetail(w http.ResponseWriter, r *http.Request) {
 	s.renderTemplate(w, "session_detail.html", data)
 }
 
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	opts, err := statsOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	checkpoints, err := stats.Load(checkpoint.NewStore(s.repo))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	report := stats.Build(checkpoints, opts)
+
+	// The charts show whole periods; a grouping only splits the table.
+	periods := report
+	if opts.GroupBy != "" {
+		whole := opts
+		whole.GroupBy = ""
+		periods = stats.Build(checkpoints, whole)
+	}
+	var cps, tokens, cost, agent, cache []bar
+	for _, row := range periods.Rows {
+		cps = append(cps, bar{row.Period, float64(row.Checkpoints)})
+		tokens = append(tokens, bar{row.Period, float64(row.TokenUsage.InputTokens + row.TokenUsage.OutputTokens)})
+		cost = append(cost, bar{row.Period, row.TokenUsage.CostUSD})
+		agent = append(agent, bar{row.Period, row.AgentPercent})
+		cache = append(cache, bar{row.Period, row.CacheHitRatio * 100})
+	}
+	count := func(v float64) string { return fmt.Sprintf("%.0f", v) }
+	percent := func(v float64) string { return fmt.Sprintf("%.0f%%", v) }
+
+	data := map[string]interface{}{
+		"Title":   "Entire — Stats",
+		"Report":  report,
+		"Options": opts,
+		"Charts": []struct {
+			Title string
+			SVG   template.HTML
+		}{
+			{"Checkpoints", barChart(cps, 0, count)},
+			{"Tokens", barChart(tokens, 0, count)},
+			{"Cost", barChart(cost, 0, pricing.Format)},
+			{"Agent attribution", barChart(agent, 100, percent)},
+			{"Cache hit ratio", barChart(cache, 100, percent)},
+		},
+	}
+
+	s.renderTemplate(w, "stats.html", data)
+}
+
+// statsOptions reads a stats report's options from the query string.
+func statsOptions(r *http.Request) (stats.Options, error) {
+	params := r.URL.Query()
+	opts := stats.Options{Interval: stats.Day, TopFiles: 10}
+	var err error
+	if v := params.Get("interval"); v != "" {
+		if opts.Interval, err = stats.ParseInterval(v); err != nil {
+			return opts, err
+		}
+	}
+	if v := params.Get("by"); v != "" {
+		if opts.GroupBy, err = pricing.ParseGroupBy(v); err != nil {
+			return opts, err
+		}
+	}
+	if v := params.Get("since"); v != "" {
+		if opts.Since, err = search.ParseTime(v); err != nil {
+			return opts, err
+		}
+	}
+	if v := params.Get("until"); v != "" {
+		if opts.Until, err = search.ParseTime(v); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func (s *Server) handleBlame(w http.ResponseWriter, r *http.Request) {
+	path := chi.URLParam(r, "*")
+	rev := r.URL.Query().Get("rev")
+	lines, err := blame.File(s.repo, checkpoint.NewStore(s.repo), path, rev)
+	if err != nil {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title": "Entire — Blame " + path,
+		"Path":  path,
+		"Rev":   rev,
+		"Lines": lines,
+	}
+
+	s.renderTemplate(w, "blame.html", data)
+}
+
+// serverFuncs link pages to each other on the running server.
+var serverFuncs = template.FuncMap{
+	"checkpointURL": func(id string) string {
+		return "/checkpoints/" + id
+	},
+	"sessionURL": func(id string, idx int) string {
+		return fmt.Sprintf("/checkpoints/%s/sessions/%d", id, idx)
+	},
+	"blameURL": func(path string) string {
+		return "/blame/" + path
+	},
+	"usd": pricing.Format,
+	"percent": func(ratio float64) string {
+		return fmt.Sprintf("%.0f%%", ratio*100)
+	},
+}
+
 func (s *Server) renderTemplate(w http.ResponseWriter, name string, data interface{}) {
-	tmpl, err := template.ParseFS(templatesFS, "templates/base.html", "templates/"+name)
+	tmpl, err := template.New("base").Funcs(serverFuncs).ParseFS(templatesFS, "templates/base.html", "templates/"+name)
 	if err != nil {
 		slog.Error("template parse error", "template", name, "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
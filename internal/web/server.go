@@ -38,12 +38,17 @@ func (s *Server) setupRoutes() {
 	r.Get("/checkpoints", s.handleCheckpointsList)
 	r.Get("/checkpoints/{id}", s.handleCheckpointDetail)
 	r.Get("/checkpoints/{id}/sessions/{idx}", s.handleSessionDetail)
+	r.Get("/stats", s.handleStats)
+	r.Get("/blame/*", s.handleBlame)
 
 	// JSON API
 	r.Route("/api", func(r chi.Router) {
 		r.Get("/checkpoints", s.apiListCheckpoints)
 		r.Get("/checkpoints/{id}", s.apiGetCheckpoint)
 		r.Get("/checkpoints/{id}/sessions/{idx}", s.apiGetSession)
+		r.Get("/search", s.apiSearch)
+		r.Get("/cost", s.apiCost)
+		r.Get("/stats", s.apiStats)
 	})
 
 	s.router = r
@@ -1,8 +1,7 @@
-// Diff mode toggle
-function toggleDiffMode() {
-    const btn = document.getElementById('diff-toggle');
-    const diff = document.getElementById('diff-content');
-    if (!btn || !diff) return;
+// Diff mode toggle; the diff follows the button's controls
+function toggleDiffMode(btn) {
+    const diff = btn.parentElement.nextElementSibling;
+    if (!diff) return;
 
     if (btn.textContent === 'Side-by-side') {
         btn.textContent = 'Unified';
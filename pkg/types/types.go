@@ -6,8 +6,14 @@ import "time"
 type SessionPhase string
 
 const (
+	// SessionActive is a session whose agent is working on a turn.
 	SessionActive    SessionPhase = "ACTIVE"
+	// SessionIdle is a session waiting for the next prompt.
+	SessionIdle      SessionPhase = "IDLE"
+	// SessionEnded is a session the agent has closed.
 	SessionEnded     SessionPhase = "ENDED"
+	// SessionCondensed is a session whose snapshots are all part of a
+	// checkpoint.
 	SessionCondensed SessionPhase = "CONDENSED"
 )
 
@@ -24,6 +30,11 @@ type SessionData struct {
 	TokenUsage    TokenUsage      `json:"token_usage"`
 	FilesChanged  []string        `json:"files_changed"`
 	NestedSessions []SessionData  `json:"nested_sessions,omitempty"`
+	TranscriptPath string         `json:"transcript_path,omitempty"`
+	// Transcript holds the raw transcript when it is only part of a file.
+	Transcript []byte `json:"-"`
+	// TranscriptOffset is the byte offset in the file where Transcript starts.
+	TranscriptOffset int64 `json:"-"`
 }
 
 // Prompt represents a user prompt in a session.
@@ -38,15 +49,20 @@ type Response struct {
 	Content    string     `json:"content"`
 	Timestamp  time.Time  `json:"timestamp"`
 	RequestID  string     `json:"request_id"`
+	Model      string     `json:"model,omitempty"`
 	TokenUsage TokenUsage `json:"token_usage"`
 }
 
 // ToolCall represents a tool invocation during a session.
 type ToolCall struct {
+	ID        string    `json:"id,omitempty"`
 	Name      string    `json:"name"`
 	Input     string    `json:"input"`
 	Output    string    `json:"output"`
+	IsError   bool      `json:"is_error,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
+	// DurationMS is the time from the call to its result, when known.
+	DurationMS int64 `json:"duration_ms,omitempty"`
 	RequestID string    `json:"request_id"`
 }
 
@@ -57,6 +73,9 @@ type TokenUsage struct {
 	CacheCreation     int `json:"cache_creation"`
 	CacheReads        int `json:"cache_reads"`
 	APICalls          int `json:"api_calls"`
+	// CostUSD is what the tokens cost. A session's cost includes its
+	// subagents.
+	CostUSD float64 `json:"cost_usd,omitempty"`
 }
 
 // CheckpointMetadata is stored on the entire/checkpoints/v1 branch.
@@ -70,6 +89,53 @@ type CheckpointMetadata struct {
 	Strategy     string           `json:"strategy"`
 	Sessions     []SessionSummary `json:"sessions"`
 	Attribution  *Attribution     `json:"attribution,omitempty"`
+	Redaction    *RedactionReport `json:"redaction,omitempty"`
+	Summary      *Summary         `json:"summary,omitempty"`
+	// CostUSD is the cost of the checkpoint's sessions.
+	CostUSD float64 `json:"cost_usd,omitempty"`
+	// Snapshots are the working tree states the agents left at the end of
+	// each turn since the previous commit, oldest first.
+	Snapshots []Snapshot `json:"snapshots,omitempty"`
+	// Lines is stored beside the metadata, as lines.json, rather than in it.
+	Lines *LineAttribution `json:"-"`
+	// RewrittenFrom are the hashes the commit had before it was amended or
+	// rebased, oldest first. CommitHash is always the latest.
+	RewrittenFrom []string `json:"rewritten_from,omitempty"`
+}
+
+// Snapshot is the working tree, untracked files included, as an agent left
+// it at the end of a turn. Commit is kept reachable under
+// refs/entire/snapshots/ so the tree can be restored.
+type Snapshot struct {
+	Commit    string    `json:"commit"`
+	AgentName string    `json:"agent_name"`
+	SessionID string    `json:"session_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Files are the paths changed since the previous snapshot.
+	Files []string `json:"files,omitempty"`
+}
+
+// Summary is a short account of what a checkpoint's sessions set out to do
+// and what came of it. The rendered form is stored next to the metadata as
+// summary.md.
+type Summary struct {
+	Intent    string   `json:"intent"`
+	Outcome   string   `json:"outcome"`
+	Files     []string `json:"files,omitempty"`
+	Generator string   `json:"generator"`
+}
+
+// RedactionReport records the secrets scrubbed from a checkpoint.
+type RedactionReport struct {
+	Total  int            `json:"total"`
+	ByRule map[string]int `json:"by_rule,omitempty"`
+	Files  []RedactedFile `json:"files,omitempty"`
+}
+
+// RedactedFile is the number of secrets scrubbed from one checkpoint file.
+type RedactedFile struct {
+	Path  string `json:"path"`
+	Count int    `json:"count"`
 }
 
 // SessionSummary is a lightweight view of a session within a checkpoint.
@@ -77,6 +143,8 @@ type SessionSummary struct {
 	Index      int        `json:"index"`
 	AgentName  string     `json:"agent_name"`
 	SessionID  string     `json:"session_id"`
+	Models     []string   `json:"models,omitempty"`
+	Prompts    int        `json:"prompts,omitempty"`
 	TokenUsage TokenUsage `json:"token_usage"`
 }
 
@@ -84,10 +152,14 @@ type SessionSummary struct {
 type SessionMetadata struct {
 	AgentName    string      `json:"agent_name"`
 	SessionID    string      `json:"session_id"`
+	Models       []string    `json:"models,omitempty"`
 	TokenUsage   TokenUsage  `json:"token_usage"`
 	Attribution  Attribution `json:"attribution"`
 	StartedAt    time.Time   `json:"started_at"`
 	EndedAt      *time.Time  `json:"ended_at,omitempty"`
+	// TranscriptOffset is where the stored transcript slice starts in the
+	// agent's transcript file; earlier checkpoints hold what came before.
+	TranscriptOffset int64 `json:"transcript_offset,omitempty"`
 }
 
 // Attribution tracks AI vs human line contribution.
@@ -95,6 +167,38 @@ type Attribution struct {
 	AgentPercent  float64 `json:"agent_percent"`
 	AgentLines    int     `json:"agent_lines"`
 	TotalLines    int     `json:"total_lines"`
+	Files         []FileAttribution `json:"files,omitempty"`
+}
+
+// FileAttribution is the AI vs human breakdown for a single file.
+type FileAttribution struct {
+	Path       string `json:"path"`
+	AgentLines int    `json:"agent_lines"`
+	HumanLines int    `json:"human_lines"`
+}
+
+// LineAttribution records which of the lines a commit added an agent
+// wrote. Added lines it does not list were written by a human.
+type LineAttribution struct {
+	Files map[string][]AgentLine `json:"files"`
+}
+
+// AgentLine is a line an agent wrote, numbered as in the committed file.
+type AgentLine struct {
+	Line int `json:"line"`
+	// Session is the index of the writing session in the checkpoint, and
+	// Prompt the index of the prompt it was answering among the session's
+	// stored prompts.
+	Session int `json:"session"`
+	Prompt  int `json:"prompt"`
+}
+
+// SessionActivity records that an agent session was active and why it was
+// considered so.
+type SessionActivity struct {
+	SessionID    string    `json:"session_id"`
+	LastActivity time.Time `json:"last_activity"`
+	Reason       string    `json:"reason"`
 }
 
 // AgentPaths holds filesystem paths for an agent's data.